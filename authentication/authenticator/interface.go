@@ -35,6 +35,14 @@ func (f TokenFunc) AuthenticateToken(ctx context.Context, token string) (*Respon
 	return f(ctx, token)
 }
 
+// Named is implemented by authenticators that can report a name, which is
+// recorded in Response.AuthMethod when they authenticate a request.
+type Named interface {
+	Name() string
+}
+
 type Response struct {
 	User user.Info
+	// AuthMethod is the name of the authenticator that authenticated the request, e.g. "token".
+	AuthMethod string
 }
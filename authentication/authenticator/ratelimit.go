@@ -0,0 +1,116 @@
+package authenticator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/x893675/valhalla-common/cache"
+	"github.com/x893675/valhalla-common/constant"
+	"github.com/x893675/valhalla-common/logger"
+)
+
+// ErrTooManyFailedAttempts is returned when a source exceeded the allowed failed attempts.
+var ErrTooManyFailedAttempts = errors.New("too many failed authentication attempts")
+
+type rateLimitedRequest struct {
+	inner  Request
+	cache  cache.Interface
+	limit  int
+	window time.Duration
+}
+
+// RateLimited wraps a request authenticator and counts failed attempts per
+// source IP. Once limit failures are recorded within window, further requests
+// from that IP are rejected without calling inner, even with valid credentials.
+// Failures only expire with the window, a successful authentication doesn't reset
+// them, so a valid credential can't be used to keep guessing others.
+//
+// Only errors returned by inner count as failures. A result of (nil, false, nil)
+// means inner does not apply to the request, e.g. an anonymous request or another
+// auth scheme in a union, and is not counted.
+//
+// The source IP is taken from req.RemoteAddr, forwarded headers are not trusted.
+func RateLimited(inner Request, c cache.Interface, limit int, window time.Duration) (Request, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("rate limit must be positive, got %d", limit)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("rate limit window must be positive, got %s", window)
+	}
+	return &rateLimitedRequest{
+		inner:  inner,
+		cache:  c,
+		limit:  limit,
+		window: window,
+	}, nil
+}
+
+// Name implements Named by reporting the name of the wrapped authenticator.
+func (r *rateLimitedRequest) Name() string {
+	if named, ok := r.inner.(Named); ok {
+		return named.Name()
+	}
+	return ""
+}
+
+func (r *rateLimitedRequest) AuthenticateRequest(req *http.Request) (*Response, bool, error) {
+	ctx := req.Context()
+	key := fmt.Sprintf(constant.AuthFailureCacheKeyFormat, sourceIP(req))
+
+	// reserve the attempt before calling inner, so concurrent guesses can't all
+	// pass the limit check; the reservation is released unless inner fails
+	attempts, err := r.reserve(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if attempts > int64(r.limit) {
+		r.release(ctx, key)
+		return nil, false, ErrTooManyFailedAttempts
+	}
+
+	resp, ok, err := r.inner.AuthenticateRequest(req)
+	// keep the reservation as a recorded failure only if inner failed
+	if err == nil {
+		r.release(ctx, key)
+	}
+	return resp, ok, err
+}
+
+// reserve 原子地增加尝试次数并返回增加后的值，窗口从第一次尝试开始计算
+func (r *rateLimitedRequest) reserve(ctx context.Context, key string) (int64, error) {
+	// 先以窗口为过期时间创建计数，Incr 会保留已有的过期时间
+	created, err := r.cache.SetNX(ctx, key, 0, r.window)
+	if err != nil {
+		return 0, err
+	}
+	n, err := r.cache.Incr(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	// 计数在 SetNX 和 Incr 之间过期时 Incr 会创建不过期的 key，需要重新设置过期时间
+	if !created && n == 1 {
+		if err := r.cache.Expire(ctx, key, r.window); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// release 撤销一次未计为失败的尝试，计数已过期时不做处理，避免创建不过期的计数
+func (r *rateLimitedRequest) release(ctx context.Context, key string) {
+	if _, _, err := r.cache.IncrByIfExists(ctx, key, -1); err != nil {
+		logger.Warnf("failed to release authentication attempt: %s", err)
+	}
+}
+
+func sourceIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,223 @@
+package authenticator
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/x893675/valhalla-common/authentication/user"
+	"github.com/x893675/valhalla-common/cache"
+	"github.com/x893675/valhalla-common/constant"
+)
+
+func TestRateLimited(t *testing.T) {
+	c, err := cache.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory() error = %v", err)
+	}
+
+	inner := RequestFunc(func(req *http.Request) (*Response, bool, error) {
+		if req.Header.Get("Authorization") == "Bearer valid" {
+			return &Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+		}
+		return nil, false, errors.New("invalid token")
+	})
+	auth, err := RateLimited(inner, c, 3, time.Minute)
+	if err != nil {
+		t.Fatalf("RateLimited() error = %v", err)
+	}
+
+	newRequest := func(ip, token string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":12345"
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	// 成功认证不计入失败次数
+	if _, ok, err := auth.AuthenticateRequest(newRequest("10.0.0.1", "valid")); !ok || err != nil {
+		t.Fatalf("AuthenticateRequest() = %v, %v, want ok", ok, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, ok, err := auth.AuthenticateRequest(newRequest("10.0.0.1", "bad"))
+		if ok || err == nil || errors.Is(err, ErrTooManyFailedAttempts) {
+			t.Fatalf("attempt %d: AuthenticateRequest() = %v, %v, want inner error", i, ok, err)
+		}
+	}
+
+	// 达到限制后即使凭证正确也被拒绝
+	_, ok, err := auth.AuthenticateRequest(newRequest("10.0.0.1", "valid"))
+	if ok || !errors.Is(err, ErrTooManyFailedAttempts) {
+		t.Errorf("AuthenticateRequest() = %v, %v, want %v", ok, err, ErrTooManyFailedAttempts)
+	}
+
+	// 其他来源不受影响
+	if _, ok, err := auth.AuthenticateRequest(newRequest("10.0.0.2", "valid")); !ok || err != nil {
+		t.Errorf("AuthenticateRequest() from other IP = %v, %v, want ok", ok, err)
+	}
+}
+
+func TestRateLimitedSuccessDoesNotReset(t *testing.T) {
+	c, err := cache.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory() error = %v", err)
+	}
+
+	var calls int
+	inner := RequestFunc(func(req *http.Request) (*Response, bool, error) {
+		calls++
+		if req.Header.Get("Authorization") == "Bearer valid" {
+			return &Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+		}
+		return nil, false, errors.New("invalid token")
+	})
+	auth, err := RateLimited(inner, c, 3, time.Minute)
+	if err != nil {
+		t.Fatalf("RateLimited() error = %v", err)
+	}
+	newRequest := func(token string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	// 持有一个有效凭证时，穿插成功的认证也不能重置失败次数
+	for i := 0; i < 100; i++ {
+		token := "guess"
+		if i%2 == 1 {
+			token = "valid"
+		}
+		_, _, _ = auth.AuthenticateRequest(newRequest(token))
+	}
+	// 3 次失败前有 2 次成功，之后的请求都被拒绝
+	if calls != 5 {
+		t.Errorf("inner called %d times, want 5", calls)
+	}
+	if _, ok, err := auth.AuthenticateRequest(newRequest("valid")); ok || !errors.Is(err, ErrTooManyFailedAttempts) {
+		t.Errorf("AuthenticateRequest() = %v, %v, want %v", ok, err, ErrTooManyFailedAttempts)
+	}
+}
+
+func TestRateLimitedReleaseAfterExpiry(t *testing.T) {
+	c, err := cache.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	key := fmt.Sprintf(constant.AuthFailureCacheKeyFormat, sourceIP(req))
+	// 认证过程中计数过期，撤销尝试时不能创建不过期的计数
+	inner := RequestFunc(func(req *http.Request) (*Response, bool, error) {
+		_ = c.Remove(req.Context(), key)
+		return &Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+	})
+	auth, err := RateLimited(inner, c, 3, time.Minute)
+	if err != nil {
+		t.Fatalf("RateLimited() error = %v", err)
+	}
+	if _, ok, err := auth.AuthenticateRequest(req); !ok || err != nil {
+		t.Fatalf("AuthenticateRequest() = %v, %v, want ok", ok, err)
+	}
+	if exist, _ := c.Exist(req.Context(), key); exist {
+		t.Error("releasing the attempt should not recreate an expired counter")
+	}
+}
+
+func TestRateLimitedNotApplicable(t *testing.T) {
+	c, err := cache.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory() error = %v", err)
+	}
+	// 匿名请求或其他认证方式的请求不计入失败次数
+	inner := RequestFunc(func(req *http.Request) (*Response, bool, error) {
+		return nil, false, nil
+	})
+	auth, err := RateLimited(inner, c, 1, time.Minute)
+	if err != nil {
+		t.Fatalf("RateLimited() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := 0; i < 3; i++ {
+		if _, ok, err := auth.AuthenticateRequest(req); ok || err != nil {
+			t.Fatalf("attempt %d: AuthenticateRequest() = %v, %v, want not applicable", i, ok, err)
+		}
+	}
+}
+
+func TestRateLimitedConcurrent(t *testing.T) {
+	c, err := cache.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory() error = %v", err)
+	}
+	var calls atomic.Int32
+	release := make(chan struct{})
+	inner := RequestFunc(func(req *http.Request) (*Response, bool, error) {
+		calls.Add(1)
+		<-release
+		return nil, false, errors.New("invalid token")
+	})
+	const limit = 3
+	auth, err := RateLimited(inner, c, limit, time.Minute)
+	if err != nil {
+		t.Fatalf("RateLimited() error = %v", err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		rejected atomic.Int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := auth.AuthenticateRequest(httptest.NewRequest(http.MethodGet, "/", nil)); errors.Is(err, ErrTooManyFailedAttempts) {
+				rejected.Add(1)
+			}
+		}()
+	}
+	// 超出限制的请求不等待 inner 直接返回
+	for rejected.Load() < 10-limit {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if got := calls.Load(); got != limit {
+		t.Errorf("inner called %d times, want %d", got, limit)
+	}
+}
+
+func TestRateLimitedWindow(t *testing.T) {
+	c, err := cache.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory() error = %v", err)
+	}
+	inner := RequestFunc(func(req *http.Request) (*Response, bool, error) {
+		return nil, false, errors.New("invalid token")
+	})
+	auth, err := RateLimited(inner, c, 1, time.Minute)
+	if err != nil {
+		t.Fatalf("RateLimited() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, _, _ = auth.AuthenticateRequest(req)
+	ttl, err := c.TTL(req.Context(), fmt.Sprintf(constant.AuthFailureCacheKeyFormat, sourceIP(req)))
+	if err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL() = %v, %v, want the failure counter to expire within the window", ttl, err)
+	}
+}
+
+func TestRateLimitedInvalidOptions(t *testing.T) {
+	c, _ := cache.NewMemory()
+	inner := RequestFunc(func(req *http.Request) (*Response, bool, error) { return nil, false, nil })
+	if _, err := RateLimited(inner, c, 0, time.Minute); err == nil {
+		t.Error("RateLimited() with zero limit should fail")
+	}
+	if _, err := RateLimited(inner, c, 1, 0); err == nil {
+		t.Error("RateLimited() with zero window should fail")
+	}
+}
@@ -68,6 +68,7 @@ func RegisterAuthenticatorFactory(factory AuthenticatorFactory) {
 	mfaAuthenticatorFactories[kind] = factory
 }
 
+// SetupWithOptions 根据 opts 创建 MFA 认证器，opts 为空或未配置认证器时不启用任何 MFA 认证
 func SetupWithOptions(p cache.Interface, opts *Options) error {
 	if opts == nil || len(opts.Providers) == 0 {
 		return nil
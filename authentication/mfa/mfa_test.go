@@ -0,0 +1,20 @@
+package mfa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/x893675/valhalla-common/authentication/user"
+	"github.com/x893675/valhalla-common/cache"
+	"github.com/x893675/valhalla-common/constant"
+)
+
+func TestSetupWithNilOptions(t *testing.T) {
+	c, _ := cache.NewMemory()
+	if err := SetupWithOptions(c, nil); err != nil {
+		t.Fatalf("SetupWithOptions(nil) error = %v", err)
+	}
+	if _, err := IssueTo(context.Background(), &user.DefaultInfo{ID: "1"}, constant.MFAProviderTOTP); err == nil {
+		t.Error("no mfa authenticator should be enabled with nil options")
+	}
+}
@@ -29,7 +29,7 @@ func (s *SMSProviderFactory) Type() string {
 	return constant.MFAProviderSMS
 }
 
-func (s *SMSProviderFactory) Create(cache cache.Interface, options map[string]interface{}) (Authenticator, error) {
+func (s *SMSProviderFactory) Create(c cache.Interface, options map[string]interface{}) (Authenticator, error) {
 	var sms SMSProvider
 	if err := mapstructure.Decode(options, &sms); err != nil {
 		return nil, err
@@ -68,7 +68,8 @@ func (s *SMSProviderFactory) Create(cache cache.Interface, options map[string]in
 		return nil, err
 	}
 	sms.aliyunSMSClient = client
-	sms.cache = cache
+	sms.cache = c
+	sms.limiter = cache.NewRateLimiter(c)
 	return &sms, nil
 }
 
@@ -89,15 +90,16 @@ type SMSProvider struct {
 	expire            time.Duration
 	rateLimitInterval time.Duration
 	cache             cache.Interface
+	limiter           *cache.RateLimiter
 }
 
 func (s *SMSProvider) SendBindDeviceRequest(ctx context.Context, user user.Info) (string, error) {
-	exist, err := s.cache.Exist(ctx, fmt.Sprintf(constant.SMSBindRateLimitKeyFormat, user.GetID()))
+	allowed, err := s.limiter.Allow(ctx, fmt.Sprintf(constant.SMSBindRateLimitKeyFormat, user.GetID()), s.rateLimitInterval)
 	if err != nil {
 		logger.Errorf("failed to check rate limit: %s", err)
 		return "", err
 	}
-	if exist {
+	if !allowed {
 		return "", errdetails.SendSMSTooFrequently("send sms too frequently, retry after %v sec", s.rateLimitInterval.Seconds())
 	}
 
@@ -108,12 +110,6 @@ func (s *SMSProvider) SendBindDeviceRequest(ctx context.Context, user user.Info)
 		return "", err
 	}
 
-	go func() {
-		if err := s.cache.Set(ctx, fmt.Sprintf(constant.SMSBindRateLimitKeyFormat, user.GetID()), "", s.rateLimitInterval); err != nil {
-			logger.Errorf("failed to cache email bind rate limit: %s", err)
-		}
-	}()
-
 	go func() {
 		req := dysmsapi.SendSmsRequest{}
 		req.SetSignName(s.AliyunSMSConfig.SignName)
@@ -147,12 +143,12 @@ func (s *SMSProvider) VerifyBindDevice(ctx context.Context, iuser user.Info, cod
 }
 
 func (s *SMSProvider) IssueTo(ctx context.Context, user user.Info) (string, error) {
-	exist, err := s.cache.Exist(ctx, fmt.Sprintf(constant.SMSVerifyRateLimitKeyFormat, user.GetID()))
+	allowed, err := s.limiter.Allow(ctx, fmt.Sprintf(constant.SMSVerifyRateLimitKeyFormat, user.GetID()), s.rateLimitInterval)
 	if err != nil {
 		logger.Errorf("failed to check rate limit: %s", err)
 		return "", err
 	}
-	if exist {
+	if !allowed {
 		return "", errdetails.SendSMSTooFrequently("send sms too frequently, retry after %v sec", s.rateLimitInterval.Seconds())
 	}
 
@@ -163,12 +159,6 @@ func (s *SMSProvider) IssueTo(ctx context.Context, user user.Info) (string, erro
 		return "", err
 	}
 
-	go func() {
-		if err := s.cache.Set(ctx, fmt.Sprintf(constant.SMSVerifyRateLimitKeyFormat, user.GetID()), "", s.rateLimitInterval); err != nil {
-			logger.Errorf("failed to cache email bind rate limit: %s", err)
-		}
-	}()
-
 	go func() {
 		logger.Debug("send sms", zap.String("phone", user.GetPhone()), zap.String("code", code))
 		req := dysmsapi.SendSmsRequest{}
@@ -0,0 +1,264 @@
+package mfa
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/x893675/valhalla-common/authentication/user"
+	"github.com/x893675/valhalla-common/cache"
+	"github.com/x893675/valhalla-common/constant"
+	"github.com/x893675/valhalla-common/errdetails"
+	"github.com/x893675/valhalla-common/logger"
+	"github.com/x893675/valhalla-common/utils/crypto"
+)
+
+const (
+	defaultTOTPDigits = 6
+	defaultTOTPPeriod = 30
+	// totpSkew 允许前后各偏差一个时间步，容忍客户端时钟误差
+	totpSkew       = 1
+	totpSecretSize = 20
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func init() {
+	RegisterAuthenticatorFactory(&TOTPProviderFactory{})
+}
+
+type TOTPProviderFactory struct{}
+
+func (t *TOTPProviderFactory) Type() string {
+	return constant.MFAProviderTOTP
+}
+
+func (t *TOTPProviderFactory) Create(cache cache.Interface, options map[string]interface{}) (Authenticator, error) {
+	var totp TOTPProvider
+	if err := mapstructure.Decode(options, &totp); err != nil {
+		return nil, err
+	}
+	if totp.Issuer == "" {
+		return nil, fmt.Errorf("issuer is required")
+	}
+	if totp.Digits == 0 {
+		totp.Digits = defaultTOTPDigits
+	}
+	if totp.Digits < 6 || totp.Digits > 8 {
+		return nil, fmt.Errorf("digits must be between 6 and 8")
+	}
+	if totp.Period == 0 {
+		totp.Period = defaultTOTPPeriod
+	}
+	if totp.CacheExpire == "" {
+		totp.expire = constant.MFATokenCacheDuration
+	} else {
+		d, err := time.ParseDuration(totp.CacheExpire)
+		if err != nil {
+			logger.Errorf("failed to parse cache expire duration: %s", err)
+			return nil, err
+		}
+		totp.expire = d
+	}
+	if totp.EncryptionKey != "" {
+		key := sha256.Sum256([]byte(totp.EncryptionKey))
+		totp.key = key[:]
+	}
+	totp.cache = cache
+	totp.now = time.Now
+	return &totp, nil
+}
+
+// TOTPProvider 基于 RFC 6238 的 TOTP 认证
+// 绑定设备时生成的密钥暂存在缓存中，验证通过后通过用户的 Extra[constant.TOTPSecretKey] 返回，由调用方持久化，
+// 之后验证 TOTP 时再通过 secret 参数传入
+type TOTPProvider struct {
+	Issuer string `json:"issuer" yaml:"issuer"`
+	Digits int    `json:"digits" yaml:"digits"`
+	// Period 时间步长（秒）
+	Period int `json:"period" yaml:"period"`
+	// EncryptionKey 用于加密 TOTP 密钥，为空时不加密
+	// 设置后缓存中的密钥和返回给调用方持久化的密钥都是加密后的值
+	EncryptionKey string `json:"encryptionKey" yaml:"encryptionKey"`
+	CacheExpire   string `json:"cacheExpire" yaml:"cacheExpire"`
+	expire        time.Duration
+	key           []byte
+	cache         cache.Interface
+	now           func() time.Time
+}
+
+// SendBindDeviceRequest 生成新的 TOTP 密钥，返回用于生成二维码的 otpauth URL
+func (t *TOTPProvider) SendBindDeviceRequest(ctx context.Context, user user.Info) (string, error) {
+	raw := make([]byte, totpSecretSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	secret := totpEncoding.EncodeToString(raw)
+
+	sealed, err := t.sealSecret(secret)
+	if err != nil {
+		logger.Errorf("failed to encrypt totp secret: %s", err)
+		return "", err
+	}
+	if err := t.cache.Set(ctx, fmt.Sprintf(constant.TOTPCacheKeyFormat, user.GetID()), sealed, t.expire); err != nil {
+		logger.Errorf("failed to cache totp secret: %s", err)
+		return "", errdetails.CacheOperationFailed("cache totp secret")
+	}
+	return t.url(user, secret), nil
+}
+
+// VerifyBindDevice 验证绑定设备时输入的 TOTP，验证通过后返回的用户信息中包含需要持久化的密钥
+func (t *TOTPProvider) VerifyBindDevice(ctx context.Context, iuser user.Info, code string) (bool, user.Info, error) {
+	key := fmt.Sprintf(constant.TOTPCacheKeyFormat, iuser.GetID())
+	var sealed string
+	if err := t.cache.Get(ctx, key, &sealed); err != nil {
+		if errors.Is(err, cache.ErrNotExists) {
+			return false, nil, nil
+		}
+		logger.Errorf("failed to get totp secret from cache: %s", err)
+		return false, nil, err
+	}
+	secret, err := t.openSecret(sealed)
+	if err != nil {
+		logger.Errorf("failed to decrypt totp secret: %s", err)
+		return false, nil, err
+	}
+	if !t.validate(secret, code) {
+		return false, nil, nil
+	}
+	if err := t.cache.Remove(ctx, key); err != nil {
+		logger.Warnf("failed to remove totp secret from cache: %s", err)
+	}
+	iuser.SetExtra(constant.TOTPSecretKey, sealed)
+	return true, iuser, nil
+}
+
+// IssueTo TOTP 由用户设备生成，不需要下发
+func (t *TOTPProvider) IssueTo(ctx context.Context, user user.Info) (string, error) {
+	return "", nil
+}
+
+// AuthenticationToken 使用持久化的密钥 secret 验证 TOTP
+// 每个时间步的验证码只能使用一次，在有效期内重复使用会被拒绝
+func (t *TOTPProvider) AuthenticationToken(ctx context.Context, iuser user.Info, token string, secret string) (user.Info, error) {
+	plain, err := t.openSecret(secret)
+	if err != nil {
+		logger.Errorf("failed to decrypt totp secret: %s", err)
+		return nil, errdetails.Forbidden("invalid totp secret")
+	}
+	counter, ok := t.match(plain, token)
+	if !ok {
+		return nil, errdetails.Forbidden("invalid totp code")
+	}
+	// 验证码最多在 2*totpSkew+1 个时间步内有效，记录保留到验证码失效
+	window := time.Duration(2*totpSkew+1) * time.Duration(t.Period) * time.Second
+	fresh, err := t.cache.SetNX(ctx, fmt.Sprintf(constant.TOTPUsedCacheKeyFormat, iuser.GetID(), counter), "", window)
+	if err != nil {
+		logger.Errorf("failed to record used totp code: %s", err)
+		return nil, errdetails.CacheOperationFailed("record used totp code")
+	}
+	if !fresh {
+		return nil, errdetails.Forbidden("totp code already used")
+	}
+	return iuser, nil
+}
+
+// sealSecret 配置了 EncryptionKey 时使用 AES-GCM 加密密钥
+func (t *TOTPProvider) sealSecret(secret string) (string, error) {
+	if t.key == nil {
+		return secret, nil
+	}
+	ciphertext, err := crypto.AESGCMEncrypt([]byte(secret), t.key)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// openSecret 解密 sealSecret 加密的密钥
+func (t *TOTPProvider) openSecret(sealed string) (string, error) {
+	if t.key == nil {
+		return sealed, nil
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := crypto.AESGCMDecrypt(ciphertext, t.key)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func (t *TOTPProvider) url(user user.Info, secret string) string {
+	account := user.GetEmail()
+	if account == "" {
+		account = user.GetName()
+	}
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", t.Issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(t.Digits))
+	v.Set("period", fmt.Sprint(t.Period))
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + t.Issuer + ":" + account,
+		RawQuery: v.Encode(),
+	}).String()
+}
+
+// validate 验证当前时间步及前后 totpSkew 个时间步内的 TOTP
+func (t *TOTPProvider) validate(secret, code string) bool {
+	_, ok := t.match(secret, code)
+	return ok
+}
+
+// match 返回与 code 匹配的时间步
+func (t *TOTPProvider) match(secret, code string) (int64, bool) {
+	if len(code) != t.Digits {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return 0, false
+	}
+	counter := t.now().Unix() / int64(t.Period)
+	for i := int64(-totpSkew); i <= totpSkew; i++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, uint64(counter+i), t.Digits)), []byte(code)) == 1 {
+			return counter + i, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode 计算 RFC 4226 HOTP 值
+func totpCode(key []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
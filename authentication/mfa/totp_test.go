@@ -0,0 +1,158 @@
+package mfa
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/x893675/valhalla-common/authentication/user"
+	"github.com/x893675/valhalla-common/cache"
+	"github.com/x893675/valhalla-common/constant"
+)
+
+func newTestTOTPProvider(t *testing.T, c cache.Interface, options map[string]interface{}) *TOTPProvider {
+	t.Helper()
+	a, err := (&TOTPProviderFactory{}).Create(c, options)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return a.(*TOTPProvider)
+}
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 附录 B 的 SHA1 测试向量
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "94287082"},
+		{unix: 1111111109, want: "07081804"},
+		{unix: 1234567890, want: "89005924"},
+		{unix: 20000000000, want: "65353130"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, uint64(tt.unix/30), 8); got != tt.want {
+			t.Errorf("totpCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestTOTPProviderBind(t *testing.T) {
+	for _, encryptionKey := range []string{"", "provider-secret"} {
+		t.Run(fmt.Sprintf("encryption=%v", encryptionKey != ""), func(t *testing.T) {
+			c, _ := cache.NewMemory()
+			p := newTestTOTPProvider(t, c, map[string]interface{}{
+				"issuer":        "valhalla",
+				"encryptionKey": encryptionKey,
+			})
+			now := time.Unix(1700000000, 0)
+			p.now = func() time.Time { return now }
+			ctx := context.Background()
+			u := &user.DefaultInfo{ID: "1", Name: "alice", Email: "alice@example.com"}
+
+			rawURL, err := p.SendBindDeviceRequest(ctx, u)
+			if err != nil {
+				t.Fatalf("SendBindDeviceRequest() error = %v", err)
+			}
+			parsed, err := url.Parse(rawURL)
+			if err != nil {
+				t.Fatalf("url.Parse() error = %v", err)
+			}
+			secret := parsed.Query().Get("secret")
+			if parsed.Scheme != "otpauth" || secret == "" {
+				t.Fatalf("SendBindDeviceRequest() url = %s", rawURL)
+			}
+
+			var stored string
+			if err := c.Get(ctx, fmt.Sprintf(constant.TOTPCacheKeyFormat, u.GetID()), &stored); err != nil {
+				t.Fatalf("cache Get() error = %v", err)
+			}
+			if encryptionKey != "" && stored == secret {
+				t.Error("cached secret should be encrypted")
+			}
+
+			key, _ := totpEncoding.DecodeString(secret)
+			code := totpCode(key, uint64(now.Unix()/30), 6)
+
+			if ok, _, err := p.VerifyBindDevice(ctx, u, "000000"); ok || err != nil {
+				t.Errorf("VerifyBindDevice() with wrong code = %v, %v", ok, err)
+			}
+			ok, bound, err := p.VerifyBindDevice(ctx, u, code)
+			if err != nil || !ok {
+				t.Fatalf("VerifyBindDevice() = %v, %v", ok, err)
+			}
+			persisted, _ := bound.GetExtra(constant.TOTPSecretKey).(string)
+			if persisted != stored {
+				t.Errorf("persisted secret = %q, want cached value %q", persisted, stored)
+			}
+			if exist, _ := c.Exist(ctx, fmt.Sprintf(constant.TOTPCacheKeyFormat, u.GetID())); exist {
+				t.Error("cached secret should be removed after binding")
+			}
+
+			// 使用持久化的密钥验证，允许前后一个时间步的偏差
+			previous := totpCode(key, uint64(now.Unix()/30-1), 6)
+			for _, c := range []string{code, previous} {
+				if _, err := p.AuthenticationToken(ctx, u, c, persisted); err != nil {
+					t.Errorf("AuthenticationToken(%s) error = %v", c, err)
+				}
+			}
+			stale := totpCode(key, uint64(now.Unix()/30-2), 6)
+			if _, err := p.AuthenticationToken(ctx, u, stale, persisted); err == nil {
+				t.Error("AuthenticationToken() should reject a code outside the window")
+			}
+		})
+	}
+}
+
+func TestTOTPProviderWrongEncryptionKey(t *testing.T) {
+	c, _ := cache.NewMemory()
+	p := newTestTOTPProvider(t, c, map[string]interface{}{"issuer": "valhalla", "encryptionKey": "key-a"})
+	sealed, err := p.sealSecret("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("sealSecret() error = %v", err)
+	}
+
+	other := newTestTOTPProvider(t, c, map[string]interface{}{"issuer": "valhalla", "encryptionKey": "key-b"})
+	if _, err := other.openSecret(sealed); err == nil {
+		t.Error("openSecret() should fail with a different encryption key")
+	}
+	if _, err := other.AuthenticationToken(context.Background(), &user.DefaultInfo{ID: "1"}, "123456", sealed); err == nil {
+		t.Error("AuthenticationToken() should fail with a different encryption key")
+	}
+}
+
+func TestTOTPProviderReplay(t *testing.T) {
+	c, _ := cache.NewMemory()
+	p := newTestTOTPProvider(t, c, map[string]interface{}{"issuer": "valhalla"})
+	now := time.Unix(1700000000, 0)
+	p.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	const secret = "JBSWY3DPEHPK3PXP"
+	key, _ := totpEncoding.DecodeString(secret)
+	code := totpCode(key, uint64(now.Unix()/30), 6)
+	alice, bob := &user.DefaultInfo{ID: "1"}, &user.DefaultInfo{ID: "2"}
+
+	if _, err := p.AuthenticationToken(ctx, alice, code, secret); err != nil {
+		t.Fatalf("AuthenticationToken() error = %v", err)
+	}
+	if _, err := p.AuthenticationToken(ctx, alice, code, secret); err == nil {
+		t.Error("AuthenticationToken() should reject a reused code")
+	}
+	// 下一个时间步内仍然有效的旧验证码同样不能重放
+	now = now.Add(30 * time.Second)
+	if _, err := p.AuthenticationToken(ctx, alice, code, secret); err == nil {
+		t.Error("AuthenticationToken() should reject a reused code in the next time step")
+	}
+	// 记录按用户区分
+	if _, err := p.AuthenticationToken(ctx, bob, code, secret); err != nil {
+		t.Errorf("AuthenticationToken() for another user error = %v", err)
+	}
+	next := totpCode(key, uint64(now.Unix()/30), 6)
+	if _, err := p.AuthenticationToken(ctx, alice, next, secret); err != nil {
+		t.Errorf("AuthenticationToken() with a new code error = %v", err)
+	}
+}
@@ -13,6 +13,9 @@ var _ authenticator.Request = (*Authenticator)(nil)
 
 var ErrInvalidToken = errors.New("invalid access token")
 
+// AuthMethod is reported in authenticator.Response.AuthMethod.
+const AuthMethod = "token"
+
 // Authenticator implements authenticator.Request
 // Authorization: Token <token>
 type Authenticator struct {
@@ -42,10 +45,21 @@ func (a *Authenticator) AuthenticateRequest(req *http.Request) (*authenticator.R
 	if !ok && err == nil {
 		err = ErrInvalidToken
 	}
+	if ok && resp != nil {
+		// the token authenticator may cache or share responses, don't modify them
+		r := *resp
+		r.AuthMethod = AuthMethod
+		resp = &r
+	}
 
 	return resp, ok, err
 }
 
+// Name implements authenticator.Named.
+func (a *Authenticator) Name() string {
+	return AuthMethod
+}
+
 func New(auth authenticator.Token) authenticator.Request {
 	return &Authenticator{auth: auth}
 }
@@ -0,0 +1,62 @@
+package cookie
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/x893675/valhalla-common/authentication/authenticator"
+)
+
+var _ authenticator.Request = (*Authenticator)(nil)
+
+var ErrInvalidToken = errors.New("invalid session cookie")
+
+// DefaultCookieName is used when no cookie name is configured.
+const DefaultCookieName = "session"
+
+// AuthMethod is reported in authenticator.Response.AuthMethod.
+const AuthMethod = "cookie"
+
+// Authenticator implements authenticator.Request
+// Cookie: <name>=<token>
+type Authenticator struct {
+	name string
+	auth authenticator.Token
+}
+
+func (a *Authenticator) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	c, err := req.Cookie(a.name)
+	// Missing or empty cookies mean the request is not for this authenticator
+	if err != nil || c.Value == "" {
+		return nil, false, nil
+	}
+
+	resp, ok, err := a.auth.AuthenticateToken(req.Context(), c.Value)
+
+	// If the token authenticator didn't error, provide a default error
+	if !ok && err == nil {
+		err = ErrInvalidToken
+	}
+	if ok && resp != nil {
+		// the token authenticator may cache or share responses, don't modify them
+		r := *resp
+		r.AuthMethod = AuthMethod
+		resp = &r
+	}
+
+	return resp, ok, err
+}
+
+// Name implements authenticator.Named.
+func (a *Authenticator) Name() string {
+	return AuthMethod
+}
+
+// New returns a request authenticator that reads the token from the cookie
+// named name and validates it with auth. DefaultCookieName is used if name is empty.
+func New(name string, auth authenticator.Token) authenticator.Request {
+	if name == "" {
+		name = DefaultCookieName
+	}
+	return &Authenticator{name: name, auth: auth}
+}
@@ -0,0 +1,96 @@
+package cookie
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x893675/valhalla-common/authentication/authenticator"
+	"github.com/x893675/valhalla-common/authentication/user"
+)
+
+func TestAuthenticateRequest(t *testing.T) {
+	auth := New("sid", authenticator.TokenFunc(func(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+		if token == "valid-token" {
+			return &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+		}
+		return nil, false, nil
+	}))
+
+	tests := []struct {
+		name     string
+		cookie   *http.Cookie
+		wantOK   bool
+		wantErr  error
+		wantUser string
+	}{
+		{
+			name:     "valid cookie",
+			cookie:   &http.Cookie{Name: "sid", Value: "valid-token"},
+			wantOK:   true,
+			wantUser: "alice",
+		},
+		{
+			name:   "missing cookie",
+			cookie: nil,
+		},
+		{
+			name:   "other cookie",
+			cookie: &http.Cookie{Name: "session", Value: "valid-token"},
+		},
+		{
+			name:   "empty cookie",
+			cookie: &http.Cookie{Name: "sid", Value: ""},
+		},
+		{
+			name:    "invalid token",
+			cookie:  &http.Cookie{Name: "sid", Value: "bad-token"},
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+
+			resp, ok, err := auth.AuthenticateRequest(req)
+			if ok != tt.wantOK {
+				t.Errorf("AuthenticateRequest() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AuthenticateRequest() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantUser != "" && (resp == nil || resp.User.GetName() != tt.wantUser) {
+				t.Errorf("AuthenticateRequest() user = %v, want %s", resp, tt.wantUser)
+			}
+			if ok && resp.AuthMethod != AuthMethod {
+				t.Errorf("AuthenticateRequest() AuthMethod = %q, want %q", resp.AuthMethod, AuthMethod)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRequestDoesNotModifySharedResponse(t *testing.T) {
+	shared := &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}, AuthMethod: "cached"}
+	auth := New("sid", authenticator.TokenFunc(func(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+		return shared, true, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "token"})
+	resp, ok, err := auth.AuthenticateRequest(req)
+	if !ok || err != nil {
+		t.Fatalf("AuthenticateRequest() = %v, %v", ok, err)
+	}
+	if resp.AuthMethod != AuthMethod {
+		t.Errorf("AuthMethod = %q, want %q", resp.AuthMethod, AuthMethod)
+	}
+	if shared.AuthMethod != "cached" {
+		t.Errorf("shared response AuthMethod changed to %q", shared.AuthMethod)
+	}
+}
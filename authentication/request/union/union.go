@@ -13,6 +13,9 @@ var _ authenticator.Request = (*unionAuthRequestHandler)(nil)
 type unionAuthRequestHandler struct {
 	Handlers    []authenticator.Request
 	FailOnError bool
+	// IsFatal decides whether a handler's error short-circuits the chain.
+	// It is only consulted when FailOnError is false.
+	IsFatal func(err error) bool
 }
 
 func (u *unionAuthRequestHandler) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
@@ -21,7 +24,7 @@ func (u *unionAuthRequestHandler) AuthenticateRequest(req *http.Request) (*authe
 		resp, ok, err := currAuthRequestHandler.AuthenticateRequest(req)
 		logger.Debugf("AuthenticateRequest: %v, %v, %v", resp, ok, err)
 		if err != nil {
-			if u.FailOnError {
+			if u.FailOnError || (u.IsFatal != nil && u.IsFatal(err)) {
 				return resp, ok, err
 			}
 			errlist = append(errlist, err)
@@ -29,6 +32,14 @@ func (u *unionAuthRequestHandler) AuthenticateRequest(req *http.Request) (*authe
 		}
 
 		if ok {
+			// Record the handler that succeeded unless it already reported itself
+			if resp != nil && resp.AuthMethod == "" {
+				if named, isNamed := currAuthRequestHandler.(authenticator.Named); isNamed {
+					r := *resp
+					r.AuthMethod = named.Name()
+					resp = &r
+				}
+			}
 			return resp, ok, err
 		}
 	}
@@ -53,3 +64,13 @@ func NewFailOnError(authRequestHandlers ...authenticator.Request) authenticator.
 	}
 	return &unionAuthRequestHandler{Handlers: authRequestHandlers, FailOnError: true}
 }
+
+// NewWithErrorPolicy returns a request authenticator that validates credentials using a chain of authenticator.Request objects.
+// An error for which isFatal returns true short-circuits the chain, other errors are aggregated and the next handler is tried.
+// This allows e.g. failing hard on a malformed token while falling through on a missing one.
+func NewWithErrorPolicy(isFatal func(err error) bool, authRequestHandlers ...authenticator.Request) authenticator.Request {
+	if len(authRequestHandlers) == 1 {
+		return authRequestHandlers[0]
+	}
+	return &unionAuthRequestHandler{Handlers: authRequestHandlers, IsFatal: isFatal}
+}
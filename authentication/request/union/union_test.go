@@ -0,0 +1,159 @@
+package union
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x893675/valhalla-common/authentication/authenticator"
+	"github.com/x893675/valhalla-common/authentication/user"
+)
+
+// namedRequest is a request authenticator reporting name
+type namedRequest struct {
+	name string
+	fn   authenticator.RequestFunc
+}
+
+func (n *namedRequest) Name() string { return n.name }
+
+func (n *namedRequest) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	return n.fn(req)
+}
+
+func headerAuth(name, header string) authenticator.Request {
+	return &namedRequest{name: name, fn: func(req *http.Request) (*authenticator.Response, bool, error) {
+		if req.Header.Get(header) == "" {
+			return nil, false, errors.New(name + ": missing " + header)
+		}
+		return &authenticator.Response{User: &user.DefaultInfo{Name: req.Header.Get(header)}}, true, nil
+	}}
+}
+
+func TestAuthMethod(t *testing.T) {
+	auth := New(headerAuth("token", "X-Token"), headerAuth("basic", "X-Basic"))
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantOK     bool
+		wantMethod string
+	}{
+		{name: "token", headers: map[string]string{"X-Token": "alice"}, wantOK: true, wantMethod: "token"},
+		{name: "basic", headers: map[string]string{"X-Basic": "bob"}, wantOK: true, wantMethod: "basic"},
+		{name: "first handler wins", headers: map[string]string{"X-Token": "alice", "X-Basic": "bob"}, wantOK: true, wantMethod: "token"},
+		{name: "none", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			resp, ok, err := auth.AuthenticateRequest(req)
+			if ok != tt.wantOK {
+				t.Fatalf("AuthenticateRequest() ok = %v, want %v, err = %v", ok, tt.wantOK, err)
+			}
+			if !tt.wantOK {
+				if err == nil {
+					t.Error("AuthenticateRequest() should return aggregated error")
+				}
+				return
+			}
+			if resp.AuthMethod != tt.wantMethod {
+				t.Errorf("AuthMethod = %q, want %q", resp.AuthMethod, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestAuthMethodPreserved(t *testing.T) {
+	// AuthMethod set by the handler itself must not be overwritten
+	preset := &namedRequest{name: "outer", fn: func(req *http.Request) (*authenticator.Response, bool, error) {
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}, AuthMethod: "inner"}, true, nil
+	}}
+	// Unnamed handlers leave AuthMethod empty
+	unnamed := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "bob"}}, true, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	resp, ok, _ := New(preset, unnamed).AuthenticateRequest(req)
+	if !ok || resp.AuthMethod != "inner" {
+		t.Errorf("AuthMethod = %q, want %q", resp.AuthMethod, "inner")
+	}
+	resp, ok, _ = New(unnamed, preset).AuthenticateRequest(req)
+	if !ok || resp.AuthMethod != "" {
+		t.Errorf("AuthMethod = %q, want empty", resp.AuthMethod)
+	}
+}
+
+func TestErrorPolicy(t *testing.T) {
+	errMalformed := errors.New("malformed token")
+	errMissing := errors.New("missing token")
+
+	var calls []string
+	failing := func(name string, err error) authenticator.Request {
+		return authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+			calls = append(calls, name)
+			return nil, false, err
+		})
+	}
+	succeeding := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		calls = append(calls, "basic")
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+	})
+	isFatal := func(err error) bool { return errors.Is(err, errMalformed) }
+
+	tests := []struct {
+		name      string
+		handlers  []authenticator.Request
+		wantOK    bool
+		wantErr   error
+		wantCalls []string
+	}{
+		{
+			name:      "tolerated error falls through",
+			handlers:  []authenticator.Request{failing("token", errMissing), succeeding},
+			wantOK:    true,
+			wantCalls: []string{"token", "basic"},
+		},
+		{
+			name:      "fatal error aborts chain",
+			handlers:  []authenticator.Request{failing("token", errMalformed), succeeding},
+			wantErr:   errMalformed,
+			wantCalls: []string{"token"},
+		},
+		{
+			name:      "tolerated errors are aggregated",
+			handlers:  []authenticator.Request{failing("token", errMissing), failing("cookie", errMissing)},
+			wantErr:   errMissing,
+			wantCalls: []string{"token", "cookie"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			_, ok, err := NewWithErrorPolicy(isFatal, tt.handlers...).AuthenticateRequest(req)
+			if ok != tt.wantOK {
+				t.Errorf("AuthenticateRequest() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("AuthenticateRequest() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(calls) != len(tt.wantCalls) {
+				t.Fatalf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			for i := range calls {
+				if calls[i] != tt.wantCalls[i] {
+					t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+					break
+				}
+			}
+		})
+	}
+}
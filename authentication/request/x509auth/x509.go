@@ -0,0 +1,91 @@
+package x509auth
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+
+	"github.com/x893675/valhalla-common/authentication/authenticator"
+	"github.com/x893675/valhalla-common/authentication/user"
+)
+
+var _ authenticator.Request = (*Authenticator)(nil)
+
+var (
+	// ErrNoTLS is returned when the request was not made over TLS.
+	ErrNoTLS = errors.New("[x509] request is not over TLS")
+	// ErrNoVerifiedChain is returned when the client certificate was not verified by the server.
+	ErrNoVerifiedChain = errors.New("[x509] no verified client certificate chain")
+)
+
+// UserMapper maps a verified client certificate to a user.
+type UserMapper interface {
+	MapUser(cert *x509.Certificate) (user.Info, error)
+}
+
+// UserMapperFunc is a function that implements the UserMapper interface.
+type UserMapperFunc func(cert *x509.Certificate) (user.Info, error)
+
+// MapUser implements UserMapper.
+func (f UserMapperFunc) MapUser(cert *x509.Certificate) (user.Info, error) {
+	return f(cert)
+}
+
+// CommonNameUserMapper uses the certificate CommonName as user name and id,
+// the Organization as groups and the first email SAN as email.
+var CommonNameUserMapper = UserMapperFunc(func(cert *x509.Certificate) (user.Info, error) {
+	if cert.Subject.CommonName == "" {
+		return nil, errors.New("[x509] certificate common name is empty")
+	}
+	u := &user.DefaultInfo{
+		Name:   cert.Subject.CommonName,
+		ID:     cert.Subject.CommonName,
+		Groups: cert.Subject.Organization,
+	}
+	if len(cert.EmailAddresses) > 0 {
+		u.Email = cert.EmailAddresses[0]
+	}
+	return u, nil
+})
+
+// AuthMethod is reported in authenticator.Response.AuthMethod.
+const AuthMethod = "x509"
+
+// Authenticator implements authenticator.Request
+// It authenticates requests by the verified TLS client certificate.
+type Authenticator struct {
+	mapper UserMapper
+}
+
+func (a *Authenticator) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	if req.TLS == nil {
+		return nil, false, ErrNoTLS
+	}
+	// VerifiedChains is only populated when the server verified the client certificate
+	if len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil, false, ErrNoVerifiedChain
+	}
+
+	u, err := a.mapper.MapUser(req.TLS.VerifiedChains[0][0])
+	if err != nil {
+		return nil, false, err
+	}
+	if u == nil {
+		return nil, false, nil
+	}
+	return &authenticator.Response{User: u, AuthMethod: AuthMethod}, true, nil
+}
+
+// Name implements authenticator.Named.
+func (a *Authenticator) Name() string {
+	return AuthMethod
+}
+
+// New returns a request authenticator that maps the verified client certificate
+// to a user with mapper. CommonNameUserMapper is used if mapper is nil.
+func New(mapper UserMapper) authenticator.Request {
+	if mapper == nil {
+		mapper = CommonNameUserMapper
+	}
+	return &Authenticator{mapper: mapper}
+}
@@ -0,0 +1,87 @@
+package x509auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x893675/valhalla-common/authentication/user"
+	"github.com/x893675/valhalla-common/utils/cert"
+)
+
+func newClientCert(t *testing.T) (*x509.Certificate, *x509.Certificate) {
+	t.Helper()
+	ca, err := cert.NewCA(cert.Config{CommonName: "Test CA", KeyType: cert.KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	pair, err := ca.NewSignedCert(cert.Config{
+		CommonName:   "alice",
+		Organization: []string{"dev", "ops"},
+		KeyType:      cert.KeyTypeECDSA,
+		Usages:       []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		t.Fatalf("Failed to sign client certificate: %v", err)
+	}
+	return pair.Certificate, ca.Certificate
+}
+
+func TestAuthenticateRequest(t *testing.T) {
+	leaf, caCert := newClientCert(t)
+
+	t.Run("verified chain", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{leaf},
+			VerifiedChains:   [][]*x509.Certificate{{leaf, caCert}},
+		}
+
+		resp, ok, err := New(nil).AuthenticateRequest(req)
+		if err != nil || !ok {
+			t.Fatalf("AuthenticateRequest() = %v, %v, want ok", ok, err)
+		}
+		if resp.User.GetName() != "alice" || resp.User.GetID() != "alice" {
+			t.Errorf("user = %s/%s, want alice", resp.User.GetName(), resp.User.GetID())
+		}
+		if groups := resp.User.GetGroups(); len(groups) != 2 || groups[0] != "dev" {
+			t.Errorf("groups = %v, want [dev ops]", groups)
+		}
+	})
+
+	t.Run("custom mapper", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf, caCert}}}
+
+		mapper := UserMapperFunc(func(c *x509.Certificate) (user.Info, error) {
+			return &user.DefaultInfo{Name: "svc:" + c.Subject.CommonName, Type: user.UserTypeService}, nil
+		})
+		resp, ok, err := New(mapper).AuthenticateRequest(req)
+		if err != nil || !ok {
+			t.Fatalf("AuthenticateRequest() = %v, %v, want ok", ok, err)
+		}
+		if resp.User.GetName() != "svc:alice" || resp.User.UserType() != user.UserTypeService {
+			t.Errorf("user = %s/%s, want svc:alice/service", resp.User.GetName(), resp.User.UserType())
+		}
+	})
+
+	t.Run("missing TLS", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		_, ok, err := New(nil).AuthenticateRequest(req)
+		if ok || !errors.Is(err, ErrNoTLS) {
+			t.Errorf("AuthenticateRequest() = %v, %v, want %v", ok, err, ErrNoTLS)
+		}
+	})
+
+	t.Run("unverified peer certificate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}
+		_, ok, err := New(nil).AuthenticateRequest(req)
+		if ok || !errors.Is(err, ErrNoVerifiedChain) {
+			t.Errorf("AuthenticateRequest() = %v, %v, want %v", ok, err, ErrNoVerifiedChain)
+		}
+	})
+}
@@ -1,11 +1,13 @@
 package token
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/x893675/valhalla-common/authentication/authenticator"
@@ -37,8 +39,16 @@ type Claims struct {
 	Ut string `json:"ut,omitempty"`
 }
 
+// ErrPrimarySecret is returned by RemoveSecret for the secret used to issue tokens.
+var ErrPrimarySecret = errors.New("can't remove the primary token secret")
+
 type AESTokenAuthenticator struct {
-	secret      []byte
+	// secretsMu guards secret and fallbacks during rotation.
+	secretsMu sync.RWMutex
+	// secret issues new tokens and is tried first when verifying.
+	secret []byte
+	// fallbacks only verify tokens, so tokens issued before a rotation stay valid until they expire.
+	fallbacks   [][]byte
 	cache       cache.Interface
 	now         func() time.Time
 	ssaResolver SystemAccountResolver
@@ -90,7 +100,21 @@ func (a *AESTokenAuthenticator) parseClaims(wireToken string) (*Claims, error) {
 	if len(ciphertext) == 0 {
 		return nil, fmt.Errorf("token is invalid")
 	}
-	plaintext, err := crypto.AESCBCDecrypt(ciphertext, a.secret)
+	var firstErr error
+	for _, secret := range a.verifySecrets() {
+		claim, err := decryptClaims(ciphertext, secret)
+		if err == nil {
+			return claim, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+func decryptClaims(ciphertext, secret []byte) (*Claims, error) {
+	plaintext, err := crypto.AESCBCDecrypt(ciphertext, secret)
 	if err != nil {
 		return nil, err
 	}
@@ -101,6 +125,78 @@ func (a *AESTokenAuthenticator) parseClaims(wireToken string) (*Claims, error) {
 	return &claim, nil
 }
 
+// verifySecrets returns the primary secret followed by the fallbacks.
+func (a *AESTokenAuthenticator) verifySecrets() [][]byte {
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+	secrets := make([][]byte, 0, len(a.fallbacks)+1)
+	return append(append(secrets, a.secret), a.fallbacks...)
+}
+
+func (a *AESTokenAuthenticator) primarySecret() []byte {
+	a.secretsMu.RLock()
+	defer a.secretsMu.RUnlock()
+	return a.secret
+}
+
+// AddFallbackSecret adds a secret that is only used to verify tokens.
+// Adding the primary secret or an existing fallback is a no-op.
+func (a *AESTokenAuthenticator) AddFallbackSecret(secret []byte) {
+	a.secretsMu.Lock()
+	defer a.secretsMu.Unlock()
+	if bytes.Equal(secret, a.secret) || containsSecret(a.fallbacks, secret) {
+		return
+	}
+	a.fallbacks = append(a.fallbacks, bytes.Clone(secret))
+}
+
+// PromoteSecret makes secret the primary secret for issuing tokens and keeps the previous primary
+// as a fallback, so tokens issued before the rotation can still be verified. Once those tokens
+// have expired, drop the previous secret with RemoveSecret.
+func (a *AESTokenAuthenticator) PromoteSecret(secret []byte) {
+	a.secretsMu.Lock()
+	defer a.secretsMu.Unlock()
+	if bytes.Equal(secret, a.secret) {
+		return
+	}
+	fallbacks := make([][]byte, 0, len(a.fallbacks)+1)
+	fallbacks = append(fallbacks, a.secret)
+	for _, s := range a.fallbacks {
+		if !bytes.Equal(s, secret) {
+			fallbacks = append(fallbacks, s)
+		}
+	}
+	a.secret = bytes.Clone(secret)
+	a.fallbacks = fallbacks
+}
+
+// RemoveSecret removes a fallback secret, tokens issued with it no longer verify.
+// It returns ErrPrimarySecret for the primary secret.
+func (a *AESTokenAuthenticator) RemoveSecret(secret []byte) error {
+	a.secretsMu.Lock()
+	defer a.secretsMu.Unlock()
+	if bytes.Equal(secret, a.secret) {
+		return ErrPrimarySecret
+	}
+	fallbacks := a.fallbacks[:0:0]
+	for _, s := range a.fallbacks {
+		if !bytes.Equal(s, secret) {
+			fallbacks = append(fallbacks, s)
+		}
+	}
+	a.fallbacks = fallbacks
+	return nil
+}
+
+func containsSecret(secrets [][]byte, secret []byte) bool {
+	for _, s := range secrets {
+		if bytes.Equal(s, secret) {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *AESTokenAuthenticator) verifyServiceAccount(ctx context.Context, wireToken string) (user.Info, error) {
 	if a.ssaResolver == nil {
 		return nil, fmt.Errorf("service account resolver is not configured")
@@ -149,7 +245,7 @@ func (a *AESTokenAuthenticator) IssueTo(ctx context.Context, u user.Info, expire
 	if err != nil {
 		return "", err
 	}
-	ciphertext, err := crypto.AESCBCEncrypt(claimBytes, a.secret)
+	ciphertext, err := crypto.AESCBCEncrypt(claimBytes, a.primarySecret())
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,99 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/x893675/valhalla-common/authentication/user"
+	"github.com/x893675/valhalla-common/cache"
+)
+
+func TestAESTokenSecretRotation(t *testing.T) {
+	ctx := context.Background()
+	c, _ := cache.NewMemory()
+	oldSecret := NormalizeKey("old-secret")
+	newSecret := NormalizeKey("new-secret")
+	a := NewAESTokenAuthenticator(oldSecret, c, time.Now, nil)
+	u := &user.DefaultInfo{ID: "1", Name: "alice", Type: user.UserTypeUser}
+
+	oldToken, err := a.IssueTo(ctx, u, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueTo() error = %v", err)
+	}
+
+	a.PromoteSecret(newSecret)
+	newToken, err := a.IssueTo(ctx, u, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueTo() error = %v", err)
+	}
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if got, err := a.Verify(token); err != nil || got.GetID() != "1" {
+			t.Errorf("Verify(%s token) = %v, %v", name, got, err)
+		}
+	}
+	// the new token is issued with the new secret only
+	if _, err := NewAESTokenAuthenticator(oldSecret, c, time.Now, nil).Verify(newToken); err == nil {
+		t.Error("new token should not verify with the old secret")
+	}
+
+	if err := a.RemoveSecret(newSecret); !errors.Is(err, ErrPrimarySecret) {
+		t.Errorf("RemoveSecret(primary) error = %v, want ErrPrimarySecret", err)
+	}
+	if err := a.RemoveSecret(oldSecret); err != nil {
+		t.Fatalf("RemoveSecret() error = %v", err)
+	}
+	if _, err := a.Verify(oldToken); err == nil {
+		t.Error("old token should not verify after the old secret is removed")
+	}
+	if _, err := a.Verify(newToken); err != nil {
+		t.Errorf("Verify(new token) error = %v", err)
+	}
+
+	// a fallback added ahead of time verifies tokens issued by another instance
+	other := NewAESTokenAuthenticator(oldSecret, c, time.Now, nil)
+	otherToken, _ := other.IssueTo(ctx, u, time.Hour)
+	a.AddFallbackSecret(oldSecret)
+	a.AddFallbackSecret(oldSecret)
+	a.AddFallbackSecret(newSecret)
+	if _, err := a.Verify(otherToken); err != nil {
+		t.Errorf("Verify() with fallback secret error = %v", err)
+	}
+	if n := len(a.verifySecrets()); n != 2 {
+		t.Errorf("secrets = %d, want 2", n)
+	}
+}
+
+func TestAESTokenSecretRotationConcurrent(t *testing.T) {
+	ctx := context.Background()
+	c, _ := cache.NewMemory()
+	a := NewAESTokenAuthenticator(NormalizeKey("secret-0"), c, time.Now, nil)
+	u := &user.DefaultInfo{ID: "1"}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 50; i++ {
+			a.PromoteSecret(NormalizeKey(string(rune('a' + i%26))))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			token, err := a.IssueTo(ctx, u, time.Hour)
+			if err != nil {
+				t.Errorf("IssueTo() error = %v", err)
+				return
+			}
+			// every previous primary is kept as a fallback
+			if _, err := a.Verify(token); err != nil {
+				t.Errorf("Verify() error = %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
@@ -22,6 +22,9 @@ type TokenManager interface {
 type Options struct {
 	Type   string `json:"type" yaml:"type"`
 	Secret string `json:"secret" yaml:"secret"`
+	// NormalizeSecret derives the AES key from Secret with NormalizeKey, so a secret of any length can be used.
+	// It changes the key derivation, tokens issued with the raw secret can't be verified after enabling it.
+	NormalizeSecret bool `json:"normalizeSecret,omitempty" yaml:"normalizeSecret"`
 }
 
 func DefaultOptions() *Options {
@@ -39,7 +42,11 @@ func NewTokenManager(cache cache.Interface, opts *Options, ssa SystemAccountReso
 	}
 	switch opts.Type {
 	case "aes":
-		return NewAESTokenAuthenticator([]byte(opts.Secret), cache, time.Now, ssa), nil
+		secret := []byte(opts.Secret)
+		if opts.NormalizeSecret {
+			secret = NormalizeKey(opts.Secret)
+		}
+		return NewAESTokenAuthenticator(secret, cache, time.Now, ssa), nil
 	default:
 		return nil, fmt.Errorf("unknown token type: %s", opts.Type)
 	}
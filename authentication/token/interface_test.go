@@ -0,0 +1,18 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/x893675/valhalla-common/cache"
+)
+
+func TestNewTokenManagerWithNilOptions(t *testing.T) {
+	c, _ := cache.NewMemory()
+	m, err := NewTokenManager(c, nil, nil)
+	if err != nil {
+		t.Fatalf("NewTokenManager(nil) error = %v", err)
+	}
+	if _, ok := m.(*AESTokenAuthenticator); !ok {
+		t.Errorf("NewTokenManager(nil) = %T, want the default AES token manager", m)
+	}
+}
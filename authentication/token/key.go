@@ -0,0 +1,10 @@
+package token
+
+import "crypto/sha256"
+
+// NormalizeKey derives a 32-byte AES-256 key from an arbitrary secret by hashing it with SHA-256.
+// Identical secrets always derive identical keys.
+func NormalizeKey(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
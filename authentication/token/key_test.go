@@ -0,0 +1,57 @@
+package token
+
+import (
+	"bytes"
+	"crypto/aes"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{name: "empty", secret: ""},
+		{name: "short", secret: "secret"},
+		{name: "long", secret: strings.Repeat("a-very-long-passphrase", 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := NormalizeKey(tt.secret)
+			if len(key) != 32 {
+				t.Fatalf("NormalizeKey() length = %d, want 32", len(key))
+			}
+			if _, err := aes.NewCipher(key); err != nil {
+				t.Errorf("aes.NewCipher() error = %v", err)
+			}
+			if !bytes.Equal(key, NormalizeKey(tt.secret)) {
+				t.Error("NormalizeKey() should be deterministic")
+			}
+		})
+	}
+
+	if bytes.Equal(NormalizeKey("secret-a"), NormalizeKey("secret-b")) {
+		t.Error("different secrets should derive different keys")
+	}
+}
+
+func TestNewTokenManagerNormalizeSecret(t *testing.T) {
+	m, err := NewTokenManager(nil, &Options{Type: "aes", Secret: "short", NormalizeSecret: true}, nil)
+	if err != nil {
+		t.Fatalf("NewTokenManager() error = %v", err)
+	}
+	a := m.(*AESTokenAuthenticator)
+	if !bytes.Equal(a.secret, NormalizeKey("short")) {
+		t.Error("NewTokenManager() should use the normalized key")
+	}
+
+	m, err = NewTokenManager(nil, DefaultOptions(), nil)
+	if err != nil {
+		t.Fatalf("NewTokenManager() error = %v", err)
+	}
+	if string(m.(*AESTokenAuthenticator).secret) != DefaultOptions().Secret {
+		t.Error("NewTokenManager() should use the raw secret by default")
+	}
+}
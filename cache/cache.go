@@ -9,19 +9,85 @@ import (
 
 const NoExpiration time.Duration = 0
 
+// NoTTL is returned by TTL for keys that exist but have no expiration.
+const NoTTL time.Duration = -1
+
 var (
 	ErrNotExists      = fmt.Errorf("key not exists")
 	ErrScanValueIsNil = fmt.Errorf("scan value is nil")
 )
 
+// checkMGetDest validates the arguments of MGet.
+func checkMGetDest(keys []string, dest []interface{}) error {
+	if len(keys) != len(dest) {
+		return fmt.Errorf("mget: got %d keys but %d destinations", len(keys), len(dest))
+	}
+	for _, d := range dest {
+		if d == nil {
+			return ErrScanValueIsNil
+		}
+	}
+	return nil
+}
+
+// checkGetMultiDest validates the arguments of GetMulti.
+func checkGetMultiDest(keys []string, dest map[string]interface{}) error {
+	for _, key := range keys {
+		if dest[key] == nil {
+			return ErrScanValueIsNil
+		}
+	}
+	return nil
+}
+
 type Interface interface {
 	Set(ctx context.Context, key string, value interface{}, expire time.Duration) error
+	// SetNX sets key only if it does not exist, or has expired, and reports whether the value was set.
+	SetNX(ctx context.Context, key string, value interface{}, expire time.Duration) (bool, error)
 	Update(ctx context.Context, key string, value interface{}) error
 	Get(ctx context.Context, key string, value interface{}) error
 	Exist(ctx context.Context, key string) (bool, error)
+	// MGet scans the values of keys into the pointers in dest, which must have the same length as keys.
+	// Elements of dest for missing keys are left untouched, so they keep their zero value.
+	MGet(ctx context.Context, keys []string, dest []interface{}) error
+	// MSet sets all pairs with the same expiration.
+	MSet(ctx context.Context, pairs map[string]interface{}, expire time.Duration) error
+	// GetMulti scans the value of each key into the pointer stored under that key in dest,
+	// with a single round trip like MGet. Keys that don't exist are removed from dest instead of
+	// failing the call, so afterwards dest holds exactly the keys that were found.
+	GetMulti(ctx context.Context, keys []string, dest map[string]interface{}) error
+	// SetMulti sets all items with the same expiration, like MSet.
+	SetMulti(ctx context.Context, items map[string]interface{}, expire time.Duration) error
 	Remove(ctx context.Context, key string) error
+	// CompareAndDelete removes key only if its current value equals expected, and reports whether it was removed.
+	// It is meant for releasing a lock taken with SetNX without removing a lock acquired by someone else.
+	CompareAndDelete(ctx context.Context, key string, expected interface{}) (bool, error)
 	RemoveWithPattern(ctx context.Context, pattern string) error
+	// RemoveWithPatternCount removes all keys matching pattern and returns the number of removed keys.
+	RemoveWithPatternCount(ctx context.Context, pattern string) (int, error)
 	Expire(ctx context.Context, key string, expire time.Duration) error
+	// Incr atomically increments the integer value of key by one and returns the new value.
+	// A missing key is treated as 0 and created without expiration.
+	Incr(ctx context.Context, key string) (int64, error)
+	// IncrBy atomically increments the integer value of key by delta and returns the new value.
+	// The TTL of an existing key is preserved.
+	IncrBy(ctx context.Context, key string, delta int64) (int64, error)
+	// IncrByIfExists increments the integer value of key by delta only if key exists, and reports
+	// whether it did. Unlike IncrBy it never creates a key, so it can't leave one without expiration.
+	// The TTL of the key is preserved.
+	IncrByIfExists(ctx context.Context, key string, delta int64) (int64, bool, error)
+	// GetOrSet scans the value of key into dest. If key does not exist, loader is called to compute the value,
+	// which is stored with expire and then scanned into dest. Concurrent callers for the same key
+	// share a single loader call.
+	GetOrSet(ctx context.Context, key string, dest interface{}, expire time.Duration, loader Loader) error
+	// TTL returns the remaining time to live of key.
+	// It returns NoTTL for a key without expiration and ErrNotExists for a missing key,
+	// including a key that expires between a previous Exist and this call.
+	// A key without expiration deliberately reports NoTTL rather than NoExpiration,
+	// since NoExpiration is 0 and would be indistinguishable from a key that is about to expire.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Ping checks that the cache is reachable, it is meant for readiness probes.
+	Ping(ctx context.Context) error
 }
 
 func IsNotExists(e error) bool {
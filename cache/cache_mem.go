@@ -1,8 +1,10 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"encoding"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -15,7 +17,13 @@ type entry struct {
 	value    []byte
 }
 
-func (e entry) scan(value interface{}) error {
+func (e entry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && now.After(e.expireAt)
+}
+
+// scan decodes the stored value into value. Types without a native encoding are decoded
+// from JSON only when jsonValues is set, see NewMemoryWithJSONValues.
+func (e entry) scan(value interface{}, jsonValues bool) error {
 	switch v := value.(type) {
 	case nil:
 		return fmt.Errorf("memory cache: can't scan %T", v)
@@ -130,13 +138,40 @@ func (e entry) scan(value interface{}) error {
 	case encoding.BinaryUnmarshaler:
 		return v.UnmarshalBinary(e.value)
 	default:
-		return fmt.Errorf("memory cache: can't unmarshall %T (implement json.Unmarshaler)", v)
+		if !jsonValues {
+			return fmt.Errorf("memory cache: can't unmarshall %T (implement encoding.BinaryUnmarshaler)", v)
+		}
+		// values of other types are stored as JSON by marshallValue
+		if err := json.Unmarshal(e.value, v); err != nil {
+			return fmt.Errorf("memory cache: can't unmarshal %T: %w", v, err)
+		}
+		return nil
 	}
 }
 
 type memoryKV struct {
+	// storage maps keys to *entry. Entries are never modified in place, so expired ones can be
+	// removed with CompareAndDelete without losing a concurrent write.
 	storage *sync.Map
 	Now     func() time.Time
+	// mu serializes every write, so a Set or Remove can't land between the read and the Store
+	// of a read-modify-write operation such as Update, Expire, SetNX or IncrBy and be lost.
+	mu sync.Mutex
+	// flight deduplicates concurrent loaders in GetOrSet.
+	flight flightGroup
+	// jsonValues stores values of types without a native encoding as JSON.
+	jsonValues bool
+
+	// stop and done control the optional janitor started by NewMemoryWithOptions.
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// MemoryCache is a memory cache whose background janitor is stopped by Close.
+type MemoryCache interface {
+	Interface
+	Close() error
 }
 
 func (m *memoryKV) get(key string) (*entry, error) {
@@ -144,23 +179,23 @@ func (m *memoryKV) get(key string) (*entry, error) {
 	if !ok {
 		return nil, ErrNotExists
 	}
-	e := v.(entry)
-	if !e.expireAt.IsZero() {
-		now := m.Now()
-		if now.After(e.expireAt) {
-			m.storage.Delete(key)
-			return nil, ErrNotExists
-		}
+	stored := v.(*entry)
+	if stored.expired(m.Now()) {
+		m.storage.CompareAndDelete(key, stored)
+		return nil, ErrNotExists
 	}
+	e := *stored
 	return &e, nil
 }
 
 func (m *memoryKV) Update(ctx context.Context, key string, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	e, err := m.get(key)
 	if err != nil {
 		return err
 	}
-	e.value, err = marshallValue(value)
+	e.value, err = marshallValue(value, m.jsonValues)
 	if err != nil {
 		return err
 	}
@@ -176,7 +211,77 @@ func (m *memoryKV) Get(ctx context.Context, key string, value interface{}) error
 	if err != nil {
 		return err
 	}
-	return e.scan(value)
+	return e.scan(value, m.jsonValues)
+}
+
+func (m *memoryKV) MGet(ctx context.Context, keys []string, dest []interface{}) error {
+	if err := checkMGetDest(keys, dest); err != nil {
+		return err
+	}
+	for i, key := range keys {
+		e, err := m.get(key)
+		if IsNotExists(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := e.scan(dest[i], m.jsonValues); err != nil {
+			return fmt.Errorf("memory cache: scan %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (m *memoryKV) MSet(ctx context.Context, pairs map[string]interface{}, expire time.Duration) error {
+	var expireAt time.Time
+	if expire > NoExpiration {
+		expireAt = m.Now().Add(expire)
+	}
+	// marshal everything first so a bad value does not leave a partial write
+	entries := make(map[string]*entry, len(pairs))
+	for key, value := range pairs {
+		b, err := marshallValue(value, m.jsonValues)
+		if err != nil {
+			return err
+		}
+		entries[key] = &entry{expireAt: expireAt, value: b}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, e := range entries {
+		m.storage.Store(key, e)
+	}
+	return nil
+}
+
+func (m *memoryKV) GetMulti(ctx context.Context, keys []string, dest map[string]interface{}) error {
+	if err := checkGetMultiDest(keys, dest); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		e, err := m.get(key)
+		if IsNotExists(err) {
+			delete(dest, key)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := e.scan(dest[key], m.jsonValues); err != nil {
+			return fmt.Errorf("memory cache: scan %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (m *memoryKV) SetMulti(ctx context.Context, items map[string]interface{}, expire time.Duration) error {
+	return m.MSet(ctx, items, expire)
+}
+
+// Ping always succeeds since the memory cache lives in process.
+func (m *memoryKV) Ping(ctx context.Context) error {
+	return nil
 }
 
 func (m *memoryKV) Exist(ctx context.Context, key string) (bool, error) {
@@ -191,21 +296,59 @@ func (m *memoryKV) Exist(ctx context.Context, key string) (bool, error) {
 }
 
 func (m *memoryKV) Remove(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.storage.Delete(key)
 	return nil
 }
 
+func (m *memoryKV) CompareAndDelete(ctx context.Context, key string, expected interface{}) (bool, error) {
+	b, err := marshallValue(expected, m.jsonValues)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.storage.Load(key)
+	if !ok {
+		return false, nil
+	}
+	e := v.(*entry)
+	if e.expired(m.Now()) || !bytes.Equal(e.value, b) {
+		return false, nil
+	}
+	return m.storage.CompareAndDelete(key, e), nil
+}
+
 func (m *memoryKV) Expire(ctx context.Context, key string, expire time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	e, err := m.get(key)
 	if err != nil {
 		return err
 	}
 	e.expireAt = m.Now().Add(expire)
 
-	m.storage.Store(key, *e)
+	m.storage.Store(key, e)
 	return nil
 }
 
+func (m *memoryKV) TTL(ctx context.Context, key string) (time.Duration, error) {
+	e, err := m.get(key)
+	if err != nil {
+		return 0, err
+	}
+	if e.expireAt.IsZero() {
+		return NoTTL, nil
+	}
+	// the key may reach its expiration after get, never report a non-positive TTL for it
+	ttl := e.expireAt.Sub(m.Now())
+	if ttl <= 0 {
+		return 0, ErrNotExists
+	}
+	return ttl, nil
+}
+
 func (m *memoryKV) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
 	var (
 		expireAt time.Time
@@ -217,15 +360,99 @@ func (m *memoryKV) Set(ctx context.Context, key string, value interface{}, expir
 	e := entry{
 		expireAt: expireAt,
 	}
-	e.value, err = marshallValue(value)
+	e.value, err = marshallValue(value, m.jsonValues)
 	if err != nil {
 		return err
 	}
-	m.storage.Store(key, e)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.storage.Store(key, &e)
 	return nil
 }
 
-func marshallValue(value interface{}) ([]byte, error) {
+func (m *memoryKV) SetNX(ctx context.Context, key string, value interface{}, expire time.Duration) (bool, error) {
+	e := &entry{}
+	if expire > NoExpiration {
+		e.expireAt = m.Now().Add(expire)
+	}
+	var err error
+	e.value, err = marshallValue(value, m.jsonValues)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		actual, loaded := m.storage.LoadOrStore(key, e)
+		if !loaded {
+			return true, nil
+		}
+		current := actual.(*entry)
+		if !current.expired(m.Now()) {
+			return false, nil
+		}
+		// the existing entry has expired, replace it unless it was changed concurrently
+		if m.storage.CompareAndSwap(key, current, e) {
+			return true, nil
+		}
+	}
+}
+
+func (m *memoryKV) GetOrSet(ctx context.Context, key string, dest interface{}, expire time.Duration, loader Loader) error {
+	return getOrSet(ctx, m, &m.flight, key, dest, func() error {
+		return loadAndSet(ctx, m, key, expire, loader)
+	})
+}
+
+func (m *memoryKV) Incr(ctx context.Context, key string) (int64, error) {
+	return m.IncrBy(ctx, key, 1)
+}
+
+func (m *memoryKV) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var e entry
+	var n int64
+	if current, err := m.get(key); err == nil {
+		e = *current
+		n, err = strconv.ParseInt(string(e.value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("memory cache: value of %s is not an integer", key)
+		}
+	} else if !IsNotExists(err) {
+		return 0, err
+	}
+
+	n += delta
+	e.value = []byte(strconv.FormatInt(n, 10))
+	m.storage.Store(key, &e)
+	return n, nil
+}
+
+func (m *memoryKV) IncrByIfExists(ctx context.Context, key string, delta int64) (int64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, err := m.get(key)
+	if IsNotExists(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	n, err := strconv.ParseInt(string(e.value), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("memory cache: value of %s is not an integer", key)
+	}
+	n += delta
+	e.value = []byte(strconv.FormatInt(n, 10))
+	m.storage.Store(key, e)
+	return n, true, nil
+}
+
+func marshallValue(value interface{}, jsonValues bool) ([]byte, error) {
 	switch v := value.(type) {
 	case nil:
 		return []byte(""), nil
@@ -269,32 +496,126 @@ func marshallValue(value interface{}) ([]byte, error) {
 	case encoding.BinaryMarshaler:
 		return v.MarshalBinary()
 	default:
-		return nil, fmt.Errorf(
-			"memory cache: can't marshal %T (implement encoding.BinaryMarshaler)", v)
+		if !jsonValues {
+			return nil, fmt.Errorf("memory cache: can't marshal %T (implement encoding.BinaryMarshaler)", v)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("memory cache: can't marshal %T: %w", v, err)
+		}
+		return b, nil
 	}
 }
 
 // RemoveWithPattern removes all keys with the given pattern.
 // memoryKV only support pattern with suffix "*". eg: `prefix:*` will remove all keys with `prefix:`
 func (m *memoryKV) RemoveWithPattern(ctx context.Context, pattern string) error {
+	_, err := m.RemoveWithPatternCount(ctx, pattern)
+	return err
+}
+
+// RemoveWithPatternCount removes all keys with the given pattern and returns the number of removed keys.
+// Expired keys are dropped but not counted.
+func (m *memoryKV) RemoveWithPatternCount(ctx context.Context, pattern string) (int, error) {
 	var keys []string
 	prefix := strings.TrimSuffix(pattern, "*")
 	m.storage.Range(func(key, value interface{}) bool {
 		k := key.(string)
 		if strings.HasPrefix(k, prefix) {
-			keys = append(keys, k)
+			if _, err := m.get(k); err == nil {
+				keys = append(keys, k)
+			}
 		}
 		return true
 	})
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for _, k := range keys {
 		m.storage.Delete(k)
 	}
-	return nil
+	return len(keys), nil
 }
 
+// NewMemory creates a memory cache. Expired entries are only removed lazily when they are accessed,
+// so keys that are never read again stay in memory; use NewMemoryWithOptions to reclaim them.
 func NewMemory() (Interface, error) {
 	return &memoryKV{
 		storage: &sync.Map{},
 		Now:     time.Now,
 	}, nil
 }
+
+// NewMemoryWithCleanup creates a memory cache with a janitor that removes expired entries every interval.
+// Close must be called to stop the janitor.
+func NewMemoryWithCleanup(interval time.Duration) (MemoryCache, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("cleanup interval must be positive")
+	}
+	return NewMemoryWithOptions(interval)
+}
+
+// NewMemoryWithOptions creates a memory cache that sweeps expired entries every sweepInterval.
+// A sweepInterval <= 0 disables the sweeper and behaves like NewMemory.
+// Close must be called to stop the sweeper.
+func NewMemoryWithOptions(sweepInterval time.Duration) (MemoryCache, error) {
+	return newMemory(time.Now, sweepInterval), nil
+}
+
+// NewMemoryWithJSONValues is like NewMemoryWithOptions, but also stores values of types without a native
+// encoding, such as structs that don't implement encoding.BinaryMarshaler, as JSON and decodes them on Get.
+// Other memory caches reject such values.
+func NewMemoryWithJSONValues(sweepInterval time.Duration) (MemoryCache, error) {
+	m := newMemory(time.Now, sweepInterval)
+	m.jsonValues = true
+	return m, nil
+}
+
+func newMemory(now func() time.Time, sweepInterval time.Duration) *memoryKV {
+	m := &memoryKV{
+		storage: &sync.Map{},
+		Now:     now,
+	}
+	if sweepInterval > 0 {
+		m.stop = make(chan struct{})
+		m.done = make(chan struct{})
+		go m.runJanitor(sweepInterval)
+	}
+	return m
+}
+
+// Close stops the janitor if there is one. It is safe to call Close more than once.
+func (m *memoryKV) Close() error {
+	if m.stop == nil {
+		return nil
+	}
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+	<-m.done
+	return nil
+}
+
+func (m *memoryKV) runJanitor(interval time.Duration) {
+	defer close(m.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.deleteExpired()
+		}
+	}
+}
+
+// deleteExpired removes all expired entries. An entry replaced by a concurrent write is kept.
+func (m *memoryKV) deleteExpired() {
+	now := m.Now()
+	m.storage.Range(func(key, value interface{}) bool {
+		if e := value.(*entry); e.expired(now) {
+			m.storage.CompareAndDelete(key, e)
+		}
+		return true
+	})
+}
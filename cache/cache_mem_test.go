@@ -0,0 +1,569 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryIncr(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := &memoryKV{storage: &sync.Map{}, Now: func() time.Time { return now }}
+
+	if n, err := c.Incr(ctx, "counter"); err != nil || n != 1 {
+		t.Fatalf("Incr() = %d, %v, want 1", n, err)
+	}
+	if n, err := c.IncrBy(ctx, "counter", 10); err != nil || n != 11 {
+		t.Fatalf("IncrBy() = %d, %v, want 11", n, err)
+	}
+	if n, err := c.IncrBy(ctx, "counter", -20); err != nil || n != -9 {
+		t.Fatalf("IncrBy() = %d, %v, want -9", n, err)
+	}
+
+	// Incr keeps the existing expiration
+	if err := c.Set(ctx, "ttl", 5, time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if n, err := c.Incr(ctx, "ttl"); err != nil || n != 6 {
+		t.Fatalf("Incr() = %d, %v, want 6", n, err)
+	}
+	now = now.Add(2 * time.Minute)
+	if exist, _ := c.Exist(ctx, "ttl"); exist {
+		t.Error("incremented key should keep its expiration")
+	}
+	if n, err := c.Incr(ctx, "ttl"); err != nil || n != 1 {
+		t.Errorf("Incr() on expired key = %d, %v, want 1", n, err)
+	}
+
+	if err := c.Set(ctx, "text", "abc", NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := c.Incr(ctx, "text"); err == nil {
+		t.Error("Incr() on non-integer value should fail")
+	}
+}
+
+func TestMemoryIncrByIfExists(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := &memoryKV{storage: &sync.Map{}, Now: func() time.Time { return now }}
+
+	if n, ok, err := c.IncrByIfExists(ctx, "counter", -1); err != nil || ok || n != 0 {
+		t.Fatalf("IncrByIfExists() on missing key = %d, %v, %v, want not applied", n, ok, err)
+	}
+	if exist, _ := c.Exist(ctx, "counter"); exist {
+		t.Fatal("IncrByIfExists() should not create the key")
+	}
+
+	if err := c.Set(ctx, "counter", 3, time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if n, ok, err := c.IncrByIfExists(ctx, "counter", -1); err != nil || !ok || n != 2 {
+		t.Fatalf("IncrByIfExists() = %d, %v, %v, want 2", n, ok, err)
+	}
+	if ttl, err := c.TTL(ctx, "counter"); err != nil || ttl != time.Minute {
+		t.Errorf("TTL() = %v, %v, want the expiration kept", ttl, err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := c.IncrByIfExists(ctx, "counter", -1); ok {
+		t.Error("IncrByIfExists() on expired key should not apply")
+	}
+}
+
+func TestMemoryIncrConcurrent(t *testing.T) {
+	ctx := context.Background()
+	c, _ := NewMemory()
+
+	const workers, perWorker = 20, 500
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				if _, err := c.Incr(ctx, "counter"); err != nil {
+					t.Errorf("Incr() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var n int64
+	if err := c.Get(ctx, "counter", &n); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if n != workers*perWorker {
+		t.Errorf("counter = %d, want %d", n, workers*perWorker)
+	}
+}
+
+func TestMemoryRemoveDuringExpire(t *testing.T) {
+	// interleave the goroutines even on a single CPU
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	ctx := context.Background()
+	c, _ := NewMemory()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = c.Expire(ctx, "key", time.Minute)
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	// a Remove that lands between the read and the Store of Expire must not be undone
+	for i := 0; i < 20000; i++ {
+		if err := c.Set(ctx, "key", i, NoExpiration); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		if err := c.Remove(ctx, "key"); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+		// give an Expire that read the key before Remove the time to store it back
+		for j := 0; j < 10; j++ {
+			if exist, _ := c.Exist(ctx, "key"); exist {
+				t.Fatalf("iteration %d: removed key came back", i)
+			}
+		}
+	}
+}
+
+func TestMemoryTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := &memoryKV{storage: &sync.Map{}, Now: func() time.Time { return now }}
+
+	if _, err := c.TTL(ctx, "missing"); !IsNotExists(err) {
+		t.Errorf("TTL() on missing key error = %v, want ErrNotExists", err)
+	}
+
+	if err := c.Set(ctx, "forever", "v", NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if ttl, err := c.TTL(ctx, "forever"); err != nil || ttl != NoTTL {
+		t.Errorf("TTL() = %v, %v, want NoTTL", ttl, err)
+	}
+
+	if err := c.Set(ctx, "code", "123456", time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	now = now.Add(20 * time.Second)
+	if ttl, err := c.TTL(ctx, "code"); err != nil || ttl != 40*time.Second {
+		t.Errorf("TTL() = %v, %v, want 40s", ttl, err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := c.TTL(ctx, "code"); !IsNotExists(err) {
+		t.Errorf("TTL() on expired key error = %v, want ErrNotExists", err)
+	}
+
+	// the key expires between Exist and TTL
+	if err := c.Set(ctx, "short", "v", time.Second); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if exist, _ := c.Exist(ctx, "short"); !exist {
+		t.Fatal("Exist() = false, want true")
+	}
+	now = now.Add(time.Second)
+	if ttl, err := c.TTL(ctx, "short"); !IsNotExists(err) {
+		t.Errorf("TTL() = %v, %v, want ErrNotExists", ttl, err)
+	}
+}
+
+func TestMemoryRemoveWithPatternCount(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := &memoryKV{storage: &sync.Map{}, Now: func() time.Time { return now }}
+
+	for _, key := range []string{"token:1:a", "token:1:b", "token:1:c", "token:2:a", "other"} {
+		if err := c.Set(ctx, key, "v", NoExpiration); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	if err := c.Set(ctx, "token:1:expired", "v", time.Second); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	now = now.Add(time.Minute)
+
+	tests := []struct {
+		pattern string
+		want    int
+	}{
+		{pattern: "token:1:*", want: 3},
+		{pattern: "token:1:*", want: 0},
+		{pattern: "token:*", want: 1},
+		{pattern: "missing:*", want: 0},
+	}
+	for _, tt := range tests {
+		n, err := c.RemoveWithPatternCount(ctx, tt.pattern)
+		if err != nil || n != tt.want {
+			t.Errorf("RemoveWithPatternCount(%s) = %d, %v, want %d", tt.pattern, n, err, tt.want)
+		}
+	}
+	if exist, _ := c.Exist(ctx, "other"); !exist {
+		t.Error("keys outside the pattern should not be removed")
+	}
+}
+
+type testProfile struct {
+	Name    string            `json:"name"`
+	Tags    []string          `json:"tags"`
+	Address testAddress       `json:"address"`
+	Extra   map[string]string `json:"extra"`
+}
+
+type testAddress struct {
+	City string `json:"city"`
+	Zip  *int   `json:"zip"`
+}
+
+func TestMemoryJSONValue(t *testing.T) {
+	ctx := context.Background()
+	c, _ := NewMemoryWithJSONValues(0)
+
+	zip := 100000
+	want := testProfile{
+		Name:    "alice",
+		Tags:    []string{"admin", "dev"},
+		Address: testAddress{City: "Beijing", Zip: &zip},
+		Extra:   map[string]string{"team": "infra"},
+	}
+	if err := c.Set(ctx, "profile", want, NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	var got testProfile
+	if err := c.Get(ctx, "profile", &got); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Name != want.Name || len(got.Tags) != 2 || got.Address.City != want.Address.City ||
+		got.Address.Zip == nil || *got.Address.Zip != zip || got.Extra["team"] != "infra" {
+		t.Errorf("Get() = %+v, want %+v", got, want)
+	}
+
+	// pointers and maps go through the same path
+	if err := c.Set(ctx, "map", map[string]int{"a": 1}, NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	var m map[string]int
+	if err := c.Get(ctx, "map", &m); err != nil || m["a"] != 1 {
+		t.Errorf("Get() = %v, %v, want map[a:1]", m, err)
+	}
+
+	if err := c.Set(ctx, "bad", func() {}, NoExpiration); err == nil {
+		t.Error("Set() with a value that can't be marshaled should fail")
+	}
+	if err := c.Get(ctx, "profile", got); err == nil {
+		t.Error("Get() into a non-pointer should fail")
+	}
+
+	// without the option structs are rejected
+	plain, _ := NewMemory()
+	if err := plain.Set(ctx, "profile", want, NoExpiration); err == nil || !strings.Contains(err.Error(), "can't marshal") {
+		t.Errorf("Set() without JSON values error = %v, want can't marshal", err)
+	}
+	_ = plain.Set(ctx, "raw", `{"name":"alice"}`, NoExpiration)
+	if err := plain.Get(ctx, "raw", &got); err == nil {
+		t.Error("Get() into a struct without JSON values should fail")
+	}
+}
+
+func TestMemoryWithCleanup(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewMemoryWithCleanup(10 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewMemoryWithCleanup() error = %v", err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := c.Set(ctx, "short:"+strconv.Itoa(i), i, 20*time.Millisecond); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	if err := c.Set(ctx, "forever", "v", NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	size := func() int {
+		n := 0
+		c.(*memoryKV).storage.Range(func(_, _ interface{}) bool {
+			n++
+			return true
+		})
+		return n
+	}
+	if n := size(); n != 11 {
+		t.Fatalf("size = %d, want 11", n)
+	}
+
+	// expired entries are removed without any Get calls
+	deadline := time.Now().Add(5 * time.Second)
+	for size() != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := size(); n != 1 {
+		t.Errorf("size = %d after cleanup, want 1", n)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+
+	if _, err := NewMemoryWithCleanup(0); err == nil {
+		t.Error("NewMemoryWithCleanup(0) should fail")
+	}
+}
+
+func TestMemoryMGetMSet(t *testing.T) {
+	ctx := context.Background()
+	shared, _ := NewMemory()
+	for name, c := range map[string]Interface{"memory": shared, "prefix": WithPrefix(shared, "p:")} {
+		t.Run(name, func(t *testing.T) {
+			if err := c.MSet(ctx, map[string]interface{}{
+				"session:1": "a",
+				"session:3": "c",
+				"count":     7,
+			}, time.Minute); err != nil {
+				t.Fatalf("MSet() error = %v", err)
+			}
+			if ttl, err := c.TTL(ctx, "session:1"); err != nil || ttl <= 0 {
+				t.Errorf("TTL() = %v, %v, want positive", ttl, err)
+			}
+
+			var s1, s2, s3 string
+			var count int
+			keys := []string{"session:1", "session:2", "session:3", "count"}
+			if err := c.MGet(ctx, keys, []interface{}{&s1, &s2, &s3, &count}); err != nil {
+				t.Fatalf("MGet() error = %v", err)
+			}
+			if s1 != "a" || s2 != "" || s3 != "c" || count != 7 {
+				t.Errorf("MGet() = %q, %q, %q, %d, want a, \"\", c, 7", s1, s2, s3, count)
+			}
+
+			if err := c.MGet(ctx, keys, []interface{}{&s1}); err == nil {
+				t.Error("MGet() with mismatched dest should fail")
+			}
+			if err := c.MGet(ctx, []string{"session:1"}, []interface{}{nil}); !errors.Is(err, ErrScanValueIsNil) {
+				t.Errorf("MGet() with nil dest error = %v, want ErrScanValueIsNil", err)
+			}
+			if err := c.MSet(ctx, map[string]interface{}{"ok": "v", "bad": func() {}}, NoExpiration); err == nil {
+				t.Error("MSet() with a value that can't be marshaled should fail")
+			}
+		})
+	}
+	if exist, _ := shared.Exist(ctx, "ok"); exist {
+		t.Error("MSet() should not write any key when a value can't be marshaled")
+	}
+}
+
+func TestMemorySweeper(t *testing.T) {
+	ctx := context.Background()
+	var clock atomic.Int64
+	clock.Store(time.Now().UnixNano())
+	c := newMemory(func() time.Time { return time.Unix(0, clock.Load()) }, 5*time.Millisecond)
+	defer c.Close()
+
+	size := func() int {
+		n := 0
+		c.storage.Range(func(_, _ interface{}) bool {
+			n++
+			return true
+		})
+		return n
+	}
+	waitForSize := func(want int) bool {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if size() == want {
+				return true
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		return false
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := c.Set(ctx, "otp:"+strconv.Itoa(i), "123456", time.Minute); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	if err := c.Set(ctx, "long", "v", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set(ctx, "forever", "v", NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// nothing has expired yet
+	time.Sleep(20 * time.Millisecond)
+	if n := size(); n != 12 {
+		t.Fatalf("size = %d before expiration, want 12", n)
+	}
+
+	// concurrent writers keep refreshing one key while the sweeper runs
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = c.Set(ctx, "hot", "v", time.Hour)
+				var v string
+				_ = c.Get(ctx, "hot", &v)
+			}
+		}
+	}()
+
+	clock.Add(int64(2 * time.Minute))
+	if !waitForSize(3) {
+		t.Errorf("size = %d after expiration, want 3", size())
+	}
+	close(stop)
+	wg.Wait()
+
+	for _, key := range []string{"long", "forever", "hot"} {
+		if exist, _ := c.Exist(ctx, key); !exist {
+			t.Errorf("%s should not be swept", key)
+		}
+	}
+
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	lazy, _ := NewMemoryWithOptions(0)
+	if err := lazy.Close(); err != nil {
+		t.Errorf("Close() without sweeper error = %v", err)
+	}
+}
+
+func TestMemorySetNX(t *testing.T) {
+	ctx := context.Background()
+	var clock atomic.Int64
+	clock.Store(time.Now().UnixNano())
+	c := newMemory(func() time.Time { return time.Unix(0, clock.Load()) }, 0)
+
+	const workers = 100
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := c.SetNX(ctx, "lock", i, time.Minute)
+			if err != nil {
+				t.Errorf("SetNX() error = %v", err)
+				return
+			}
+			if ok {
+				wins.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := wins.Load(); n != 1 {
+		t.Fatalf("%d goroutines won the lock, want 1", n)
+	}
+
+	if ok, err := c.SetNX(ctx, "lock", "again", time.Minute); err != nil || ok {
+		t.Errorf("SetNX() on held lock = %v, %v, want false", ok, err)
+	}
+
+	// an expired key can be taken again
+	clock.Add(int64(2 * time.Minute))
+	if ok, err := c.SetNX(ctx, "lock", "again", time.Minute); err != nil || !ok {
+		t.Errorf("SetNX() on expired key = %v, %v, want true", ok, err)
+	}
+	var got string
+	if err := c.Get(ctx, "lock", &got); err != nil || got != "again" {
+		t.Errorf("Get() = %q, %v, want again", got, err)
+	}
+	if ttl, err := c.TTL(ctx, "lock"); err != nil || ttl != time.Minute {
+		t.Errorf("TTL() = %v, %v, want 1m", ttl, err)
+	}
+}
+
+func TestMemoryCompareAndDelete(t *testing.T) {
+	ctx := context.Background()
+	var clock atomic.Int64
+	clock.Store(time.Now().UnixNano())
+	mem := newMemory(func() time.Time { return time.Unix(0, clock.Load()) }, 0)
+
+	for name, c := range map[string]Interface{"memory": mem, "prefix": WithPrefix(mem, "p:")} {
+		t.Run(name, func(t *testing.T) {
+			if ok, err := c.SetNX(ctx, "lock", "owner-a", time.Minute); err != nil || !ok {
+				t.Fatalf("SetNX() = %v, %v, want true", ok, err)
+			}
+			if ok, err := c.CompareAndDelete(ctx, "lock", "owner-b"); err != nil || ok {
+				t.Errorf("CompareAndDelete() by another owner = %v, %v, want false", ok, err)
+			}
+			if exist, _ := c.Exist(ctx, "lock"); !exist {
+				t.Fatal("lock should still be held")
+			}
+			if ok, err := c.CompareAndDelete(ctx, "lock", "owner-a"); err != nil || !ok {
+				t.Errorf("CompareAndDelete() by owner = %v, %v, want true", ok, err)
+			}
+			if ok, err := c.CompareAndDelete(ctx, "lock", "owner-a"); err != nil || ok {
+				t.Errorf("CompareAndDelete() on missing key = %v, %v, want false", ok, err)
+			}
+
+			// an expired lock counts as absent: it can be taken again and the old owner can't release it
+			if ok, _ := c.SetNX(ctx, "lock", "owner-a", time.Second); !ok {
+				t.Fatal("SetNX() should take the released lock")
+			}
+			clock.Add(int64(2 * time.Second))
+			if ok, err := c.CompareAndDelete(ctx, "lock", "owner-a"); err != nil || ok {
+				t.Errorf("CompareAndDelete() on expired key = %v, %v, want false", ok, err)
+			}
+			if ok, err := c.SetNX(ctx, "lock", "owner-b", time.Minute); err != nil || !ok {
+				t.Errorf("SetNX() on expired key = %v, %v, want true", ok, err)
+			}
+			if ok, _ := c.CompareAndDelete(ctx, "lock", "owner-a"); ok {
+				t.Error("the previous owner should not release the new lock")
+			}
+			if ok, _ := c.CompareAndDelete(ctx, "lock", "owner-b"); !ok {
+				t.Error("the new owner should release the lock")
+			}
+		})
+	}
+}
+
+func TestMemoryPing(t *testing.T) {
+	mem, err := NewMemoryWithCleanup(time.Minute)
+	if err != nil {
+		t.Fatalf("NewMemoryWithCleanup() error = %v", err)
+	}
+	for name, c := range map[string]Interface{"memory": mem, "prefix": WithPrefix(mem, "p:")} {
+		if err := c.Ping(context.Background()); err != nil {
+			t.Errorf("%s Ping() error = %v", name, err)
+		}
+	}
+	_ = mem.Close()
+	if err := mem.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() after Close() error = %v", err)
+	}
+}
@@ -2,38 +2,148 @@ package cache
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"time"
 
 	redisv9 "github.com/redis/go-redis/v9"
 )
 
+const (
+	// redisPingTimeout bounds the connectivity check done by NewRedis.
+	redisPingTimeout = 3 * time.Second
+	// getOrSetLockTTL bounds how long a GetOrSet lock is held if its owner dies while loading.
+	getOrSetLockTTL = 10 * time.Second
+	// getOrSetRetryInterval is how often GetOrSet checks whether another process has stored the value.
+	getOrSetRetryInterval = 50 * time.Millisecond
+)
+
 type redisKV struct {
 	client redisv9.Cmdable
+	// flight deduplicates concurrent loaders in GetOrSet within the process.
+	flight flightGroup
+	// jsonValues stores values go-redis can't encode as JSON, see RedisOptions.JSONValues.
+	jsonValues bool
 }
 
 func (r *redisKV) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
-	_, err := r.client.Set(context.TODO(), key, value, expire).Result()
+	arg, err := redisArg(value, r.jsonValues)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.Set(context.TODO(), key, arg, expire).Result()
 	return err
 }
 
 func (r *redisKV) Update(ctx context.Context, key string, value interface{}) error {
-	ttl, err := r.client.TTL(ctx, key).Result()
+	arg, err := redisArg(value, r.jsonValues)
+	if err != nil {
+		return err
+	}
+	// PTTL keeps the remaining expiration to the millisecond, -1 maps to KeepTTL
+	ttl, err := r.client.PTTL(ctx, key).Result()
 	if err != nil {
 		return err
 	}
-	return r.client.Set(ctx, key, value, ttl).Err()
+	return r.client.Set(ctx, key, arg, ttl).Err()
 }
 
 func (r *redisKV) Get(ctx context.Context, key string, value interface{}) error {
-	err := r.client.Get(ctx, key).Scan(value)
+	s, err := r.client.Get(ctx, key).Result()
 	if errors.Is(redisv9.Nil, err) {
 		return ErrNotExists
 	}
+	if err != nil {
+		return err
+	}
+	return redisScan(s, value, r.jsonValues)
+}
+
+func (r *redisKV) MGet(ctx context.Context, keys []string, dest []interface{}) error {
+	if err := checkMGetDest(keys, dest); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return err
+	}
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			// missing key
+			continue
+		}
+		if err := redisScan(s, dest[i], r.jsonValues); err != nil {
+			return fmt.Errorf("redis: scan %s: %w", keys[i], err)
+		}
+	}
+	return nil
+}
+
+// MSet pipelines one SET per key, since MSET can't set an expiration.
+func (r *redisKV) MSet(ctx context.Context, pairs map[string]interface{}, expire time.Duration) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+	args := make(map[string]interface{}, len(pairs))
+	for key, value := range pairs {
+		arg, err := redisArg(value, r.jsonValues)
+		if err != nil {
+			return err
+		}
+		args[key] = arg
+	}
+	_, err := r.client.Pipelined(ctx, func(pipe redisv9.Pipeliner) error {
+		for key, arg := range args {
+			pipe.Set(ctx, key, arg, expire)
+		}
+		return nil
+	})
 	return err
 }
 
+// GetMulti fetches keys with a single MGET.
+func (r *redisKV) GetMulti(ctx context.Context, keys []string, dest map[string]interface{}) error {
+	if err := checkGetMultiDest(keys, dest); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return err
+	}
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			// missing key
+			delete(dest, keys[i])
+			continue
+		}
+		if err := redisScan(s, dest[keys[i]], r.jsonValues); err != nil {
+			return fmt.Errorf("redis: scan %s: %w", keys[i], err)
+		}
+	}
+	return nil
+}
+
+func (r *redisKV) SetMulti(ctx context.Context, items map[string]interface{}, expire time.Duration) error {
+	return r.MSet(ctx, items, expire)
+}
+
+func (r *redisKV) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 func (r *redisKV) Exist(ctx context.Context, key string) (bool, error) {
 	count, err := r.client.Exists(ctx, key).Result()
 	return count > 0, err
@@ -43,11 +153,126 @@ func (r *redisKV) Remove(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+// compareAndDeleteScript deletes KEYS[1] only if its value equals ARGV[1].
+var compareAndDeleteScript = redisv9.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// incrByIfExistsScript increments KEYS[1] by ARGV[1] only if it exists, returning nil otherwise.
+var incrByIfExistsScript = redisv9.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("INCRBY", KEYS[1], ARGV[1])
+end
+return false
+`)
+
+func (r *redisKV) IncrByIfExists(ctx context.Context, key string, delta int64) (int64, bool, error) {
+	n, err := incrByIfExistsScript.Run(ctx, r.client, []string{key}, delta).Int64()
+	if errors.Is(err, redisv9.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return n, true, nil
+}
+
+func (r *redisKV) CompareAndDelete(ctx context.Context, key string, expected interface{}) (bool, error) {
+	arg, err := redisArg(expected, r.jsonValues)
+	if err != nil {
+		return false, err
+	}
+	n, err := compareAndDeleteScript.Run(ctx, r.client, []string{key}, arg).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
 func (r *redisKV) Expire(ctx context.Context, key string, expire time.Duration) error {
 	return r.client.Expire(ctx, key, expire).Err()
 }
 
+func (r *redisKV) TTL(ctx context.Context, key string) (time.Duration, error) {
+	// PTTL keeps millisecond precision, so short-lived keys don't round down to zero
+	ttl, err := r.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	return redisTTL(ttl)
+}
+
+// redisTTL maps the reply of the TTL and PTTL commands, where -2 means the key does not exist
+// and -1 means the key has no expiration.
+func redisTTL(ttl time.Duration) (time.Duration, error) {
+	switch ttl {
+	case -2:
+		return 0, ErrNotExists
+	case -1:
+		return NoTTL, nil
+	default:
+		return ttl, nil
+	}
+}
+
+// GetOrSet deduplicates loaders within the process and uses a SET NX lock on "<key>:lock"
+// so that only one process loads a missing key; the others wait until the value is stored.
+func (r *redisKV) GetOrSet(ctx context.Context, key string, dest interface{}, expire time.Duration, loader Loader) error {
+	return getOrSet(ctx, r, &r.flight, key, dest, func() error {
+		lockKey := key + ":lock"
+		token := make([]byte, 16)
+		if _, err := rand.Read(token); err != nil {
+			return err
+		}
+		owner := hex.EncodeToString(token)
+		for {
+			locked, err := r.SetNX(ctx, lockKey, owner, getOrSetLockTTL)
+			if err != nil {
+				return err
+			}
+			if locked {
+				// only release the lock if it has not expired and been taken by another process
+				defer r.CompareAndDelete(context.WithoutCancel(ctx), lockKey, owner)
+				return loadAndSet(ctx, r, key, expire, loader)
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(getOrSetRetryInterval):
+			}
+			if exist, err := r.Exist(ctx, key); err != nil || exist {
+				return err
+			}
+		}
+	})
+}
+
+func (r *redisKV) Incr(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, key).Result()
+}
+
+func (r *redisKV) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	return r.client.IncrBy(ctx, key, delta).Result()
+}
+
+func (r *redisKV) SetNX(ctx context.Context, key string, value interface{}, expire time.Duration) (bool, error) {
+	arg, err := redisArg(value, r.jsonValues)
+	if err != nil {
+		return false, err
+	}
+	return r.client.SetNX(ctx, key, arg, expire).Result()
+}
+
 func (r *redisKV) RemoveWithPattern(ctx context.Context, pattern string) error {
+	_, err := r.RemoveWithPatternCount(ctx, pattern)
+	return err
+}
+
+func (r *redisKV) RemoveWithPatternCount(ctx context.Context, pattern string) (int, error) {
 	var cursor uint64
 	var n int
 
@@ -56,50 +281,142 @@ func (r *redisKV) RemoveWithPattern(ctx context.Context, pattern string) error {
 		var err error
 		keys, cursor, err = r.client.Scan(ctx, cursor, pattern, 100).Result()
 		if err != nil {
-			return err
+			return n, err
 		}
-		n += len(keys)
 		if len(keys) > 0 {
-			r.client.Del(ctx, keys...)
+			deleted, err := r.client.Del(ctx, keys...).Result()
+			if err != nil {
+				return n, err
+			}
+			n += int(deleted)
 		}
 		if cursor == 0 {
 			break
 		}
 	}
-	return nil
+	return n, nil
 }
 
 func NewRedis(opt *RedisOptions) (Interface, error) {
+	if opt == nil {
+		return nil, fmt.Errorf("redis options cannot be empty")
+	}
 	if len(opt.Addrs) == 0 {
 		return nil, fmt.Errorf("redis addresses cannot be empty")
 	}
 
-	kv := redisKV{}
+	client, err := newRedisClient(opt)
+	if err != nil {
+		return nil, err
+	}
+	// fail at startup instead of on first use when redis is unreachable or misconfigured
+	ctx, cancel := context.WithTimeout(context.Background(), redisPingTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return &redisKV{client: client, jsonValues: opt.JSONValues}, nil
+}
+
+func newRedisClient(opt *RedisOptions) (redisv9.UniversalClient, error) {
+	t, err := opt.tuning()
+	if err != nil {
+		return nil, err
+	}
 
 	switch opt.Schema {
 	case Redis:
-		kv.client = redisv9.NewClient(&redisv9.Options{
-			Addr:     opt.Addrs[0],
-			Username: opt.Username,
-			Password: opt.Password,
-			DB:       opt.DB,
-		})
+		return redisv9.NewClient(&redisv9.Options{
+			Addr:         opt.Addrs[0],
+			Username:     opt.Username,
+			Password:     opt.Password,
+			DB:           opt.DB,
+			TLSConfig:    t.tls,
+			PoolSize:     t.poolSize,
+			MinIdleConns: t.minIdleConns,
+			DialTimeout:  t.dialTimeout,
+			ReadTimeout:  t.readTimeout,
+			WriteTimeout: t.writeTimeout,
+		}), nil
 	case RedisSentinel:
-		kv.client = redisv9.NewFailoverClient(&redisv9.FailoverOptions{
-			SentinelAddrs: opt.Addrs,
-			Username:      opt.Username,
-			Password:      opt.Password,
-			DB:            opt.DB,
-		})
+		if opt.MasterName == "" {
+			return nil, fmt.Errorf("redis master name is required for schema %s", RedisSentinel)
+		}
+		return redisv9.NewFailoverClient(failoverOptions(opt, t)), nil
 	case RedisCluster:
-		kv.client = redisv9.NewClusterClient(&redisv9.ClusterOptions{
-			Addrs:    opt.Addrs,
-			Username: opt.Username,
-			Password: opt.Password,
-		})
+		return redisv9.NewClusterClient(&redisv9.ClusterOptions{
+			Addrs:        opt.Addrs,
+			Username:     opt.Username,
+			Password:     opt.Password,
+			TLSConfig:    t.tls,
+			PoolSize:     t.poolSize,
+			MinIdleConns: t.minIdleConns,
+			DialTimeout:  t.dialTimeout,
+			ReadTimeout:  t.readTimeout,
+			WriteTimeout: t.writeTimeout,
+		}), nil
 	default:
 		return nil, fmt.Errorf("not support redis schema:%s", opt.Schema)
 	}
+}
 
-	return &kv, nil
+// failoverOptions maps RedisOptions to the sentinel client options, Addrs are the sentinel addresses.
+func failoverOptions(opt *RedisOptions, t redisTuning) *redisv9.FailoverOptions {
+	return &redisv9.FailoverOptions{
+		MasterName:       opt.MasterName,
+		SentinelAddrs:    opt.Addrs,
+		SentinelUsername: opt.SentinelUsername,
+		SentinelPassword: opt.SentinelPassword,
+		Username:         opt.Username,
+		Password:         opt.Password,
+		DB:               opt.DB,
+		TLSConfig:        t.tls,
+		PoolSize:         t.poolSize,
+		MinIdleConns:     t.minIdleConns,
+		DialTimeout:      t.dialTimeout,
+		ReadTimeout:      t.readTimeout,
+		WriteTimeout:     t.writeTimeout,
+	}
+}
+
+// redisArg returns value as is when go-redis can encode it or jsonValues is not set,
+// and encodes any other value as JSON, matching NewMemoryWithJSONValues.
+func redisArg(value interface{}, jsonValues bool) (interface{}, error) {
+	if !jsonValues {
+		return value, nil
+	}
+	switch value.(type) {
+	case nil, string, *string, []byte,
+		int, *int, int8, *int8, int16, *int16, int32, *int32, int64, *int64,
+		uint, *uint, uint8, *uint8, uint16, *uint16, uint32, *uint32, uint64, *uint64,
+		float32, *float32, float64, *float64, bool, *bool,
+		time.Time, *time.Time, time.Duration, *time.Duration,
+		encoding.BinaryMarshaler, net.IP:
+		return value, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("redis: can't marshal %T: %w", value, err)
+	}
+	return b, nil
+}
+
+// redisScan scans a reply into dest, using JSON for the types go-redis can't scan when jsonValues is set.
+func redisScan(s string, dest interface{}, jsonValues bool) error {
+	if !jsonValues {
+		return redisv9.NewStringResult(s, nil).Scan(dest)
+	}
+	switch dest.(type) {
+	case nil, *string, *[]byte,
+		*int, *int8, *int16, *int32, *int64,
+		*uint, *uint8, *uint16, *uint32, *uint64,
+		*float32, *float64, *bool, *time.Time, *time.Duration,
+		encoding.BinaryUnmarshaler, *net.IP:
+		return redisv9.NewStringResult(s, nil).Scan(dest)
+	}
+	if err := json.Unmarshal([]byte(s), dest); err != nil {
+		return fmt.Errorf("redis: can't unmarshal %T: %w", dest, err)
+	}
+	return nil
 }
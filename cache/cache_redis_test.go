@@ -0,0 +1,677 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	redisv9 "github.com/redis/go-redis/v9"
+
+	"github.com/x893675/valhalla-common/utils/cert"
+)
+
+func TestRedisTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   time.Duration
+		want    time.Duration
+		wantErr error
+	}{
+		{name: "missing key", reply: -2, wantErr: ErrNotExists},
+		{name: "no expiration", reply: -1, want: NoTTL},
+		{name: "expiring key", reply: 30 * time.Second, want: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := redisTTL(tt.reply)
+			if err != tt.wantErr {
+				t.Fatalf("redisTTL() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("redisTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisTTLThroughClient(t *testing.T) {
+	ctx := context.Background()
+	srv := newFakeRedis(t)
+	c, err := NewRedis(&RedisOptions{Schema: Redis, Addrs: []string{srv.addr}})
+	if err != nil {
+		t.Fatalf("NewRedis() error = %v", err)
+	}
+
+	if _, err := c.TTL(ctx, "missing"); !IsNotExists(err) {
+		t.Errorf("TTL() on missing key error = %v, want ErrNotExists", err)
+	}
+
+	if err := c.Set(ctx, "forever", "v", NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if ttl, err := c.TTL(ctx, "forever"); err != nil || ttl != NoTTL {
+		t.Errorf("TTL() = %v, %v, want NoTTL", ttl, err)
+	}
+
+	if err := c.Set(ctx, "code", "123456", time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	srv.advance(20 * time.Second)
+	if ttl, err := c.TTL(ctx, "code"); err != nil || ttl <= 39*time.Second || ttl > 40*time.Second {
+		t.Errorf("TTL() = %v, %v, want about 40s", ttl, err)
+	}
+
+	// the key expires between Exist and TTL
+	if err := c.Set(ctx, "short", "v", 1500*time.Millisecond); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if exist, _ := c.Exist(ctx, "short"); !exist {
+		t.Fatal("Exist() = false, want true")
+	}
+	srv.advance(1500 * time.Millisecond)
+	if ttl, err := c.TTL(ctx, "short"); !IsNotExists(err) {
+		t.Errorf("TTL() = %v, %v, want ErrNotExists", ttl, err)
+	}
+}
+
+func TestNewRedisClientOptions(t *testing.T) {
+	for _, schema := range []string{Redis, RedisSentinel, RedisCluster} {
+		t.Run(schema, func(t *testing.T) {
+			client, err := newRedisClient(&RedisOptions{
+				Schema:                schema,
+				Addrs:                 []string{"127.0.0.1:6379"},
+				MasterName:            "mymaster",
+				TLSEnabled:            true,
+				TLSInsecureSkipVerify: true,
+				PoolSize:              42,
+				MinIdleConns:          3,
+				DialTimeout:           "2s",
+				ReadTimeout:           "500ms",
+			})
+			if err != nil {
+				t.Fatalf("newRedisClient() error = %v", err)
+			}
+			defer client.Close()
+
+			var (
+				tlsConfig    *tls.Config
+				poolSize     int
+				minIdleConns int
+				dialTimeout  time.Duration
+				readTimeout  time.Duration
+				writeTimeout time.Duration
+			)
+			switch c := client.(type) {
+			case *redisv9.Client:
+				o := c.Options()
+				tlsConfig, poolSize, minIdleConns = o.TLSConfig, o.PoolSize, o.MinIdleConns
+				dialTimeout, readTimeout, writeTimeout = o.DialTimeout, o.ReadTimeout, o.WriteTimeout
+			case *redisv9.ClusterClient:
+				o := c.Options()
+				tlsConfig, poolSize, minIdleConns = o.TLSConfig, o.PoolSize, o.MinIdleConns
+				dialTimeout, readTimeout, writeTimeout = o.DialTimeout, o.ReadTimeout, o.WriteTimeout
+			default:
+				t.Fatalf("unexpected client type %T", client)
+			}
+
+			if tlsConfig == nil || !tlsConfig.InsecureSkipVerify {
+				t.Errorf("TLSConfig = %+v, want InsecureSkipVerify", tlsConfig)
+			}
+			if poolSize != 42 || minIdleConns != 3 {
+				t.Errorf("pool = %d/%d, want 42/3", poolSize, minIdleConns)
+			}
+			if dialTimeout != 2*time.Second || readTimeout != 500*time.Millisecond {
+				t.Errorf("timeouts = %v/%v, want 2s/500ms", dialTimeout, readTimeout)
+			}
+			// unset values fall back to the go-redis defaults
+			if writeTimeout != readTimeout {
+				t.Errorf("WriteTimeout = %v, want the go-redis default of ReadTimeout", writeTimeout)
+			}
+		})
+	}
+
+	client, err := newRedisClient(&RedisOptions{Schema: Redis, Addrs: []string{"127.0.0.1:6379"}})
+	if err != nil {
+		t.Fatalf("newRedisClient() error = %v", err)
+	}
+	defer client.Close()
+	if o := client.(*redisv9.Client).Options(); o.TLSConfig != nil || o.PoolSize <= 0 || o.DialTimeout <= 0 {
+		t.Errorf("default options = TLS %v, pool %d, dial %v", o.TLSConfig, o.PoolSize, o.DialTimeout)
+	}
+
+	if _, err := newRedisClient(&RedisOptions{Schema: Redis, Addrs: []string{"127.0.0.1:6379"}, DialTimeout: "soon"}); err == nil {
+		t.Error("newRedisClient() with an invalid timeout should fail")
+	}
+}
+
+func TestRedisFailoverOptions(t *testing.T) {
+	opt := &RedisOptions{
+		Schema:           RedisSentinel,
+		Addrs:            []string{"10.0.0.1:26379", "10.0.0.2:26379"},
+		MasterName:       "mymaster",
+		SentinelUsername: "sentinel",
+		SentinelPassword: "sentinel-secret",
+		Username:         "app",
+		Password:         "app-secret",
+		DB:               2,
+	}
+	tuning, err := opt.tuning()
+	if err != nil {
+		t.Fatalf("tuning() error = %v", err)
+	}
+	o := failoverOptions(opt, tuning)
+	if o.MasterName != "mymaster" || len(o.SentinelAddrs) != 2 {
+		t.Errorf("master = %q, sentinels = %v", o.MasterName, o.SentinelAddrs)
+	}
+	if o.SentinelUsername != "sentinel" || o.SentinelPassword != "sentinel-secret" {
+		t.Errorf("sentinel credentials = %q/%q", o.SentinelUsername, o.SentinelPassword)
+	}
+	if o.Username != "app" || o.Password != "app-secret" || o.DB != 2 {
+		t.Errorf("master credentials = %q/%q db %d", o.Username, o.Password, o.DB)
+	}
+
+	opt.MasterName = ""
+	if _, err := newRedisClient(opt); err == nil {
+		t.Error("newRedisClient() without a master name should fail")
+	}
+}
+
+func TestRedisTLSFiles(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := cert.NewCA(cert.Config{CommonName: "redis-ca"})
+	if err != nil {
+		t.Fatalf("NewCA() error = %v", err)
+	}
+	client, err := ca.NewSignedCert(cert.Config{
+		CommonName: "redis-client",
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		t.Fatalf("NewSignedCert() error = %v", err)
+	}
+	caFile := filepath.Join(dir, "ca.crt")
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	if err := cert.WriteCertToFile(caFile, ca.Certificate); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SaveToFile(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+
+	opt := &RedisOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}
+	tuning, err := opt.tuning()
+	if err != nil {
+		t.Fatalf("tuning() error = %v", err)
+	}
+	cfg := tuning.tls
+	if cfg == nil {
+		t.Fatal("TLS should be enabled by the certificate files")
+	}
+	if cfg.InsecureSkipVerify || cfg.RootCAs == nil {
+		t.Errorf("TLS config = %+v, want verification against the CA file", cfg)
+	}
+	if len(cfg.Certificates) != 1 || !cfg.Certificates[0].Leaf.Equal(client.Certificate) {
+		t.Errorf("client certificates = %v", cfg.Certificates)
+	}
+	if _, err := client.Certificate.Verify(x509.VerifyOptions{
+		Roots:     cfg.RootCAs,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		t.Errorf("RootCAs should trust the CA file: %v", err)
+	}
+
+	invalid := []*RedisOptions{
+		{CertFile: certFile},
+		{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.key")},
+		{CAFile: keyFile},
+		{CertFile: caFile, KeyFile: keyFile},
+	}
+	for _, opt := range invalid {
+		if _, err := opt.tuning(); err == nil {
+			t.Errorf("tuning(%+v) should fail", opt)
+		}
+	}
+}
+
+func TestNewRedisPingFailure(t *testing.T) {
+	start := time.Now()
+	_, err := NewRedis(&RedisOptions{
+		Schema:      Redis,
+		Addrs:       []string{"127.0.0.1:1"},
+		DialTimeout: "200ms",
+	})
+	if err == nil {
+		t.Fatal("NewRedis() should fail when redis is unreachable")
+	}
+	if elapsed := time.Since(start); elapsed > redisPingTimeout+time.Second {
+		t.Errorf("NewRedis() took %v, want a fast failure", elapsed)
+	}
+}
+
+// fakeRedis is an in-process RESP server backed by a map. It serves PING, GET, SET (with NX, EX, PX
+// and KEEPTTL), SETNX, MGET, EXISTS, DEL and PTTL, and runs the compare-and-delete and
+// increment-if-exists scripts through EVAL after answering EVALSHA with NOSCRIPT. Every other command is answered with an error.
+// Keys expire against a clock that tests can move with advance.
+type fakeRedis struct {
+	addr string
+
+	mu       sync.Mutex
+	data     map[string]string
+	expireAt map[string]time.Time
+	offset   time.Duration
+	// nxRejected counts the SET NX and SETNX commands refused because the key exists
+	nxRejected int
+}
+
+// serveFakeRedis starts a fakeRedis and returns its listen address.
+func serveFakeRedis(t testing.TB) string {
+	return newFakeRedis(t).addr
+}
+
+func newFakeRedis(t testing.TB) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	f := &fakeRedis{addr: ln.Addr().String(), data: map[string]string{}, expireAt: map[string]time.Time{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					args, err := readRESPCommand(r)
+					if err != nil {
+						return
+					}
+					if _, err := conn.Write([]byte(f.handle(args))); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return f
+}
+
+// advance moves the clock of the server forward by d.
+func (f *fakeRedis) advance(d time.Duration) {
+	f.mu.Lock()
+	f.offset += d
+	f.mu.Unlock()
+}
+
+func (f *fakeRedis) rejectedNX() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.nxRejected
+}
+
+// lookup returns the value of key, removing it if it has expired. The caller holds f.mu.
+func (f *fakeRedis) lookup(key string) (string, bool) {
+	if at, ok := f.expireAt[key]; ok && !time.Now().Add(f.offset).Before(at) {
+		delete(f.data, key)
+		delete(f.expireAt, key)
+	}
+	v, ok := f.data[key]
+	return v, ok
+}
+
+func (f *fakeRedis) del(key string) int {
+	_, ok := f.lookup(key)
+	delete(f.data, key)
+	delete(f.expireAt, key)
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+func (f *fakeRedis) bulk(key string) string {
+	v, ok := f.lookup(key)
+	if !ok {
+		return "$-1\r\n"
+	}
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+}
+
+func (f *fakeRedis) handle(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch strings.ToLower(args[0]) {
+	case "ping":
+		return "+PONG\r\n"
+	case "set":
+		var (
+			nx, keepTTL bool
+			expire      time.Duration
+		)
+		for i := 3; i < len(args); i++ {
+			switch strings.ToLower(args[i]) {
+			case "nx":
+				nx = true
+			case "keepttl":
+				keepTTL = true
+			case "ex", "px":
+				n, _ := strconv.Atoi(args[i+1])
+				expire = time.Duration(n) * time.Millisecond
+				if strings.EqualFold(args[i], "ex") {
+					expire = time.Duration(n) * time.Second
+				}
+				i++
+			}
+		}
+		if _, exist := f.lookup(args[1]); nx && exist {
+			f.nxRejected++
+			return "$-1\r\n"
+		}
+		f.data[args[1]] = args[2]
+		if expire > 0 {
+			f.expireAt[args[1]] = time.Now().Add(f.offset + expire)
+		} else if !keepTTL {
+			delete(f.expireAt, args[1])
+		}
+		return "+OK\r\n"
+	case "setnx":
+		if _, exist := f.lookup(args[1]); exist {
+			f.nxRejected++
+			return ":0\r\n"
+		}
+		f.data[args[1]] = args[2]
+		return ":1\r\n"
+	case "get":
+		return f.bulk(args[1])
+	case "mget":
+		reply := fmt.Sprintf("*%d\r\n", len(args)-1)
+		for _, key := range args[1:] {
+			reply += f.bulk(key)
+		}
+		return reply
+	case "exists":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := f.lookup(key); ok {
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "del":
+		n := 0
+		for _, key := range args[1:] {
+			n += f.del(key)
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "pttl":
+		if _, ok := f.lookup(args[1]); !ok {
+			return ":-2\r\n"
+		}
+		at, ok := f.expireAt[args[1]]
+		if !ok {
+			return ":-1\r\n"
+		}
+		return fmt.Sprintf(":%d\r\n", at.Sub(time.Now().Add(f.offset)).Milliseconds())
+	case "evalsha":
+		return "-NOSCRIPT No matching script. Please use EVAL.\r\n"
+	case "eval":
+		// EVAL script 1 key arg, running the increment-if-exists or the compare-and-delete script
+		v, ok := f.lookup(args[3])
+		if strings.Contains(args[1], "INCRBY") {
+			if !ok {
+				return "$-1\r\n"
+			}
+			n, _ := strconv.ParseInt(v, 10, 64)
+			delta, _ := strconv.ParseInt(args[4], 10, 64)
+			f.data[args[3]] = strconv.FormatInt(n+delta, 10)
+			return fmt.Sprintf(":%d\r\n", n+delta)
+		}
+		if ok && v == args[4] {
+			return fmt.Sprintf(":%d\r\n", f.del(args[3]))
+		}
+		return ":0\r\n"
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("unexpected RESP line %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected RESP line %q", line)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedisPing(t *testing.T) {
+	c, err := NewRedis(&RedisOptions{Schema: Redis, Addrs: []string{serveFakeRedis(t)}})
+	if err != nil {
+		t.Fatalf("NewRedis() error = %v", err)
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() error = %v", err)
+	}
+	if err := WithPrefix(c, "p:").Ping(context.Background()); err != nil {
+		t.Errorf("prefixed Ping() error = %v", err)
+	}
+}
+
+func TestRedisJSONValue(t *testing.T) {
+	ctx := context.Background()
+	server := serveFakeRedis(t)
+	c, err := NewRedis(&RedisOptions{Schema: Redis, Addrs: []string{server}, JSONValues: true})
+	if err != nil {
+		t.Fatalf("NewRedis() error = %v", err)
+	}
+
+	want := testProfile{Name: "alice", Tags: []string{"a", "b"}, Address: testAddress{City: "Hangzhou"}, Extra: map[string]string{"k": "v"}}
+	if err := c.Set(ctx, "profile", want, time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	var got testProfile
+	if err := c.Get(ctx, "profile", &got); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %+v, want %+v", got, want)
+	}
+
+	// primitives keep the native encoding
+	if err := c.MSet(ctx, map[string]interface{}{"count": 42, "other": &testAddress{City: "Beijing"}}, NoExpiration); err != nil {
+		t.Fatalf("MSet() error = %v", err)
+	}
+	var raw string
+	if err := c.Get(ctx, "count", &raw); err != nil || raw != "42" {
+		t.Errorf("Get() raw = %q, %v, want 42", raw, err)
+	}
+	var (
+		count int
+		addr  testAddress
+	)
+	if err := c.MGet(ctx, []string{"count", "other"}, []interface{}{&count, &addr}); err != nil {
+		t.Fatalf("MGet() error = %v", err)
+	}
+	if count != 42 || addr.City != "Beijing" {
+		t.Errorf("MGet() = %d, %+v", count, addr)
+	}
+
+	if err := c.Set(ctx, "bad", func() {}, NoExpiration); err == nil {
+		t.Error("Set() with an unsupported value should fail")
+	}
+
+	// without the option go-redis rejects structs
+	plain, err := NewRedis(&RedisOptions{Schema: Redis, Addrs: []string{server}})
+	if err != nil {
+		t.Fatalf("NewRedis() error = %v", err)
+	}
+	if err := plain.Set(ctx, "plain", want, NoExpiration); err == nil || !strings.Contains(err.Error(), "can't marshal") {
+		t.Errorf("Set() without JSON values error = %v, want can't marshal", err)
+	}
+	if err := plain.Get(ctx, "profile", &got); err == nil {
+		t.Error("Get() into a struct without JSON values should fail")
+	}
+}
+
+// TestRedisGetOrSetAcrossProcesses uses two clients with their own singleflight groups, like two
+// processes, to check that the SET NX lock lets only one of them run the loader.
+func TestRedisIncrByIfExists(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewRedis(&RedisOptions{Schema: Redis, Addrs: []string{serveFakeRedis(t)}})
+	if err != nil {
+		t.Fatalf("NewRedis() error = %v", err)
+	}
+	if n, ok, err := c.IncrByIfExists(ctx, "counter", -1); err != nil || ok || n != 0 {
+		t.Fatalf("IncrByIfExists() on missing key = %d, %v, %v, want not applied", n, ok, err)
+	}
+	if exist, _ := c.Exist(ctx, "counter"); exist {
+		t.Fatal("IncrByIfExists() should not create the key")
+	}
+	if err := c.Set(ctx, "counter", 3, time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if n, ok, err := WithPrefix(c, "").IncrByIfExists(ctx, "counter", -1); err != nil || !ok || n != 2 {
+		t.Errorf("IncrByIfExists() = %d, %v, %v, want 2", n, ok, err)
+	}
+}
+
+func TestRedisGetOrSetAcrossProcesses(t *testing.T) {
+	ctx := context.Background()
+	srv := newFakeRedis(t)
+	newClient := func() Interface {
+		c, err := NewRedis(&RedisOptions{Schema: Redis, Addrs: []string{srv.addr}})
+		if err != nil {
+			t.Fatalf("NewRedis() error = %v", err)
+		}
+		return c
+	}
+	holder, waiter := newClient(), newClient()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	holderErr := make(chan error, 1)
+	go func() {
+		var v string
+		holderErr <- holder.GetOrSet(ctx, "profile", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			return "loaded", nil
+		})
+	}()
+	<-started
+
+	waiterDone := make(chan error, 1)
+	var got string
+	go func() {
+		waiterDone <- waiter.GetOrSet(ctx, "profile", &got, time.Minute, func(ctx context.Context) (interface{}, error) {
+			return "loaded by waiter", nil
+		})
+	}()
+	// the waiter must have found the lock taken before the holder stores the value
+	for srv.rejectedNX() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if err := <-holderErr; err != nil {
+		t.Fatalf("holder GetOrSet() error = %v", err)
+	}
+	if err := <-waiterDone; err != nil {
+		t.Fatalf("waiter GetOrSet() error = %v", err)
+	}
+	if got != "loaded" {
+		t.Errorf("waiter GetOrSet() = %q, want the value loaded by the lock holder", got)
+	}
+	if exist, _ := holder.Exist(ctx, "profile:lock"); exist {
+		t.Error("lock should be released after loading")
+	}
+}
+
+// TestRedisGetOrSetLoaderError checks that a failed loader releases the lock right away,
+// so a waiting process loads the value itself instead of waiting for the lock to expire.
+func TestRedisGetOrSetLoaderError(t *testing.T) {
+	ctx := context.Background()
+	srv := newFakeRedis(t)
+	holder, err := NewRedis(&RedisOptions{Schema: Redis, Addrs: []string{srv.addr}})
+	if err != nil {
+		t.Fatalf("NewRedis() error = %v", err)
+	}
+	waiter, err := NewRedis(&RedisOptions{Schema: Redis, Addrs: []string{srv.addr}})
+	if err != nil {
+		t.Fatalf("NewRedis() error = %v", err)
+	}
+
+	loadErr := errors.New("database unavailable")
+	started, release := make(chan struct{}), make(chan struct{})
+	holderErr := make(chan error, 1)
+	go func() {
+		var v string
+		holderErr <- holder.GetOrSet(ctx, "profile", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			return nil, loadErr
+		})
+	}()
+	<-started
+
+	begin := time.Now()
+	waiterDone := make(chan error, 1)
+	var got string
+	go func() {
+		waiterDone <- waiter.GetOrSet(ctx, "profile", &got, time.Minute, func(ctx context.Context) (interface{}, error) {
+			return "loaded by waiter", nil
+		})
+	}()
+	for srv.rejectedNX() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if err := <-holderErr; !errors.Is(err, loadErr) {
+		t.Fatalf("holder GetOrSet() error = %v, want %v", err, loadErr)
+	}
+	if err := <-waiterDone; err != nil {
+		t.Fatalf("waiter GetOrSet() error = %v", err)
+	}
+	if got != "loaded by waiter" {
+		t.Errorf("waiter GetOrSet() = %q, want loaded by waiter", got)
+	}
+	if elapsed := time.Since(begin); elapsed >= getOrSetLockTTL/2 {
+		t.Errorf("waiter took %v, should not wait for the lock to expire", elapsed)
+	}
+}
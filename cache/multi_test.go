@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type binaryValue struct {
+	Name string `json:"name"`
+}
+
+func (v binaryValue) MarshalBinary() ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (v *binaryValue) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, v)
+}
+
+func TestGetMultiSetMulti(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func(t *testing.T) Interface
+	}{
+		{"memory", func(t *testing.T) Interface {
+			c, _ := NewMemory()
+			return c
+		}},
+		{"redis", func(t *testing.T) Interface {
+			c, err := NewRedis(&RedisOptions{Schema: Redis, Addrs: []string{serveFakeRedis(t)}})
+			if err != nil {
+				t.Fatalf("NewRedis() error = %v", err)
+			}
+			return c
+		}},
+		{"prefix", func(t *testing.T) Interface {
+			c, _ := NewMemory()
+			return WithPrefix(c, "app")
+		}},
+		{"tiered", func(t *testing.T) Interface {
+			local, _ := NewMemory()
+			remote, _ := NewMemory()
+			return NewTiered(local, remote, time.Minute)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := tt.new(t)
+			if err := c.SetMulti(ctx, map[string]interface{}{
+				"session": "s-1",
+				"mfa":     true,
+				"info":    binaryValue{Name: "alice"},
+				"empty":   "",
+			}, time.Minute); err != nil {
+				t.Fatalf("SetMulti() error = %v", err)
+			}
+
+			var (
+				session, empty, missing string
+				mfa                     bool
+				info                    binaryValue
+			)
+			dest := map[string]interface{}{
+				"session": &session,
+				"mfa":     &mfa,
+				"info":    &info,
+				"empty":   &empty,
+				"missing": &missing,
+			}
+			if err := c.GetMulti(ctx, []string{"session", "mfa", "info", "empty", "missing"}, dest); err != nil {
+				t.Fatalf("GetMulti() error = %v", err)
+			}
+			if session != "s-1" || !mfa || info.Name != "alice" {
+				t.Errorf("GetMulti() = %q, %v, %+v", session, mfa, info)
+			}
+			if _, ok := dest["missing"]; ok {
+				t.Error("GetMulti() should remove missing keys from dest")
+			}
+			if _, ok := dest["empty"]; !ok || len(dest) != 4 {
+				t.Errorf("GetMulti() dest = %v, want the 4 found keys", dest)
+			}
+
+			if err := c.GetMulti(ctx, []string{"session"}, map[string]interface{}{}); err != ErrScanValueIsNil {
+				t.Errorf("GetMulti() without destination error = %v, want %v", err, ErrScanValueIsNil)
+			}
+		})
+	}
+}
+
+func TestTieredGetMultiFillsLocal(t *testing.T) {
+	ctx := context.Background()
+	local, _ := NewMemory()
+	remote, _ := NewMemory()
+	c := NewTiered(local, remote, time.Minute)
+	_ = local.Set(ctx, "a", "local", time.Minute)
+	_ = remote.MSet(ctx, map[string]interface{}{"a": "remote", "b": "remote"}, time.Minute)
+
+	var a, b, missing string
+	dest := map[string]interface{}{"a": &a, "b": &b, "missing": &missing}
+	if err := c.GetMulti(ctx, []string{"a", "b", "missing"}, dest); err != nil {
+		t.Fatalf("GetMulti() error = %v", err)
+	}
+	if a != "local" || b != "remote" || len(dest) != 2 {
+		t.Errorf("GetMulti() = %q, %q, dest %v", a, b, dest)
+	}
+	var filled string
+	if err := local.Get(ctx, "b", &filled); err != nil || filled != "remote" {
+		t.Errorf("local Get(b) = %q, %v, want the remote value", filled, err)
+	}
+	if exist, _ := local.Exist(ctx, "missing"); exist {
+		t.Error("GetMulti() should not store missing keys locally")
+	}
+}
+
+// BenchmarkGetMulti compares n sequential Gets with one GetMulti against a redis server over TCP,
+// where GetMulti saves n-1 round trips.
+func BenchmarkGetMulti(b *testing.B) {
+	const n = 16
+	ctx := context.Background()
+	c, err := NewRedis(&RedisOptions{Schema: Redis, Addrs: []string{serveFakeRedis(b)}})
+	if err != nil {
+		b.Fatal(err)
+	}
+	keys := make([]string, n)
+	items := make(map[string]interface{}, n)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+		items[keys[i]] = binaryValue{Name: keys[i]}
+	}
+	if err := c.SetMulti(ctx, items, NoExpiration); err != nil {
+		b.Fatal(err)
+	}
+	values := make([]binaryValue, n)
+
+	b.Run("Get", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j, key := range keys {
+				if err := c.Get(ctx, key, &values[j]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("GetMulti", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dest := make(map[string]interface{}, n)
+			for j, key := range keys {
+				dest[key] = &values[j]
+			}
+			if err := c.GetMulti(ctx, keys, dest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -1,6 +1,12 @@
 package cache
 
-import "fmt"
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/x893675/valhalla-common/utils/cert"
+)
 
 type Options struct {
 	Type  string        `json:"type" yaml:"type" toml:"type"`
@@ -25,6 +31,108 @@ type RedisOptions struct {
 	MasterName       string `json:"masterName" yaml:"masterName" toml:"masterName"`
 	SentinelUsername string `json:"sentinelUsername" yaml:"sentinelUsername" toml:"sentinelUsername"`
 	SentinelPassword string `json:"sentinelPassword" yaml:"sentinelPassword" toml:"sentinelPassword"`
+
+	// TLSEnabled connects to redis over TLS, which most managed cloud redis services require.
+	TLSEnabled bool `json:"tlsEnabled" yaml:"tlsEnabled" toml:"tlsEnabled"`
+	// TLSInsecureSkipVerify skips verification of the server certificate. Only use it for testing.
+	TLSInsecureSkipVerify bool `json:"tlsInsecureSkipVerify" yaml:"tlsInsecureSkipVerify" toml:"tlsInsecureSkipVerify"`
+	// CAFile is a PEM bundle used to verify the server certificate instead of the system roots.
+	CAFile string `json:"caFile" yaml:"caFile" toml:"caFile"`
+	// CertFile and KeyFile are the PEM client certificate and key for mutual TLS.
+	CertFile string `json:"certFile" yaml:"certFile" toml:"certFile"`
+	KeyFile  string `json:"keyFile" yaml:"keyFile" toml:"keyFile"`
+
+	// PoolSize and MinIdleConns tune the connection pool. Zero uses the go-redis defaults.
+	PoolSize     int `json:"poolSize" yaml:"poolSize" toml:"poolSize"`
+	MinIdleConns int `json:"minIdleConns" yaml:"minIdleConns" toml:"minIdleConns"`
+
+	// DialTimeout, ReadTimeout and WriteTimeout are durations such as "5s". Empty uses the go-redis defaults.
+	DialTimeout  string `json:"dialTimeout" yaml:"dialTimeout" toml:"dialTimeout"`
+	ReadTimeout  string `json:"readTimeout" yaml:"readTimeout" toml:"readTimeout"`
+	WriteTimeout string `json:"writeTimeout" yaml:"writeTimeout" toml:"writeTimeout"`
+
+	// JSONValues stores values go-redis can't encode, such as structs that don't implement
+	// encoding.BinaryMarshaler, as JSON and decodes them on Get. Without it such values are rejected.
+	JSONValues bool `json:"jsonValues" yaml:"jsonValues" toml:"jsonValues"`
+}
+
+// redisTuning holds the parsed TLS, pool and timeout settings shared by all redis schemas.
+type redisTuning struct {
+	tls          *tls.Config
+	poolSize     int
+	minIdleConns int
+	dialTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+func (o *RedisOptions) tuning() (redisTuning, error) {
+	t := redisTuning{
+		poolSize:     o.PoolSize,
+		minIdleConns: o.MinIdleConns,
+	}
+	if o.TLSEnabled || o.CAFile != "" || o.CertFile != "" {
+		var err error
+		if t.tls, err = o.tlsConfig(); err != nil {
+			return t, err
+		}
+	}
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{name: "dialTimeout", value: o.DialTimeout, dst: &t.dialTimeout},
+		{name: "readTimeout", value: o.ReadTimeout, dst: &t.readTimeout},
+		{name: "writeTimeout", value: o.WriteTimeout, dst: &t.writeTimeout},
+	} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return t, fmt.Errorf("invalid redis %s %q: %w", d.name, d.value, err)
+		}
+		*d.dst = v
+	}
+	return t, nil
+}
+
+// tlsConfig builds the TLS config. Setting CAFile or CertFile enables TLS even if TLSEnabled is false.
+func (o *RedisOptions) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.TLSInsecureSkipVerify,
+	}
+	if o.CAFile != "" {
+		pool, err := cert.NewCertPoolFromFiles(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load redis CA file: %w", err)
+		}
+		cfg.RootCAs = pool
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, fmt.Errorf("redis certFile and keyFile must be set together")
+		}
+		certs, err := cert.ReadCertsFromFile(o.CertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load redis client certificate: %w", err)
+		}
+		key, err := cert.ReadPrivateKeyFromFile(o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load redis client key: %w", err)
+		}
+		if err := cert.VerifyCertKeyMatch(certs[0], key); err != nil {
+			return nil, fmt.Errorf("redis client certificate: %w", err)
+		}
+		clientCert := tls.Certificate{PrivateKey: key, Leaf: certs[0]}
+		for _, c := range certs {
+			clientCert.Certificate = append(clientCert.Certificate, c.Raw)
+		}
+		cfg.Certificates = []tls.Certificate{clientCert}
+	}
+	return cfg, nil
 }
 
 func DefaultOptions() *Options {
@@ -33,7 +141,11 @@ func DefaultOptions() *Options {
 	}
 }
 
+// New creates a cache from opts. A nil opts uses DefaultOptions.
 func New(opts *Options) (Interface, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
 	switch opts.Type {
 	case "mem":
 		return NewMemory()
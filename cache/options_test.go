@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewWithNilOptions(t *testing.T) {
+	c, err := New(nil)
+	if err != nil {
+		t.Fatalf("New(nil) error = %v", err)
+	}
+	if _, ok := c.(*memoryKV); !ok {
+		t.Errorf("New(nil) = %T, want the default memory cache", c)
+	}
+	if err := c.Set(context.Background(), "key", "v", time.Minute); err != nil {
+		t.Errorf("Set() error = %v", err)
+	}
+
+	if _, err := New(&Options{Type: Redis}); err == nil {
+		t.Error("New() with redis type and nil redis options should fail")
+	}
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// prefixKV isolates a keyspace by prepending a fixed prefix to every key.
+type prefixKV struct {
+	inner  Interface
+	prefix string
+}
+
+var _ Interface = (*prefixKV)(nil)
+
+// WithPrefix returns an Interface that transparently prepends prefix to every key and pattern,
+// so several components can share one backend without key collisions.
+// Keys written through one prefix are not visible through another.
+//
+// Keys that already start with prefix are passed through unchanged, so callers may keep
+// building keys from format strings that include the prefix. Wrappers stack: the outer
+// prefix is applied first, so WithPrefix(WithPrefix(c, "svc:"), "mfa:") stores "x" as "svc:mfa:x".
+// An empty prefix returns inner as is.
+func WithPrefix(inner Interface, prefix string) Interface {
+	if prefix == "" {
+		return inner
+	}
+	return &prefixKV{inner: inner, prefix: prefix}
+}
+
+func (p *prefixKV) key(key string) string {
+	if strings.HasPrefix(key, p.prefix) {
+		return key
+	}
+	return p.prefix + key
+}
+
+func (p *prefixKV) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	return p.inner.Set(ctx, p.key(key), value, expire)
+}
+
+func (p *prefixKV) SetNX(ctx context.Context, key string, value interface{}, expire time.Duration) (bool, error) {
+	return p.inner.SetNX(ctx, p.key(key), value, expire)
+}
+
+func (p *prefixKV) Update(ctx context.Context, key string, value interface{}) error {
+	return p.inner.Update(ctx, p.key(key), value)
+}
+
+func (p *prefixKV) Get(ctx context.Context, key string, value interface{}) error {
+	return p.inner.Get(ctx, p.key(key), value)
+}
+
+func (p *prefixKV) IncrByIfExists(ctx context.Context, key string, delta int64) (int64, bool, error) {
+	return p.inner.IncrByIfExists(ctx, p.key(key), delta)
+}
+
+func (p *prefixKV) Ping(ctx context.Context) error {
+	return p.inner.Ping(ctx)
+}
+
+func (p *prefixKV) Exist(ctx context.Context, key string) (bool, error) {
+	return p.inner.Exist(ctx, p.key(key))
+}
+
+func (p *prefixKV) MGet(ctx context.Context, keys []string, dest []interface{}) error {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = p.key(key)
+	}
+	return p.inner.MGet(ctx, prefixed, dest)
+}
+
+func (p *prefixKV) MSet(ctx context.Context, pairs map[string]interface{}, expire time.Duration) error {
+	prefixed := make(map[string]interface{}, len(pairs))
+	for key, value := range pairs {
+		prefixed[p.key(key)] = value
+	}
+	return p.inner.MSet(ctx, prefixed, expire)
+}
+
+func (p *prefixKV) GetMulti(ctx context.Context, keys []string, dest map[string]interface{}) error {
+	if err := checkGetMultiDest(keys, dest); err != nil {
+		return err
+	}
+	prefixed := make([]string, len(keys))
+	prefixedDest := make(map[string]interface{}, len(keys))
+	for i, key := range keys {
+		prefixed[i] = p.key(key)
+		prefixedDest[prefixed[i]] = dest[key]
+	}
+	if err := p.inner.GetMulti(ctx, prefixed, prefixedDest); err != nil {
+		return err
+	}
+	for i, key := range keys {
+		if _, ok := prefixedDest[prefixed[i]]; !ok {
+			delete(dest, key)
+		}
+	}
+	return nil
+}
+
+func (p *prefixKV) SetMulti(ctx context.Context, items map[string]interface{}, expire time.Duration) error {
+	return p.MSet(ctx, items, expire)
+}
+
+func (p *prefixKV) Remove(ctx context.Context, key string) error {
+	return p.inner.Remove(ctx, p.key(key))
+}
+
+func (p *prefixKV) CompareAndDelete(ctx context.Context, key string, expected interface{}) (bool, error) {
+	return p.inner.CompareAndDelete(ctx, p.key(key), expected)
+}
+
+// RemoveWithPattern removes the keys matching pattern within the prefix only.
+func (p *prefixKV) RemoveWithPattern(ctx context.Context, pattern string) error {
+	return p.inner.RemoveWithPattern(ctx, p.key(pattern))
+}
+
+// RemoveWithPatternCount removes the keys matching pattern within the prefix only.
+func (p *prefixKV) RemoveWithPatternCount(ctx context.Context, pattern string) (int, error) {
+	return p.inner.RemoveWithPatternCount(ctx, p.key(pattern))
+}
+
+func (p *prefixKV) Expire(ctx context.Context, key string, expire time.Duration) error {
+	return p.inner.Expire(ctx, p.key(key), expire)
+}
+
+func (p *prefixKV) GetOrSet(ctx context.Context, key string, dest interface{}, expire time.Duration, loader Loader) error {
+	return p.inner.GetOrSet(ctx, p.key(key), dest, expire, loader)
+}
+
+func (p *prefixKV) Incr(ctx context.Context, key string) (int64, error) {
+	return p.inner.Incr(ctx, p.key(key))
+}
+
+func (p *prefixKV) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	return p.inner.IncrBy(ctx, p.key(key), delta)
+}
+
+func (p *prefixKV) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return p.inner.TTL(ctx, p.key(key))
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithPrefix(t *testing.T) {
+	ctx := context.Background()
+	shared, _ := NewMemory()
+	token := WithPrefix(shared, "token:")
+	mfa := WithPrefix(shared, "mfa:")
+
+	if err := token.Set(ctx, "user:1", "token-value", time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := mfa.Set(ctx, "user:1", "mfa-value", NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	var got string
+	if err := token.Get(ctx, "user:1", &got); err != nil || got != "token-value" {
+		t.Errorf("token Get() = %q, %v, want token-value", got, err)
+	}
+	if err := mfa.Get(ctx, "user:1", &got); err != nil || got != "mfa-value" {
+		t.Errorf("mfa Get() = %q, %v, want mfa-value", got, err)
+	}
+	if err := shared.Get(ctx, "token:user:1", &got); err != nil || got != "token-value" {
+		t.Errorf("shared Get() = %q, %v, want token-value", got, err)
+	}
+	if exist, _ := shared.Exist(ctx, "user:1"); exist {
+		t.Error("unprefixed key should not exist in the shared cache")
+	}
+
+	if err := mfa.Update(ctx, "user:1", "updated"); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := token.Get(ctx, "user:1", &got); err != nil || got != "token-value" {
+		t.Errorf("Update() through one prefix changed another: %q, %v", got, err)
+	}
+
+	if ttl, err := token.TTL(ctx, "user:1"); err != nil || ttl <= 0 {
+		t.Errorf("TTL() = %v, %v, want positive", ttl, err)
+	}
+	if n, err := mfa.Incr(ctx, "attempts"); err != nil || n != 1 {
+		t.Errorf("Incr() = %d, %v, want 1", n, err)
+	}
+	if exist, _ := token.Exist(ctx, "attempts"); exist {
+		t.Error("counter under mfa prefix should not be visible through token prefix")
+	}
+
+	if err := mfa.RemoveWithPattern(ctx, "*"); err != nil {
+		t.Fatalf("RemoveWithPattern() error = %v", err)
+	}
+	if exist, _ := mfa.Exist(ctx, "user:1"); exist {
+		t.Error("RemoveWithPattern() should remove keys under its prefix")
+	}
+	if exist, _ := token.Exist(ctx, "user:1"); !exist {
+		t.Error("RemoveWithPattern() should not remove keys under another prefix")
+	}
+
+	if err := token.Remove(ctx, "user:1"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if exist, _ := shared.Exist(ctx, "token:user:1"); exist {
+		t.Error("Remove() should remove the prefixed key")
+	}
+}
+
+func TestWithPrefixRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	shared, _ := NewMemory()
+	a := NewRateLimiter(WithPrefix(shared, "a:"))
+	b := NewRateLimiter(WithPrefix(shared, "b:"))
+
+	if ok, err := a.Allow(ctx, "send", time.Minute); err != nil || !ok {
+		t.Fatalf("a.Allow() = %v, %v, want true", ok, err)
+	}
+	if ok, err := a.Allow(ctx, "send", time.Minute); err != nil || ok {
+		t.Errorf("second a.Allow() = %v, %v, want false", ok, err)
+	}
+	if ok, err := b.Allow(ctx, "send", time.Minute); err != nil || !ok {
+		t.Errorf("b.Allow() = %v, %v, want true", ok, err)
+	}
+}
+
+func TestWithPrefixRemoveWithPatternCount(t *testing.T) {
+	ctx := context.Background()
+	shared, _ := NewMemory()
+	a := WithPrefix(shared, "a:")
+	b := WithPrefix(shared, "b:")
+	for _, key := range []string{"x:1", "x:2"} {
+		_ = a.Set(ctx, key, "v", NoExpiration)
+		_ = b.Set(ctx, key, "v", NoExpiration)
+	}
+
+	if n, err := a.RemoveWithPatternCount(ctx, "x:*"); err != nil || n != 2 {
+		t.Errorf("RemoveWithPatternCount() = %d, %v, want 2", n, err)
+	}
+	if exist, _ := b.Exist(ctx, "x:1"); !exist {
+		t.Error("keys under another prefix should not be removed")
+	}
+}
+
+func TestWithPrefixNamespacing(t *testing.T) {
+	ctx := context.Background()
+	shared, _ := NewMemory()
+	svc := WithPrefix(shared, "svc:")
+
+	// a key that already carries the prefix is not prefixed twice
+	if err := svc.Set(ctx, "svc:token:1", "a", NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	var got string
+	if err := svc.Get(ctx, "token:1", &got); err != nil || got != "a" {
+		t.Errorf("Get() = %q, %v, want a", got, err)
+	}
+	if exist, _ := shared.Exist(ctx, "svc:svc:token:1"); exist {
+		t.Error("key should not be double-prefixed")
+	}
+
+	// stacked wrappers apply the outer prefix first
+	mfa := WithPrefix(svc, "mfa:")
+	if err := mfa.Set(ctx, "code:1", "b", NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := shared.Get(ctx, "svc:mfa:code:1", &got); err != nil || got != "b" {
+		t.Errorf("shared Get() = %q, %v, want b", got, err)
+	}
+	if err := svc.Get(ctx, "mfa:code:1", &got); err != nil || got != "b" {
+		t.Errorf("svc Get() = %q, %v, want b", got, err)
+	}
+
+	// patterns are prefixed the same way as keys
+	if n, err := mfa.RemoveWithPatternCount(ctx, "mfa:*"); err != nil || n != 1 {
+		t.Errorf("RemoveWithPatternCount() = %d, %v, want 1", n, err)
+	}
+	if exist, _ := svc.Exist(ctx, "token:1"); !exist {
+		t.Error("RemoveWithPatternCount() should only remove keys under the mfa prefix")
+	}
+
+	if WithPrefix(shared, "") != shared {
+		t.Error("WithPrefix() with an empty prefix should return the inner cache")
+	}
+}
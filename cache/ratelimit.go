@@ -0,0 +1,23 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimiter allows at most one event per key within an interval.
+// It is built on SetNX, so on Redis the limit is shared by all processes using the same Redis.
+type RateLimiter struct {
+	cache Interface
+}
+
+// NewRateLimiter creates a RateLimiter backed by the given cache.
+func NewRateLimiter(c Interface) *RateLimiter {
+	return &RateLimiter{cache: c}
+}
+
+// Allow reports whether an event for key is allowed. The first call for a key is allowed,
+// and later calls are denied until interval has passed since the allowed call.
+func (r *RateLimiter) Allow(ctx context.Context, key string, interval time.Duration) (bool, error) {
+	return r.cache.SetNX(ctx, key, "", interval)
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	mem := &memoryKV{storage: &sync.Map{}, Now: func() time.Time { return now }}
+
+	for name, c := range map[string]Interface{"memory": mem, "prefix": WithPrefix(mem, "p:")} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			limiter := NewRateLimiter(c)
+			key := "rate-limit:" + name
+
+			if ok, err := limiter.Allow(ctx, key, time.Minute); err != nil || !ok {
+				t.Fatalf("first Allow() = %v, %v, want true", ok, err)
+			}
+			if ok, err := limiter.Allow(ctx, key, time.Minute); err != nil || ok {
+				t.Errorf("second Allow() = %v, %v, want false", ok, err)
+			}
+			if ok, _ := limiter.Allow(ctx, key+":other", time.Minute); !ok {
+				t.Error("Allow() for another key should be allowed")
+			}
+
+			now = now.Add(2 * time.Minute)
+			if ok, err := limiter.Allow(ctx, key, time.Minute); err != nil || !ok {
+				t.Errorf("Allow() after interval = %v, %v, want true", ok, err)
+			}
+		})
+	}
+}
+
+func TestRateLimiterConcurrent(t *testing.T) {
+	c, _ := NewMemory()
+	limiter := NewRateLimiter(c)
+
+	var allowed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := limiter.Allow(context.Background(), "rate-limit:concurrent", time.Minute); ok {
+				atomic.AddInt32(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 1 {
+		t.Errorf("allowed = %d, want 1", allowed)
+	}
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Loader computes the value of a missing key for GetOrSet.
+type Loader func(ctx context.Context) (interface{}, error)
+
+// flightGroup deduplicates concurrent calls for the same key within the process.
+// The zero value is ready to use.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	wg  sync.WaitGroup
+	err error
+}
+
+// do runs fn once for all callers that arrive while a call for key is in flight,
+// and returns its error to each of them.
+func (g *flightGroup) do(key string, fn func() error) error {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.err
+	}
+	c := &flightCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.err = fn()
+	c.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	return c.err
+}
+
+// getOrSet implements GetOrSet on top of the basic operations of c.
+// fill is called at most once per key at a time within the process and stores the loaded value.
+func getOrSet(ctx context.Context, c Interface, g *flightGroup, key string, dest interface{}, fill func() error) error {
+	if dest == nil {
+		return ErrScanValueIsNil
+	}
+	err := c.Get(ctx, key, dest)
+	if !IsNotExists(err) {
+		return err
+	}
+	if err := g.do(key, fill); err != nil {
+		return err
+	}
+	return c.Get(ctx, key, dest)
+}
+
+// loadAndSet stores the value returned by loader unless key has been set in the meantime.
+func loadAndSet(ctx context.Context, c Interface, key string, expire time.Duration, loader Loader) error {
+	exist, err := c.Exist(ctx, key)
+	if err != nil || exist {
+		return err
+	}
+	value, err := loader(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, key, value, expire)
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrSet(t *testing.T) {
+	shared, _ := NewMemoryWithJSONValues(0)
+	for name, c := range map[string]Interface{"memory": shared, "prefix": WithPrefix(shared, "p:")} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			var calls int32
+			loader := func(ctx context.Context) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(20 * time.Millisecond)
+				return testProfile{Name: "alice", Tags: []string{"admin"}}, nil
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 100; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var got testProfile
+					if err := c.GetOrSet(ctx, "profile", &got, time.Minute, loader); err != nil {
+						t.Errorf("GetOrSet() error = %v", err)
+						return
+					}
+					if got.Name != "alice" || len(got.Tags) != 1 {
+						t.Errorf("GetOrSet() dest = %+v", got)
+					}
+				}()
+			}
+			wg.Wait()
+			if calls != 1 {
+				t.Errorf("loader called %d times, want 1", calls)
+			}
+
+			// the loaded value is stored with the expiration
+			if ttl, err := c.TTL(ctx, "profile"); err != nil || ttl <= 0 || ttl > time.Minute {
+				t.Errorf("TTL() = %v, %v, want (0, 1m]", ttl, err)
+			}
+			var got testProfile
+			if err := c.GetOrSet(ctx, "profile", &got, time.Minute, loader); err != nil || calls != 1 {
+				t.Errorf("GetOrSet() on existing key = %v, loader calls = %d", err, calls)
+			}
+		})
+	}
+}
+
+func TestGetOrSetLoaderError(t *testing.T) {
+	ctx := context.Background()
+	c, _ := NewMemory()
+	errLoad := errors.New("load failed")
+
+	var n int
+	if err := c.GetOrSet(ctx, "key", &n, NoExpiration, func(ctx context.Context) (interface{}, error) {
+		return nil, errLoad
+	}); !errors.Is(err, errLoad) {
+		t.Errorf("GetOrSet() error = %v, want %v", err, errLoad)
+	}
+	if exist, _ := c.Exist(ctx, "key"); exist {
+		t.Error("failed load should not be cached")
+	}
+
+	if err := c.GetOrSet(ctx, "key", &n, NoExpiration, func(ctx context.Context) (interface{}, error) {
+		return 42, nil
+	}); err != nil || n != 42 {
+		t.Errorf("GetOrSet() = %d, %v, want 42", n, err)
+	}
+	if err := c.GetOrSet(ctx, "key", nil, NoExpiration, nil); !errors.Is(err, ErrScanValueIsNil) {
+		t.Errorf("GetOrSet() with nil dest error = %v, want ErrScanValueIsNil", err)
+	}
+}
@@ -0,0 +1,226 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// tieredKV serves reads from a local cache in front of a shared remote cache.
+type tieredKV struct {
+	local    Interface
+	remote   Interface
+	localTTL time.Duration
+}
+
+var _ Interface = (*tieredKV)(nil)
+
+// NewTiered returns an Interface that reads from local first and falls back to remote,
+// storing the values read from remote in local for at most localTTL.
+//
+// Writes go to remote first and then update or invalidate local. Other processes sharing remote
+// don't invalidate this local layer, so a value changed elsewhere can be served stale for up to
+// localTTL; choose localTTL according to how much staleness the cached data can tolerate.
+// Remote is authoritative for TTL, counters, locks and MGet of keys missing locally.
+// A localTTL of zero or less disables the local layer and returns remote.
+func NewTiered(local, remote Interface, localTTL time.Duration) Interface {
+	if localTTL <= 0 {
+		return remote
+	}
+	return &tieredKV{local: local, remote: remote, localTTL: localTTL}
+}
+
+// localExpire bounds the local expiration by localTTL.
+func (t *tieredKV) localExpire(expire time.Duration) time.Duration {
+	if expire <= NoExpiration || expire > t.localTTL {
+		return t.localTTL
+	}
+	return expire
+}
+
+// fill stores the value scanned into dest in the local layer, errors are ignored
+// since the local layer is only an optimization.
+func (t *tieredKV) fill(ctx context.Context, key string, dest interface{}) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return
+	}
+	_ = t.local.Set(ctx, key, v.Elem().Interface(), t.localTTL)
+}
+
+func (t *tieredKV) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	if err := t.remote.Set(ctx, key, value, expire); err != nil {
+		return err
+	}
+	if err := t.local.Set(ctx, key, value, t.localExpire(expire)); err != nil {
+		_ = t.local.Remove(ctx, key)
+	}
+	return nil
+}
+
+func (t *tieredKV) SetNX(ctx context.Context, key string, value interface{}, expire time.Duration) (bool, error) {
+	ok, err := t.remote.SetNX(ctx, key, value, expire)
+	if ok {
+		_ = t.local.Remove(ctx, key)
+	}
+	return ok, err
+}
+
+func (t *tieredKV) Update(ctx context.Context, key string, value interface{}) error {
+	err := t.remote.Update(ctx, key, value)
+	_ = t.local.Remove(ctx, key)
+	return err
+}
+
+func (t *tieredKV) Get(ctx context.Context, key string, value interface{}) error {
+	if err := t.local.Get(ctx, key, value); err == nil {
+		return nil
+	}
+	if err := t.remote.Get(ctx, key, value); err != nil {
+		return err
+	}
+	t.fill(ctx, key, value)
+	return nil
+}
+
+func (t *tieredKV) Exist(ctx context.Context, key string) (bool, error) {
+	if exist, err := t.local.Exist(ctx, key); err == nil && exist {
+		return true, nil
+	}
+	return t.remote.Exist(ctx, key)
+}
+
+// MGet serves the keys found locally and fetches the others from remote in one call,
+// without storing them locally since MGet can't tell missing keys from zero values.
+func (t *tieredKV) MGet(ctx context.Context, keys []string, dest []interface{}) error {
+	if err := checkMGetDest(keys, dest); err != nil {
+		return err
+	}
+	var (
+		missKeys []string
+		missDest []interface{}
+	)
+	for i, key := range keys {
+		if err := t.local.Get(ctx, key, dest[i]); err != nil {
+			missKeys = append(missKeys, key)
+			missDest = append(missDest, dest[i])
+		}
+	}
+	if len(missKeys) == 0 {
+		return nil
+	}
+	return t.remote.MGet(ctx, missKeys, missDest)
+}
+
+func (t *tieredKV) MSet(ctx context.Context, pairs map[string]interface{}, expire time.Duration) error {
+	if err := t.remote.MSet(ctx, pairs, expire); err != nil {
+		return err
+	}
+	if err := t.local.MSet(ctx, pairs, t.localExpire(expire)); err != nil {
+		for key := range pairs {
+			_ = t.local.Remove(ctx, key)
+		}
+	}
+	return nil
+}
+
+// GetMulti serves the keys found locally and fetches the others from remote in one call,
+// storing the values found remotely in local like Get.
+func (t *tieredKV) GetMulti(ctx context.Context, keys []string, dest map[string]interface{}) error {
+	if err := checkGetMultiDest(keys, dest); err != nil {
+		return err
+	}
+	var missKeys []string
+	missDest := make(map[string]interface{})
+	for _, key := range keys {
+		if err := t.local.Get(ctx, key, dest[key]); err != nil {
+			missKeys = append(missKeys, key)
+			missDest[key] = dest[key]
+		}
+	}
+	if len(missKeys) == 0 {
+		return nil
+	}
+	if err := t.remote.GetMulti(ctx, missKeys, missDest); err != nil {
+		return err
+	}
+	for _, key := range missKeys {
+		if value, ok := missDest[key]; ok {
+			t.fill(ctx, key, value)
+		} else {
+			delete(dest, key)
+		}
+	}
+	return nil
+}
+
+func (t *tieredKV) SetMulti(ctx context.Context, items map[string]interface{}, expire time.Duration) error {
+	return t.MSet(ctx, items, expire)
+}
+
+func (t *tieredKV) Remove(ctx context.Context, key string) error {
+	err := t.remote.Remove(ctx, key)
+	_ = t.local.Remove(ctx, key)
+	return err
+}
+
+func (t *tieredKV) CompareAndDelete(ctx context.Context, key string, expected interface{}) (bool, error) {
+	ok, err := t.remote.CompareAndDelete(ctx, key, expected)
+	_ = t.local.Remove(ctx, key)
+	return ok, err
+}
+
+// RemoveWithPattern clears the whole local layer, since backends may not agree on pattern syntax.
+func (t *tieredKV) RemoveWithPattern(ctx context.Context, pattern string) error {
+	_, err := t.RemoveWithPatternCount(ctx, pattern)
+	return err
+}
+
+// RemoveWithPatternCount returns the number of keys removed from remote and clears the whole local layer.
+func (t *tieredKV) RemoveWithPatternCount(ctx context.Context, pattern string) (int, error) {
+	n, err := t.remote.RemoveWithPatternCount(ctx, pattern)
+	_ = t.local.RemoveWithPattern(ctx, "*")
+	return n, err
+}
+
+func (t *tieredKV) Expire(ctx context.Context, key string, expire time.Duration) error {
+	err := t.remote.Expire(ctx, key, expire)
+	_ = t.local.Remove(ctx, key)
+	return err
+}
+
+func (t *tieredKV) Incr(ctx context.Context, key string) (int64, error) {
+	return t.IncrBy(ctx, key, 1)
+}
+
+func (t *tieredKV) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	n, err := t.remote.IncrBy(ctx, key, delta)
+	_ = t.local.Remove(ctx, key)
+	return n, err
+}
+
+func (t *tieredKV) IncrByIfExists(ctx context.Context, key string, delta int64) (int64, bool, error) {
+	n, ok, err := t.remote.IncrByIfExists(ctx, key, delta)
+	_ = t.local.Remove(ctx, key)
+	return n, ok, err
+}
+
+func (t *tieredKV) GetOrSet(ctx context.Context, key string, dest interface{}, expire time.Duration, loader Loader) error {
+	if err := t.local.Get(ctx, key, dest); err == nil {
+		return nil
+	}
+	if err := t.remote.GetOrSet(ctx, key, dest, expire, loader); err != nil {
+		return err
+	}
+	t.fill(ctx, key, dest)
+	return nil
+}
+
+func (t *tieredKV) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return t.remote.TTL(ctx, key)
+}
+
+func (t *tieredKV) Ping(ctx context.Context) error {
+	return errors.Join(t.local.Ping(ctx), t.remote.Ping(ctx))
+}
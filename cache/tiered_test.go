@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingKV counts the reads that reach the wrapped cache.
+type countingKV struct {
+	Interface
+	gets atomic.Int64
+}
+
+func (c *countingKV) Get(ctx context.Context, key string, value interface{}) error {
+	c.gets.Add(1)
+	return c.Interface.Get(ctx, key, value)
+}
+
+func newTestTiered(localTTL time.Duration) (Interface, *countingKV, *atomic.Int64) {
+	var clock atomic.Int64
+	clock.Store(time.Now().UnixNano())
+	now := func() time.Time { return time.Unix(0, clock.Load()) }
+	remote := &countingKV{Interface: newMemory(now, 0)}
+	return NewTiered(newMemory(now, 0), remote, localTTL), remote, &clock
+}
+
+func TestTiered(t *testing.T) {
+	ctx := context.Background()
+	c, remote, _ := newTestTiered(time.Minute)
+
+	if err := remote.Set(ctx, "user:1", "alice", NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	var got string
+	for i := 0; i < 3; i++ {
+		if err := c.Get(ctx, "user:1", &got); err != nil || got != "alice" {
+			t.Fatalf("Get() = %q, %v, want alice", got, err)
+		}
+	}
+	if n := remote.gets.Load(); n != 1 {
+		t.Errorf("remote reads = %d, want 1", n)
+	}
+
+	// writes go through to both layers
+	if err := c.Set(ctx, "user:1", "bob", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := remote.Interface.Get(ctx, "user:1", &got); err != nil || got != "bob" {
+		t.Errorf("remote Get() = %q, %v, want bob", got, err)
+	}
+	if err := c.Get(ctx, "user:1", &got); err != nil || got != "bob" {
+		t.Errorf("Get() = %q, %v, want bob", got, err)
+	}
+
+	if err := c.Remove(ctx, "user:1"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := c.Get(ctx, "user:1", &got); !IsNotExists(err) {
+		t.Errorf("Get() after Remove() error = %v, want ErrNotExists", err)
+	}
+
+	if n, err := c.Incr(ctx, "counter"); err != nil || n != 1 {
+		t.Errorf("Incr() = %d, %v, want 1", n, err)
+	}
+	var count int
+	if err := c.Get(ctx, "counter", &count); err != nil || count != 1 {
+		t.Errorf("Get() counter = %d, %v, want 1", count, err)
+	}
+	if n, _ := c.Incr(ctx, "counter"); n != 2 {
+		t.Errorf("Incr() = %d, want 2", n)
+	}
+	if err := c.Get(ctx, "counter", &count); err != nil || count != 2 {
+		t.Errorf("Get() after Incr() = %d, %v, want 2", count, err)
+	}
+
+	_ = c.MSet(ctx, map[string]interface{}{"a": "1", "b": "2"}, NoExpiration)
+	_ = remote.Set(ctx, "c", "3", NoExpiration)
+	var a, b, cc, missing string
+	if err := c.MGet(ctx, []string{"a", "b", "c", "missing"}, []interface{}{&a, &b, &cc, &missing}); err != nil {
+		t.Fatalf("MGet() error = %v", err)
+	}
+	if a != "1" || b != "2" || cc != "3" || missing != "" {
+		t.Errorf("MGet() = %q %q %q %q", a, b, cc, missing)
+	}
+
+	if n, err := c.RemoveWithPatternCount(ctx, "*"); err != nil || n != 4 {
+		t.Errorf("RemoveWithPatternCount() = %d, %v, want 4", n, err)
+	}
+	if exist, _ := c.Exist(ctx, "a"); exist {
+		t.Error("RemoveWithPatternCount() should clear the local layer")
+	}
+
+	loads := 0
+	loader := func(ctx context.Context) (interface{}, error) {
+		loads++
+		return "loaded", nil
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.GetOrSet(ctx, "lazy", &got, time.Hour, loader); err != nil || got != "loaded" {
+			t.Fatalf("GetOrSet() = %q, %v", got, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loader calls = %d, want 1", loads)
+	}
+
+	if err := c.Ping(ctx); err != nil {
+		t.Errorf("Ping() error = %v", err)
+	}
+	if NewTiered(newMemory(time.Now, 0), remote, 0) != Interface(remote) {
+		t.Error("NewTiered() with localTTL 0 should return remote")
+	}
+}
+
+func TestTieredStaleness(t *testing.T) {
+	ctx := context.Background()
+	c, remote, clock := newTestTiered(10 * time.Second)
+
+	if err := c.Set(ctx, "policy", "v1", NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	// another process updates remote directly
+	if err := remote.Set(ctx, "policy", "v2", NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	var got string
+	clock.Add(int64(9 * time.Second))
+	if err := c.Get(ctx, "policy", &got); err != nil || got != "v1" {
+		t.Errorf("Get() within localTTL = %q, %v, want the local v1", got, err)
+	}
+	clock.Add(int64(2 * time.Second))
+	if err := c.Get(ctx, "policy", &got); err != nil || got != "v2" {
+		t.Errorf("Get() after localTTL = %q, %v, want v2", got, err)
+	}
+
+	// a remote expiration shorter than localTTL also bounds the local copy
+	if err := c.Set(ctx, "code", "123456", 3*time.Second); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	clock.Add(int64(4 * time.Second))
+	if err := c.Get(ctx, "code", &got); !IsNotExists(err) {
+		t.Errorf("Get() after expiration error = %v, want ErrNotExists", err)
+	}
+}
+
+func BenchmarkTieredGet(b *testing.B) {
+	ctx := context.Background()
+	for _, bc := range []struct {
+		name     string
+		localTTL time.Duration
+	}{
+		{name: "remote-only", localTTL: 0},
+		{name: "tiered", localTTL: time.Minute},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			remote := &countingKV{Interface: newMemory(time.Now, 0)}
+			c := NewTiered(newMemory(time.Now, 0), remote, bc.localTTL)
+			_ = remote.Set(ctx, "hot", "value", NoExpiration)
+			var got string
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = c.Get(ctx, "hot", &got)
+			}
+			b.ReportMetric(float64(remote.gets.Load())/float64(b.N), "remote-gets/op")
+		})
+	}
+}
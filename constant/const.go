@@ -35,6 +35,11 @@ const (
 	TOTPCacheKeyPrefix = "totp:"
 	TOTPCacheKeyFormat = TOTPCacheKeyPrefix + "%s"
 
+	// TOTPUsedCacheKeyPrefix
+	// 已使用的 TOTP 时间步，防止验证码在有效期内被重放，  totp-used:uid:counter
+	TOTPUsedCacheKeyPrefix = "totp-used:"
+	TOTPUsedCacheKeyFormat = TOTPUsedCacheKeyPrefix + "%s:%d"
+
 	// EmailBindCacheKeyPrefix
 	// 验证邮箱时的缓存key，  email-bind:uid:code: user-info
 	EmailBindCacheKeyPrefix = "email-bind:"
@@ -68,4 +73,19 @@ const (
 
 	MFALoginCacheKeyPrefix = "mfa-login:"
 	MFALoginCacheKeyFormat = MFALoginCacheKeyPrefix + "%s"
+
+	// AuthFailureCacheKeyPrefix
+	// 认证失败次数的缓存key，  auth-failure:ip: count
+	AuthFailureCacheKeyPrefix = "auth-failure:"
+	AuthFailureCacheKeyFormat = AuthFailureCacheKeyPrefix + "%s"
+
+	// AccessKeyCacheKeyPrefix
+	// AK/SK 签名凭证的缓存key，  access-key:ak: secret
+	AccessKeyCacheKeyPrefix = "access-key:"
+	AccessKeyCacheKeyFormat = AccessKeyCacheKeyPrefix + "%s"
+
+	// SignerNonceCacheKeyPrefix
+	// 已使用的 AK/SK 签名 Nonce，防止签名请求被重放，  signer-nonce:ak:nonce
+	SignerNonceCacheKeyPrefix = "signer-nonce:"
+	SignerNonceCacheKeyFormat = SignerNonceCacheKeyPrefix + "%s:%s"
 )
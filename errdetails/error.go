@@ -17,9 +17,12 @@ limitations under the License.
 package errdetails
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 )
 
 // BizError
@@ -64,6 +67,73 @@ func (e *BizError) WithMetadata(md map[string]string) *BizError {
 	return err
 }
 
+// MarshalJSON 按 code、reason、message、metadata 的固定顺序输出，metadata 按 key 排序，
+// 保证相同的错误总是得到相同的响应体
+func (e *BizError) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	field := func(name string, value interface{}) error {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(`"` + name + `":`)
+		buf.Write(b)
+		return nil
+	}
+
+	if e.Code != 0 {
+		if err := field("code", e.Code); err != nil {
+			return nil, err
+		}
+	}
+	if e.Reason != "" {
+		if err := field("reason", e.Reason); err != nil {
+			return nil, err
+		}
+	}
+	if e.Message != "" {
+		if err := field("message", e.Message); err != nil {
+			return nil, err
+		}
+	}
+	if len(e.Metadata) > 0 {
+		keys := make([]string, 0, len(e.Metadata))
+		for k := range e.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"metadata":{`)
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			kb, err := json.Marshal(k)
+			if err != nil {
+				return nil, err
+			}
+			vb, err := json.Marshal(e.Metadata[k])
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(kb)
+			buf.WriteByte(':')
+			buf.Write(vb)
+		}
+		buf.WriteByte('}')
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
 func HTTPStatusCode(err error) int {
 	if err == nil {
 		return http.StatusOK
@@ -0,0 +1,65 @@
+package errdetails
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestBizErrorMarshalJSON(t *testing.T) {
+	err := New(http.StatusBadRequest, 400, "InvalidArgument", "bad request").WithMetadata(map[string]string{
+		"user_id":   "42",
+		"field":     "name",
+		"action":    "create",
+		"namespace": "default",
+		"quote":     `a "b"`,
+	})
+
+	want := `{"code":400,"reason":"InvalidArgument","message":"bad request",` +
+		`"metadata":{"action":"create","field":"name","namespace":"default","quote":"a \"b\"","user_id":"42"}}`
+	for i := 0; i < 20; i++ {
+		got, e := json.Marshal(err)
+		if e != nil {
+			t.Fatalf("json.Marshal() error = %v", e)
+		}
+		if string(got) != want {
+			t.Fatalf("json.Marshal() = %s, want %s", got, want)
+		}
+	}
+
+	// 反序列化后得到相同的字段
+	var decoded BizError
+	data, _ := json.Marshal(err)
+	if e := json.Unmarshal(data, &decoded); e != nil {
+		t.Fatalf("json.Unmarshal() error = %v", e)
+	}
+	if decoded.Code != err.Code || decoded.Reason != err.Reason || decoded.Message != err.Message ||
+		!reflect.DeepEqual(decoded.Metadata, err.Metadata) {
+		t.Errorf("json.Unmarshal() = %+v, want %+v", decoded, err)
+	}
+}
+
+func TestBizErrorMarshalJSONOmitEmpty(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *BizError
+		want string
+	}{
+		{name: "empty", err: &BizError{}, want: `{}`},
+		{name: "message only", err: &BizError{Message: "oops"}, want: `{"message":"oops"}`},
+		{name: "cloned without metadata", err: Clone(New(http.StatusNotFound, 404, "NotFound", "")), want: `{"code":404,"reason":"NotFound"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.err)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("json.Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package errdetails
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// WriteError 将错误以 JSON 格式写入响应，状态码取自 BizError.HTTPStatusCode
+func WriteError(w http.ResponseWriter, err error) {
+	e := FromError(err)
+	if e == nil {
+		return
+	}
+	status := e.HTTPStatusCode
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(e)
+}
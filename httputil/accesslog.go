@@ -0,0 +1,100 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/x893675/valhalla-common/authentication/user"
+	"github.com/x893675/valhalla-common/logger"
+)
+
+const (
+	// XRequestID 请求 ID 的 header
+	XRequestID = "X-Request-Id"
+)
+
+type userKey struct{}
+
+// WithUser 将已认证的用户信息写入 context，供访问日志等中间件使用
+func WithUser(ctx context.Context, u user.Info) context.Context {
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+// UserFrom 从 context 中获取已认证的用户信息
+func UserFrom(ctx context.Context) (user.Info, bool) {
+	u, ok := ctx.Value(userKey{}).(user.Info)
+	return u, ok && u != nil
+}
+
+// responseRecorder 记录响应状态码和写入的字节数
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	// handler 未调用 WriteHeader 时，net/http 会隐式写入 200
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		if r.status == 0 {
+			r.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 获取底层 ResponseWriter
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// AccessLog 返回记录请求方法、路径、状态码、耗时等信息的访问日志中间件
+func AccessLog(l logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			rec := &responseRecorder{ResponseWriter: w}
+
+			next.ServeHTTP(rec, req)
+
+			status := rec.status
+			if status == 0 {
+				// handler 没有写入任何内容，net/http 会返回 200
+				status = http.StatusOK
+			}
+			fields := []zap.Field{
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path),
+				zap.Int("status", status),
+				zap.Int("bytes", rec.bytes),
+				zap.Duration("duration", time.Since(start)),
+			}
+			if id := req.Header.Get(XRequestID); id != "" {
+				fields = append(fields, zap.String("request_id", id))
+			}
+			if u, ok := UserFrom(req.Context()); ok {
+				fields = append(fields, zap.String("user", u.GetName()))
+			}
+			l.Info("access", fields...)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/x893675/valhalla-common/authentication/user"
+	"github.com/x893675/valhalla-common/logger"
+)
+
+// recordLogger 记录最后一次 Info 调用的字段
+type recordLogger struct {
+	logger.Logger
+	msg    string
+	fields map[string]interface{}
+}
+
+func (r *recordLogger) Info(msg string, fields ...zap.Field) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	r.msg = msg
+	r.fields = enc.Fields
+}
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBytes  int
+	}{
+		{
+			name: "explicit status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(time.Millisecond)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("created"))
+			},
+			wantStatus: http.StatusCreated,
+			wantBytes:  7,
+		},
+		{
+			name: "implicit status on write",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			},
+			wantStatus: http.StatusOK,
+			wantBytes:  2,
+		},
+		{
+			name:       "no write at all",
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			wantStatus: http.StatusOK,
+			wantBytes:  0,
+		},
+		{
+			name: "error status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "not found", http.StatusNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBytes:  len("not found\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &recordLogger{}
+			h := AccessLog(l)(tt.handler)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/users?x=1", nil)
+			req.Header.Set(XRequestID, "req-1")
+			req = req.WithContext(WithUser(req.Context(), &user.DefaultInfo{Name: "alice"}))
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("response status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := l.fields["status"]; got != int64(tt.wantStatus) {
+				t.Errorf("logged status = %v, want %d", got, tt.wantStatus)
+			}
+			if got := l.fields["bytes"]; got != int64(tt.wantBytes) {
+				t.Errorf("logged bytes = %v, want %d", got, tt.wantBytes)
+			}
+			if d, _ := l.fields["duration"].(time.Duration); d <= 0 {
+				t.Errorf("logged duration = %v, want > 0", l.fields["duration"])
+			}
+			if got := l.fields["method"]; got != http.MethodPost {
+				t.Errorf("logged method = %v, want POST", got)
+			}
+			if got := l.fields["path"]; got != "/api/v1/users" {
+				t.Errorf("logged path = %v, want /api/v1/users", got)
+			}
+			if got := l.fields["request_id"]; got != "req-1" {
+				t.Errorf("logged request_id = %v, want req-1", got)
+			}
+			if got := l.fields["user"]; got != "alice" {
+				t.Errorf("logged user = %v, want alice", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/x893675/valhalla-common/authentication/authenticator"
+	"github.com/x893675/valhalla-common/errdetails"
+	"github.com/x893675/valhalla-common/logger"
+)
+
+const (
+	// WWWAuthenticate RFC 7235 认证质询 header
+	WWWAuthenticate = "WWW-Authenticate"
+
+	defaultChallengeScheme = "Bearer"
+)
+
+// Challenge 描述 WWW-Authenticate 质询的认证方案和 realm
+type Challenge struct {
+	// Scheme 认证方案，默认为 Bearer
+	Scheme string
+	// Realm 保护域，为空时不输出
+	Realm string
+}
+
+// String 返回 WWW-Authenticate header 的值，如 Bearer realm="valhalla"
+func (c Challenge) String() string {
+	scheme := c.Scheme
+	if scheme == "" {
+		scheme = defaultChallengeScheme
+	}
+	if c.Realm == "" {
+		return scheme
+	}
+	realm := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(c.Realm)
+	return fmt.Sprintf(`%s realm="%s"`, scheme, realm)
+}
+
+// WriteChallenge 写入 WWW-Authenticate header 和 401 错误响应
+func WriteChallenge(w http.ResponseWriter, c Challenge, msg string) {
+	w.Header().Set(WWWAuthenticate, c.String())
+	errdetails.WriteError(w, errdetails.Unauthorized("%s", msg))
+}
+
+// Authenticate 返回认证中间件，认证失败时响应 401 并携带 WWW-Authenticate 质询，
+// 认证成功时将用户信息写入请求 context
+func Authenticate(auth authenticator.Request, c Challenge) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			resp, ok, err := auth.AuthenticateRequest(req)
+			if err != nil || !ok || resp == nil || resp.User == nil {
+				if err != nil {
+					logger.Debugf("unable to authenticate the request: %s", err)
+				}
+				WriteChallenge(w, c, "unauthorized")
+				return
+			}
+			next.ServeHTTP(w, req.WithContext(WithUser(req.Context(), resp.User)))
+		})
+	}
+}
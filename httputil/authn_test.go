@@ -0,0 +1,82 @@
+package httputil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x893675/valhalla-common/authentication/authenticator"
+	"github.com/x893675/valhalla-common/authentication/user"
+	"github.com/x893675/valhalla-common/errdetails"
+)
+
+func TestChallengeString(t *testing.T) {
+	tests := []struct {
+		challenge Challenge
+		want      string
+	}{
+		{challenge: Challenge{}, want: "Bearer"},
+		{challenge: Challenge{Realm: "valhalla"}, want: `Bearer realm="valhalla"`},
+		{challenge: Challenge{Scheme: "Basic", Realm: `a "b"`}, want: `Basic realm="a \"b\""`},
+	}
+	for _, tt := range tests {
+		if got := tt.challenge.String(); got != tt.want {
+			t.Errorf("Challenge.String() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	auth := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		if req.Header.Get("Authorization") == "Bearer valid" {
+			return &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+		}
+		return nil, false, nil
+	})
+	h := Authenticate(auth, Challenge{Realm: "valhalla"})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		u, ok := UserFrom(req.Context())
+		if !ok {
+			t.Error("user should be stored in request context")
+			return
+		}
+		_, _ = w.Write([]byte(u.GetName()))
+	}))
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", rr.Code)
+		}
+		if got := rr.Header().Get(WWWAuthenticate); got != `Bearer realm="valhalla"` {
+			t.Errorf("WWW-Authenticate = %q, want Bearer realm=\"valhalla\"", got)
+		}
+		var body errdetails.BizError
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid error body: %v", err)
+		}
+		if body.Code != errdetails.UnauthorizedCode || body.Reason != errdetails.UnauthorizedReason {
+			t.Errorf("error body = %+v, want Unauthorized", body)
+		}
+	})
+
+	t.Run("authenticated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer valid")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200", rr.Code)
+		}
+		if got := rr.Header().Get(WWWAuthenticate); got != "" {
+			t.Errorf("WWW-Authenticate = %q, want empty", got)
+		}
+		if rr.Body.String() != "alice" {
+			t.Errorf("body = %q, want alice", rr.Body.String())
+		}
+	})
+}
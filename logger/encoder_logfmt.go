@@ -0,0 +1,154 @@
+/*
+Copyright 2024 x893675.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var _logfmtPool = buffer.NewPool()
+
+// logfmtEncoder 以 logfmt（key=value）格式输出日志
+// 字段通过内嵌的 MapObjectEncoder 收集，输出时按 key 排序以保证结果稳定
+type logfmtEncoder struct {
+	*zapcore.MapObjectEncoder
+	cfg        zapcore.EncoderConfig
+	formatTime func(time.Time) string
+}
+
+func newLogfmtEncoder(cfg zapcore.EncoderConfig, formatTime func(time.Time) string) zapcore.Encoder {
+	return &logfmtEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		cfg:              cfg,
+		formatTime:       formatTime,
+	}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		cfg:              e.cfg,
+		formatTime:       e.formatTime,
+	}
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	return clone
+}
+
+func (e *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf := _logfmtPool.Get()
+
+	if e.cfg.TimeKey != "" && !ent.Time.IsZero() {
+		writeLogfmtPair(buf, e.cfg.TimeKey, e.formatTime(ent.Time))
+	}
+	if e.cfg.LevelKey != "" {
+		writeLogfmtPair(buf, e.cfg.LevelKey, ent.Level.String())
+	}
+	if e.cfg.NameKey != "" && ent.LoggerName != "" {
+		writeLogfmtPair(buf, e.cfg.NameKey, ent.LoggerName)
+	}
+	if e.cfg.CallerKey != "" && ent.Caller.Defined {
+		writeLogfmtPair(buf, e.cfg.CallerKey, ent.Caller.TrimmedPath())
+	}
+	if e.cfg.MessageKey != "" {
+		writeLogfmtPair(buf, e.cfg.MessageKey, ent.Message)
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for k, v := range e.Fields {
+		enc.Fields[k] = v
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeLogfmtPair(buf, k, formatLogfmtValue(enc.Fields[k]))
+	}
+
+	if e.cfg.StacktraceKey != "" && ent.Stack != "" {
+		writeLogfmtPair(buf, e.cfg.StacktraceKey, ent.Stack)
+	}
+
+	lineEnding := e.cfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	buf.AppendString(lineEnding)
+	return buf, nil
+}
+
+func writeLogfmtPair(buf *buffer.Buffer, key, value string) {
+	if buf.Len() > 0 {
+		buf.AppendByte(' ')
+	}
+	buf.AppendString(key)
+	buf.AppendByte('=')
+	buf.AppendString(quoteLogfmtValue(value))
+}
+
+func formatLogfmtValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case error:
+		return t.Error()
+	case fmt.Stringer:
+		return t.String()
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// quoteLogfmtValue 当值为空或包含空格、等号、引号及控制字符时加引号
+func quoteLogfmtValue(s string) string {
+	if s == "" {
+		return `""`
+	}
+	if strings.IndexFunc(s, func(r rune) bool {
+		return r == ' ' || r == '=' || r == '"' || unicode.IsSpace(r) || unicode.IsControl(r)
+	}) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// parseLogfmt 解析一行 logfmt 输出，支持带引号的值
+func parseLogfmt(t *testing.T, line string) map[string]string {
+	t.Helper()
+	res := map[string]string{}
+	for line != "" {
+		line = strings.TrimLeft(line, " ")
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			t.Fatalf("invalid logfmt pair: %q", line)
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+		if strings.HasPrefix(line, `"`) {
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				t.Fatalf("invalid quoted value: %q", line)
+			}
+			value, _ := strconv.Unquote(quoted)
+			res[key] = value
+			line = line[len(quoted):]
+			continue
+		}
+		end := strings.IndexByte(line, ' ')
+		if end < 0 {
+			end = len(line)
+		}
+		res[key] = line[:end]
+		line = line[end:]
+	}
+	return res
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	enc := newDefaultProductionLogEncoder("logfmt", false).Clone()
+	zap.String("request_id", "abc").AddTo(enc)
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	buf, err := enc.EncodeEntry(zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       ts,
+		LoggerName: "test",
+		Message:    "hello world",
+	}, []zapcore.Field{
+		zap.String("path", "/api/v1"),
+		zap.String("agent", `curl "7.0" beta`),
+		zap.Int("status", 200),
+		zap.Bool("ok", true),
+		zap.String("empty", ""),
+	})
+	if err != nil {
+		t.Fatalf("EncodeEntry() error = %v", err)
+	}
+	line := buf.String()
+	if !strings.HasSuffix(line, "\n") {
+		t.Errorf("line should end with newline: %q", line)
+	}
+
+	got := parseLogfmt(t, strings.TrimSuffix(line, "\n"))
+	want := map[string]string{
+		"ts":         ts.Format(logTimeLayout),
+		"level":      "info",
+		"logger":     "test",
+		"msg":        "hello world",
+		"request_id": "abc",
+		"path":       "/api/v1",
+		"agent":      `curl "7.0" beta`,
+		"status":     "200",
+		"ok":         "true",
+		"empty":      "",
+	}
+	if len(got) != len(want) {
+		t.Errorf("parsed %d pairs, want %d: %q", len(got), len(want), line)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+	if !strings.HasPrefix(line, "ts=") {
+		t.Errorf("line should start with ts: %q", line)
+	}
+	if !strings.Contains(line, `msg="hello world"`) {
+		t.Errorf("message with spaces should be quoted: %q", line)
+	}
+}
+
+func TestProductionLogEncoderUTC(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 11, 4, 5, 0, time.FixedZone("CST", 8*3600))
+
+	for _, format := range []string{"json", "logfmt", "console"} {
+		for _, utc := range []bool{true, false} {
+			buf, err := newDefaultProductionLogEncoder(format, utc).EncodeEntry(zapcore.Entry{
+				Level:   zapcore.InfoLevel,
+				Time:    ts,
+				Message: "hello",
+			}, nil)
+			if err != nil {
+				t.Fatalf("EncodeEntry() error = %v", err)
+			}
+			want := "2024-01-02T11:04:05+08:00"
+			if utc {
+				want = "2024-01-02T03:04:05Z"
+			}
+			if line := buf.String(); !strings.Contains(line, want) {
+				t.Errorf("format=%s utc=%v: line = %q, want timestamp %s", format, utc, line, want)
+			}
+		}
+	}
+}
@@ -42,7 +42,7 @@ func defaultZapLogger() *loggingT {
 	var multiWriteSyncer []zapcore.WriteSyncer
 	// 默认总是输出到 stdout
 	multiWriteSyncer = append(multiWriteSyncer, os.Stdout)
-	core := zapcore.NewCore(newDefaultProductionLogEncoder(opts.Format), zapcore.NewMultiWriteSyncer(multiWriteSyncer...), level)
+	core := zapcore.NewCore(newDefaultProductionLogEncoder(opts.Format, opts.UTC), zapcore.NewMultiWriteSyncer(multiWriteSyncer...), level)
 	zl := zap.New(core)
 	zl = zl.WithOptions(zap.AddStacktrace(zapcore.ErrorLevel))
 
@@ -53,7 +53,11 @@ func defaultZapLogger() *loggingT {
 	}
 }
 
+// ApplyZapLoggerWithOptions 使用 opts 重新配置全局日志，opts 为空时使用 NewLogOptions 的默认配置
 func ApplyZapLoggerWithOptions(opts *Options) {
+	if opts == nil {
+		opts = NewLogOptions()
+	}
 	_logging.mu.Lock()
 	defer _logging.mu.Unlock()
 	var multiWriteSyncer []zapcore.WriteSyncer
@@ -70,13 +74,13 @@ func ApplyZapLoggerWithOptions(opts *Options) {
 			MaxBackups: fileOpts.MaxBackups,
 			MaxAge:     fileOpts.MaxAgeDays,
 			Compress:   fileOpts.Compress,
-			LocalTime:  true, // 始终使用本地时间
+			LocalTime:  !opts.UTC, // 备份文件名中的时间与日志时间戳保持一致
 		}
 		multiWriteSyncer = append(multiWriteSyncer, zapcore.Lock(zapcore.AddSync(lumberJackLogger)))
 	}
 
 	level := convertZapLogLevel(opts.Level)
-	core := zapcore.NewCore(newDefaultProductionLogEncoder(opts.Format),
+	core := zapcore.NewCore(newDefaultProductionLogEncoder(opts.Format, opts.UTC),
 		zapcore.NewMultiWriteSyncer(multiWriteSyncer...),
 		level)
 	zl := zap.New(core)
@@ -125,17 +129,30 @@ type LogFilter interface {
 	FilterF(format string, args []interface{}) (string, []interface{})
 }
 
-func newDefaultProductionLogEncoder(format string) zapcore.Encoder {
+const logTimeLayout = "2006-01-02T15:04:05Z07:00"
+
+// newDefaultProductionLogEncoder 创建日志编码器，utc 为 true 时时间戳使用 UTC，否则使用本地时间
+func newDefaultProductionLogEncoder(format string, utc bool) zapcore.Encoder {
 	encCfg := zap.NewProductionEncoderConfig()
+	formatTime := func(ts time.Time) string {
+		if utc {
+			ts = ts.UTC()
+		}
+		return ts.Format(logTimeLayout)
+	}
 	encCfg.EncodeTime = func(ts time.Time, encoder zapcore.PrimitiveArrayEncoder) {
-		encoder.AppendString(ts.Format("2006-01-02T15:04:05Z07:00"))
+		encoder.AppendString(formatTime(ts))
 	}
-	// 支持 console 格式，默认使用 json
-	if format == "console" {
+	// 支持 console、logfmt 格式，默认使用 json
+	switch format {
+	case "console":
 		encCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
 		return zapcore.NewConsoleEncoder(encCfg)
+	case "logfmt":
+		return newLogfmtEncoder(encCfg, formatTime)
+	default:
+		return zapcore.NewJSONEncoder(encCfg)
 	}
-	return zapcore.NewJSONEncoder(encCfg)
 }
 
 func Info(msg string, fields ...zap.Field) {
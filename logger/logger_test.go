@@ -0,0 +1,18 @@
+package logger
+
+import "testing"
+
+func TestApplyZapLoggerWithNilOptions(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("ApplyZapLoggerWithOptions(nil) panicked: %v", r)
+		}
+	}()
+	ApplyZapLoggerWithOptions(nil)
+	if !_logging.l.Core().Enabled(convertZapLogLevel(NewLogOptions().Level)) {
+		t.Error("default log level should be enabled")
+	}
+	if _logging.l.Core().Enabled(convertZapLogLevel("debug")) {
+		t.Error("debug level should be disabled by default")
+	}
+}
@@ -20,12 +20,14 @@ package logger
 type Options struct {
 	// Level 日志级别: debug, info, warn, error
 	Level string `json:"level" yaml:"level" toml:"level"`
-	// Format 输出格式: console, json
+	// Format 输出格式: console, json, logfmt
 	Format string `json:"format" yaml:"format" toml:"format"`
 	// Output 输出目标: stdout（仅标准输出）或文件路径（标准输出+文件，如 /var/log/app.log）
 	Output string `json:"output" yaml:"output" toml:"output"`
 	// File 文件轮转配置（仅当 Output 为文件路径时有效）
 	File *FileOptions `json:"file,omitempty" yaml:"file,omitempty" toml:"file,omitempty"`
+	// UTC 日志时间戳和轮转文件名使用 UTC 时间，默认使用本地时间
+	UTC bool `json:"utc,omitempty" yaml:"utc,omitempty" toml:"utc,omitempty"`
 }
 
 // FileOptions 日志文件轮转配置
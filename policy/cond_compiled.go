@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+)
+
+// CompiledCondition 预编译的条件，编译时解析操作符函数以及日期、IP/CIDR、数值和布尔等条件值，
+// 适用于同一条件需要对大量请求重复求值的场景，可并发使用
+type CompiledCondition struct {
+	clauses []compiledClause
+}
+
+type compiledClause struct {
+	key string
+	// null 为 true 时 match 的参数为条件键是否存在
+	null bool
+	// ifExists 为 true 时条件键不存在视为满足
+	ifExists bool
+	match    func(value interface{}) bool
+}
+
+// CompileCondition 编译条件，条件使用未知的操作符时返回 ErrInvalidPolicy
+func CompileCondition(cond Condition) (*CompiledCondition, error) {
+	c := &CompiledCondition{}
+	for name, values := range cond {
+		op, ok := lookupConditionOperator(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown condition operator %q", ErrInvalidPolicy, name)
+		}
+		for key, v := range values {
+			c.clauses = append(c.clauses, compiledClause{
+				key:      key,
+				null:     op.name == Null,
+				ifExists: op.ifExists,
+				match:    compileMatch(op.name, op.fn, v),
+			})
+		}
+	}
+	return c, nil
+}
+
+// Evaluate 使用上下文对条件求值，所有条件都满足时返回 true，结果与 ConditionMather 一致
+func (c *CompiledCondition) Evaluate(ctx ConditionContext) bool {
+	for _, clause := range c.clauses {
+		value, exists := ctx[clause.key]
+		if clause.null {
+			if !clause.match(exists) {
+				return false
+			}
+			continue
+		}
+		if !exists {
+			if clause.ifExists {
+				continue
+			}
+			return false
+		}
+		if !clause.match(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func compileMatch(op string, fn ConditionOperatorFunc, values []string) func(interface{}) bool {
+	switch op {
+	case DateEquals, DateNotEquals, DateLessThan, DateLessThanEquals, DateGreaterThan, DateGreaterThanEquals:
+		return compileDateMatch(op, values)
+	case IPAddress, NotIPAddress:
+		return compileIPMatch(op == NotIPAddress, values)
+	case NumericEquals, NumericNotEquals, NumericLessThan, NumericLessThanEquals, NumericGreaterThan, NumericGreaterThanEquals:
+		ints := parseInts(values)
+		return func(value interface{}) bool {
+			return fn(value, ints)
+		}
+	case ArnLike, ArnNotLike:
+		patterns := make([]arnPattern, len(values))
+		for i, v := range values {
+			patterns[i] = compileArnPattern(v)
+		}
+		not := op == ArnNotLike
+		return func(value interface{}) bool {
+			// 与 ArnLikeFunc 一致，请求值不是字符串时 ArnLike 和 ArnNotLike 都不匹配
+			arn, ok := value.(string)
+			if !ok {
+				return false
+			}
+			for _, p := range patterns {
+				if p.match(arn) {
+					return !not
+				}
+			}
+			return not
+		}
+	case Bool:
+		bools := parseBools(values)
+		return func(value interface{}) bool {
+			return fn(value, bools)
+		}
+	default:
+		return func(value interface{}) bool {
+			return fn(value, values)
+		}
+	}
+}
+
+func compileDateMatch(op string, values []string) func(interface{}) bool {
+	var cmp func(a, b time.Time) bool
+	switch op {
+	case DateEquals:
+		cmp = func(a, b time.Time) bool { return a.Equal(b) }
+	case DateNotEquals:
+		cmp = func(a, b time.Time) bool { return !a.Equal(b) }
+	case DateLessThan:
+		cmp = func(a, b time.Time) bool { return a.Before(b) }
+	case DateLessThanEquals:
+		cmp = func(a, b time.Time) bool { return !a.After(b) }
+	case DateGreaterThan:
+		cmp = func(a, b time.Time) bool { return a.After(b) }
+	case DateGreaterThanEquals:
+		cmp = func(a, b time.Time) bool { return !a.Before(b) }
+	}
+
+	// 与 DateXXXFunc 一致，无法解析的时间按零值比较
+	times := make([]time.Time, len(values))
+	for i, v := range values {
+		times[i], _ = time.Parse(time.RFC3339, v)
+	}
+	return func(value interface{}) bool {
+		return matchStrings(value, func(value string) bool {
+			t, _ := time.Parse(time.RFC3339, value)
+			return anyMatch(t, times, cmp)
+		})
+	}
+}
+
+func compileIPMatch(not bool, values []string) func(interface{}) bool {
+	ips := make([]policyIP, len(values))
+	for i, v := range values {
+		ips[i] = parsePolicyIP(v)
+	}
+	return func(value interface{}) bool {
+		return matchStrings(value, func(value string) bool {
+			requestIP := parseConditionIP(value)
+			if requestIP == nil {
+				return false
+			}
+			for _, p := range ips {
+				// 与 IPAddressFunc 一致，无效的条件值不匹配
+				if matched, ok := p.match(requestIP); ok && matched != not {
+					return true
+				}
+			}
+			return false
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestCompiledCondition(t *testing.T) {
+	type testCase struct {
+		name           string
+		conditionCtx   ConditionContext
+		condition      Condition
+		expectedResult bool
+	}
+	cases := []testCase{
+		{
+			name:           "NotIPAddress - 不在网段内",
+			conditionCtx:   ConditionContext{"acs:SourceIp": "10.0.0.1"},
+			condition:      Condition{NotIPAddress: ConditionValue{"acs:SourceIp": []string{"192.168.0.0/16"}}},
+			expectedResult: true,
+		},
+		{
+			name:           "NotIPAddress - 在网段内",
+			conditionCtx:   ConditionContext{"acs:SourceIp": "192.168.1.1"},
+			condition:      Condition{NotIPAddress: ConditionValue{"acs:SourceIp": []string{"192.168.0.0/16", "invalid"}}},
+			expectedResult: false,
+		},
+		{
+			name:           "IP地址 - 请求 IP 无效",
+			conditionCtx:   ConditionContext{"acs:SourceIp": "invalid"},
+			condition:      Condition{IPAddress: ConditionValue{"acs:SourceIp": []string{"0.0.0.0/0"}}},
+			expectedResult: false,
+		},
+		{
+			name:           "日期大于等于 - 相等",
+			conditionCtx:   ConditionContext{"acs:CurrentTime": "2024-01-12T06:59:00Z"},
+			condition:      Condition{DateGreaterThanEquals: ConditionValue{"acs:CurrentTime": []string{"2024-01-12T14:59:00+08:00"}}},
+			expectedResult: true,
+		},
+		{
+			name:           "日期不等 - 匹配",
+			conditionCtx:   ConditionContext{"acs:CurrentTime": "2024-01-10T00:00:00Z"},
+			condition:      Condition{DateNotEquals: ConditionValue{"acs:CurrentTime": []string{"2024-01-12T00:00:00Z"}}},
+			expectedResult: true,
+		},
+		{
+			name:           "空条件",
+			conditionCtx:   ConditionContext{},
+			condition:      Condition{},
+			expectedResult: true,
+		},
+	}
+
+	for _, tt := range conditionMatherTests {
+		cases = append(cases, testCase{tt.name, tt.conditionCtx, tt.condition, tt.expectedResult})
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := CompileCondition(tt.condition)
+			if err != nil {
+				t.Fatalf("CompileCondition() error = %v", err)
+			}
+			if got := compiled.Evaluate(tt.conditionCtx); got != tt.expectedResult {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.expectedResult)
+			}
+
+			// 与 ConditionMather 的结果一致
+			ctxJSON, _ := json.Marshal(tt.conditionCtx)
+			condJSON, _ := json.Marshal(tt.condition)
+			want, err := ConditionMather(string(ctxJSON), string(condJSON))
+			if err != nil {
+				t.Fatalf("ConditionMather() error = %v", err)
+			}
+			if got := compiled.Evaluate(tt.conditionCtx); got != want.(bool) {
+				t.Errorf("Evaluate() = %v, ConditionMather() = %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCompileConditionWithUnknownOperator(t *testing.T) {
+	_, err := CompileCondition(Condition{"UnknownOperator": ConditionValue{"key": []string{"value"}}})
+	if !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("CompileCondition() error = %v, want %v", err, ErrInvalidPolicy)
+	}
+}
+
+var benchCondition = Condition{
+	IPAddress: ConditionValue{
+		"acs:SourceIp": []string{"10.0.0.0/8", "192.168.0.0/16"},
+	},
+	DateLessThan: ConditionValue{
+		"acs:CurrentTime": []string{"2030-01-01T00:00:00Z"},
+	},
+	StringEquals: ConditionValue{
+		"acs:UserRole": []string{"admin", "superuser"},
+	},
+}
+
+var benchConditionCtx = ConditionContext{
+	"acs:SourceIp":    "192.168.1.10",
+	"acs:CurrentTime": "2024-01-10T00:00:00Z",
+	"acs:UserRole":    "admin",
+}
+
+func BenchmarkConditionMather(b *testing.B) {
+	ctxJSON, _ := json.Marshal(benchConditionCtx)
+	condJSON, _ := json.Marshal(benchCondition)
+	ctx, cond := string(ctxJSON), string(condJSON)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, _ := ConditionMather(ctx, cond); !ok.(bool) {
+			b.Fatal("ConditionMather() = false")
+		}
+	}
+}
+
+func BenchmarkCompiledConditionEvaluate(b *testing.B) {
+	compiled, err := CompileCondition(benchCondition)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !compiled.Evaluate(benchConditionCtx) {
+			b.Fatal("Evaluate() = false")
+		}
+	}
+}
@@ -2,6 +2,8 @@ package policy
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 )
 
 func ConditionMather(arguments ...interface{}) (interface{}, error) {
@@ -22,18 +24,84 @@ func ConditionMather(arguments ...interface{}) (interface{}, error) {
 	}
 
 	for k, cond := range conds {
-		fn, ok := conditionOperatorFuncMap[k]
+		op, ok := lookupConditionOperator(k)
 		if !ok {
 			return false, nil
 		}
 		for condKey, v1 := range cond {
-			if _, ok := condsContext[condKey]; !ok {
+			_, exists := condsContext[condKey]
+			// Null 只检查键是否存在，不关心键的值
+			if op.name == Null {
+				if !op.fn(exists, v1) {
+					return false, nil
+				}
+				continue
+			}
+			if !exists {
+				// IfExists 操作符在键不存在时视为满足
+				if op.ifExists {
+					continue
+				}
 				return false, nil
 			}
-			if !fn(condsContext[condKey], v1) {
+			if !op.fn(condsContext[condKey], v1) {
 				return false, nil
 			}
 		}
 	}
 	return true, nil
 }
+
+// ConditionMatherExplain 与 ConditionMather 的求值规则一致，条件不满足时额外返回每个未满足的条件的原因，
+// 原因按操作符和条件键排序，条件全部满足时原因为空
+func ConditionMatherExplain(condsContextString, conditionString string) (bool, []string, error) {
+	if conditionString == "" {
+		return true, nil, nil
+	}
+	var conds Condition
+	if err := json.Unmarshal([]byte(conditionString), &conds); err != nil {
+		return false, nil, err
+	}
+	var condsContext ConditionContext
+	if err := json.Unmarshal([]byte(condsContextString), &condsContext); err != nil {
+		return false, nil, err
+	}
+
+	var reasons []string
+	for _, name := range sortedKeys(conds) {
+		op, ok := lookupConditionOperator(name)
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("unknown condition operator %s", name))
+			continue
+		}
+		cond := conds[name]
+		for _, condKey := range sortedKeys(cond) {
+			value, exists := condsContext[condKey]
+			if op.name == Null {
+				if !op.fn(exists, cond[condKey]) {
+					reasons = append(reasons, fmt.Sprintf("%s %s did not match", name, condKey))
+				}
+				continue
+			}
+			if !exists {
+				if !op.ifExists {
+					reasons = append(reasons, fmt.Sprintf("key %s not present", condKey))
+				}
+				continue
+			}
+			if !op.fn(value, cond[condKey]) {
+				reasons = append(reasons, fmt.Sprintf("%s %s did not match", name, condKey))
+			}
+		}
+	}
+	return len(reasons) == 0, reasons, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
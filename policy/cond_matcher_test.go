@@ -2,158 +2,422 @@ package policy
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
+	"time"
 )
 
-func TestConditionMather(t *testing.T) {
-	tests := []struct {
-		name           string
-		conditionCtx   ConditionContext
-		condition      Condition
-		expectedResult bool
-		expectError    bool
-	}{
-		{
-			name: "IP地址匹配 - 精确匹配",
-			conditionCtx: ConditionContext{
-				"acs:SourceIp": "10.0.0.1",
+var conditionMatherTests = []struct {
+	name           string
+	conditionCtx   ConditionContext
+	condition      Condition
+	expectedResult bool
+	expectError    bool
+}{
+	{
+		name: "IP地址匹配 - 精确匹配",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "10.0.0.1",
+		},
+		condition: Condition{
+			IPAddress: ConditionValue{
+				"acs:SourceIp": []string{"10.0.0.1", "192.168.1.1"},
 			},
-			condition: Condition{
-				IPAddress: ConditionValue{
-					"acs:SourceIp": []string{"10.0.0.1", "192.168.1.1"},
-				},
+		},
+		expectedResult: true,
+		expectError:    false,
+	},
+	{
+		name: "IP地址匹配 - CIDR匹配",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "192.168.234.50",
+		},
+		condition: Condition{
+			IPAddress: ConditionValue{
+				"acs:SourceIp": []string{"192.168.234.0/24"},
 			},
-			expectedResult: true,
-			expectError:    false,
 		},
-		{
-			name: "IP地址匹配 - CIDR匹配",
-			conditionCtx: ConditionContext{
-				"acs:SourceIp": "192.168.234.50",
+		expectedResult: true,
+		expectError:    false,
+	},
+	{
+		name: "IP地址不匹配",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "127.0.0.1",
+		},
+		condition: Condition{
+			IPAddress: ConditionValue{
+				"acs:SourceIp": []string{"10.0.0.1", "192.168.234.0/24"},
 			},
-			condition: Condition{
-				IPAddress: ConditionValue{
-					"acs:SourceIp": []string{"192.168.234.0/24"},
-				},
+		},
+		expectedResult: false,
+		expectError:    false,
+	},
+	{
+		name: "日期小于 - 匹配",
+		conditionCtx: ConditionContext{
+			"acs:CurrentTime": "2024-01-10T00:00:00Z",
+		},
+		condition: Condition{
+			DateLessThan: ConditionValue{
+				"acs:CurrentTime": []string{"2024-01-12T06:59:00Z"},
 			},
-			expectedResult: true,
-			expectError:    false,
 		},
-		{
-			name: "IP地址不匹配",
-			conditionCtx: ConditionContext{
-				"acs:SourceIp": "127.0.0.1",
+		expectedResult: true,
+		expectError:    false,
+	},
+	{
+		name: "日期小于 - 不匹配",
+		conditionCtx: ConditionContext{
+			"acs:CurrentTime": "2024-01-15T00:00:00Z",
+		},
+		condition: Condition{
+			DateLessThan: ConditionValue{
+				"acs:CurrentTime": []string{"2024-01-12T06:59:00Z"},
 			},
-			condition: Condition{
-				IPAddress: ConditionValue{
-					"acs:SourceIp": []string{"10.0.0.1", "192.168.234.0/24"},
-				},
+		},
+		expectedResult: false,
+		expectError:    false,
+	},
+	{
+		name: "字符串相等 - 匹配",
+		conditionCtx: ConditionContext{
+			"acs:UserRole": "admin",
+		},
+		condition: Condition{
+			StringEquals: ConditionValue{
+				"acs:UserRole": []string{"admin", "superuser"},
 			},
-			expectedResult: false,
-			expectError:    false,
 		},
-		{
-			name: "日期小于 - 匹配",
-			conditionCtx: ConditionContext{
-				"acs:CurrentTime": "2024-01-10T00:00:00Z",
+		expectedResult: true,
+		expectError:    false,
+	},
+	{
+		name: "字符串相等 - 不匹配",
+		conditionCtx: ConditionContext{
+			"acs:UserRole": "guest",
+		},
+		condition: Condition{
+			StringEquals: ConditionValue{
+				"acs:UserRole": []string{"admin", "superuser"},
 			},
-			condition: Condition{
-				DateLessThan: ConditionValue{
-					"acs:CurrentTime": []string{"2024-01-12T06:59:00Z"},
-				},
+		},
+		expectedResult: false,
+		expectError:    false,
+	},
+	{
+		name: "多个条件 - 全部匹配",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp":    "10.0.0.1",
+			"acs:CurrentTime": "2024-01-10T00:00:00Z",
+		},
+		condition: Condition{
+			IPAddress: ConditionValue{
+				"acs:SourceIp": []string{"10.0.0.1"},
+			},
+			DateLessThan: ConditionValue{
+				"acs:CurrentTime": []string{"2024-01-12T00:00:00Z"},
 			},
-			expectedResult: true,
-			expectError:    false,
 		},
-		{
-			name: "日期小于 - 不匹配",
-			conditionCtx: ConditionContext{
-				"acs:CurrentTime": "2024-01-15T00:00:00Z",
+		expectedResult: true,
+		expectError:    false,
+	},
+	{
+		name: "多个条件 - 部分不匹配",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp":    "10.0.0.1",
+			"acs:CurrentTime": "2024-01-15T00:00:00Z",
+		},
+		condition: Condition{
+			IPAddress: ConditionValue{
+				"acs:SourceIp": []string{"10.0.0.1"},
 			},
-			condition: Condition{
-				DateLessThan: ConditionValue{
-					"acs:CurrentTime": []string{"2024-01-12T06:59:00Z"},
-				},
+			DateLessThan: ConditionValue{
+				"acs:CurrentTime": []string{"2024-01-12T00:00:00Z"},
 			},
-			expectedResult: false,
-			expectError:    false,
 		},
-		{
-			name: "字符串相等 - 匹配",
-			conditionCtx: ConditionContext{
-				"acs:UserRole": "admin",
+		expectedResult: false,
+		expectError:    false,
+	},
+	{
+		name: "上下文缺少必需字段",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "10.0.0.1",
+		},
+		condition: Condition{
+			DateLessThan: ConditionValue{
+				"acs:CurrentTime": []string{"2024-01-12T00:00:00Z"},
 			},
-			condition: Condition{
-				StringEquals: ConditionValue{
-					"acs:UserRole": []string{"admin", "superuser"},
-				},
+		},
+		expectedResult: false,
+		expectError:    false,
+	},
+	{
+		name: "IPv6 地址匹配 - 精确匹配",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "2001:db8::1",
+		},
+		condition: Condition{
+			IPAddress: ConditionValue{
+				"acs:SourceIp": []string{"2001:0db8:0000::0001"},
 			},
-			expectedResult: true,
-			expectError:    false,
 		},
-		{
-			name: "字符串相等 - 不匹配",
-			conditionCtx: ConditionContext{
-				"acs:UserRole": "guest",
+		expectedResult: true,
+		expectError:    false,
+	},
+	{
+		name: "IPv6 地址匹配 - CIDR匹配",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "2001:db8:abcd::42",
+		},
+		condition: Condition{
+			IPAddress: ConditionValue{
+				"acs:SourceIp": []string{"10.0.0.0/8", "2001:db8::/32"},
 			},
-			condition: Condition{
-				StringEquals: ConditionValue{
-					"acs:UserRole": []string{"admin", "superuser"},
-				},
+		},
+		expectedResult: true,
+		expectError:    false,
+	},
+	{
+		name: "IPv6 地址匹配 - CIDR不匹配",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "2001:db9::1",
+		},
+		condition: Condition{
+			IPAddress: ConditionValue{
+				"acs:SourceIp": []string{"2001:db8::/32"},
 			},
-			expectedResult: false,
-			expectError:    false,
 		},
-		{
-			name: "多个条件 - 全部匹配",
-			conditionCtx: ConditionContext{
-				"acs:SourceIp":    "10.0.0.1",
-				"acs:CurrentTime": "2024-01-10T00:00:00Z",
+		expectedResult: false,
+		expectError:    false,
+	},
+	{
+		name: "IPv6 地址匹配 - 带 zone 的地址",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "fe80::1%eth0",
+		},
+		condition: Condition{
+			IPAddress: ConditionValue{
+				"acs:SourceIp": []string{"fe80::/10"},
 			},
-			condition: Condition{
-				IPAddress: ConditionValue{
-					"acs:SourceIp": []string{"10.0.0.1"},
-				},
-				DateLessThan: ConditionValue{
-					"acs:CurrentTime": []string{"2024-01-12T00:00:00Z"},
-				},
+		},
+		expectedResult: true,
+		expectError:    false,
+	},
+	{
+		name: "IPv6 地址不匹配 - 带 zone 的地址",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "fe80::1%eth0",
+		},
+		condition: Condition{
+			NotIPAddress: ConditionValue{
+				"acs:SourceIp": []string{"fe80::1"},
 			},
-			expectedResult: true,
-			expectError:    false,
 		},
-		{
-			name: "多个条件 - 部分不匹配",
-			conditionCtx: ConditionContext{
-				"acs:SourceIp":    "10.0.0.1",
-				"acs:CurrentTime": "2024-01-15T00:00:00Z",
+		expectedResult: false,
+		expectError:    false,
+	},
+	{
+		name: "Null - 键必须不存在，上下文缺少该键",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "10.0.0.1",
+		},
+		condition: Condition{
+			Null: ConditionValue{
+				"acs:Token": []string{"true"},
 			},
-			condition: Condition{
-				IPAddress: ConditionValue{
-					"acs:SourceIp": []string{"10.0.0.1"},
-				},
-				DateLessThan: ConditionValue{
-					"acs:CurrentTime": []string{"2024-01-12T00:00:00Z"},
-				},
+		},
+		expectedResult: true,
+		expectError:    false,
+	},
+	{
+		name: "Null - 键必须不存在，上下文包含该键",
+		conditionCtx: ConditionContext{
+			"acs:Token": "abc",
+		},
+		condition: Condition{
+			Null: ConditionValue{
+				"acs:Token": []string{"true"},
 			},
-			expectedResult: false,
-			expectError:    false,
 		},
-		{
-			name: "上下文缺少必需字段",
-			conditionCtx: ConditionContext{
-				"acs:SourceIp": "10.0.0.1",
+		expectedResult: false,
+		expectError:    false,
+	},
+	{
+		name: "Null - 键必须存在，上下文包含该键",
+		conditionCtx: ConditionContext{
+			"acs:Token": "abc",
+		},
+		condition: Condition{
+			Null: ConditionValue{
+				"acs:Token": []string{"false"},
 			},
-			condition: Condition{
-				DateLessThan: ConditionValue{
-					"acs:CurrentTime": []string{"2024-01-12T00:00:00Z"},
-				},
+		},
+		expectedResult: true,
+		expectError:    false,
+	},
+	{
+		name: "Null - 键必须存在，上下文缺少该键",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "10.0.0.1",
+		},
+		condition: Condition{
+			Null: ConditionValue{
+				"acs:Token": []string{"false"},
 			},
-			expectedResult: false,
-			expectError:    false,
 		},
-	}
+		expectedResult: false,
+		expectError:    false,
+	},
+	{
+		name: "Null - 与其他条件组合",
+		conditionCtx: ConditionContext{
+			"acs:SourceIp": "10.0.0.1",
+		},
+		condition: Condition{
+			Null: ConditionValue{
+				"acs:Token": []string{"true"},
+			},
+			IPAddress: ConditionValue{
+				"acs:SourceIp": []string{"10.0.0.0/8"},
+			},
+		},
+		expectedResult: true,
+		expectError:    false,
+	},
+	{
+		name:           "数值小于 - 匹配",
+		conditionCtx:   ConditionContext{"acs:MaxKeys": 50},
+		condition:      Condition{NumericLessThan: ConditionValue{"acs:MaxKeys": []string{"100"}}},
+		expectedResult: true,
+	},
+	{
+		name:           "数值小于 - 不匹配",
+		conditionCtx:   ConditionContext{"acs:MaxKeys": 150},
+		condition:      Condition{NumericLessThan: ConditionValue{"acs:MaxKeys": []string{"100"}}},
+		expectedResult: false,
+	},
+	{
+		name:           "数值等于 - 请求值为字符串",
+		conditionCtx:   ConditionContext{"acs:MaxKeys": "100"},
+		condition:      Condition{NumericEquals: ConditionValue{"acs:MaxKeys": []string{"invalid", "100"}}},
+		expectedResult: true,
+	},
+	{
+		name:           "数值大于等于 - 请求值无法解析",
+		conditionCtx:   ConditionContext{"acs:MaxKeys": "many"},
+		condition:      Condition{NumericGreaterThanEquals: ConditionValue{"acs:MaxKeys": []string{"0"}}},
+		expectedResult: false,
+	},
+	{
+		name:           "数值不等 - 请求值不是整数",
+		conditionCtx:   ConditionContext{"acs:MaxKeys": 1.5},
+		condition:      Condition{NumericNotEquals: ConditionValue{"acs:MaxKeys": []string{"1"}}},
+		expectedResult: false,
+	},
+	{
+		name:           "布尔值 - 匹配",
+		conditionCtx:   ConditionContext{"acs:MFAPresent": true},
+		condition:      Condition{Bool: ConditionValue{"acs:MFAPresent": []string{"true"}}},
+		expectedResult: true,
+	},
+	{
+		name:           "布尔值 - 不匹配",
+		conditionCtx:   ConditionContext{"acs:MFAPresent": false},
+		condition:      Condition{Bool: ConditionValue{"acs:MFAPresent": []string{"true"}}},
+		expectedResult: false,
+	},
+	{
+		name:           "布尔值 - 请求值为字符串",
+		conditionCtx:   ConditionContext{"acs:MFAPresent": "true"},
+		condition:      Condition{Bool: ConditionValue{"acs:MFAPresent": []string{"yes", "true"}}},
+		expectedResult: true,
+	},
+	{
+		name:           "布尔值 - 请求值无法解析",
+		conditionCtx:   ConditionContext{"acs:MFAPresent": "yes"},
+		condition:      Condition{Bool: ConditionValue{"acs:MFAPresent": []string{"true"}}},
+		expectedResult: false,
+	},
+	{
+		name:           "ARN - 通配符匹配",
+		conditionCtx:   ConditionContext{"acs:SourceArn": "arn:acs:ecs:cn-hangzhou:123:instance/i-001"},
+		condition:      Condition{ArnLike: ConditionValue{"acs:SourceArn": []string{"arn:acs:ecs:*:123:instance/*"}}},
+		expectedResult: true,
+	},
+	{
+		name:           "ARN - 单字符通配符",
+		conditionCtx:   ConditionContext{"acs:SourceArn": "arn:acs:ecs:cn-hangzhou:123:instance/i-001"},
+		condition:      Condition{ArnLike: ConditionValue{"acs:SourceArn": []string{"arn:acs:ecs:cn-hangzhou:12?:instance/i-00?"}}},
+		expectedResult: true,
+	},
+	{
+		name:           "ARN - 账号不匹配",
+		conditionCtx:   ConditionContext{"acs:SourceArn": "arn:acs:ecs:cn-hangzhou:456:instance/i-001"},
+		condition:      Condition{ArnLike: ConditionValue{"acs:SourceArn": []string{"arn:acs:ecs:*:123:instance/*"}}},
+		expectedResult: false,
+	},
+	{
+		name:           "ARN - 通配符不跨越组件",
+		conditionCtx:   ConditionContext{"acs:SourceArn": "arn:acs:ecs:cn-hangzhou:123:instance/i-001"},
+		condition:      Condition{ArnLike: ConditionValue{"acs:SourceArn": []string{"arn:acs:*:instance/*"}}},
+		expectedResult: false,
+	},
+	{
+		name:           "ARN - 资源中包含冒号",
+		conditionCtx:   ConditionContext{"acs:SourceArn": "arn:acs:oss:cn-hangzhou:123:bucket:logs/2024"},
+		condition:      Condition{ArnLike: ConditionValue{"acs:SourceArn": []string{"arn:acs:oss:*:123:bucket:*"}}},
+		expectedResult: true,
+	},
+	{
+		name:           "ARN - 请求值不是 ARN",
+		conditionCtx:   ConditionContext{"acs:SourceArn": "instance/i-001"},
+		condition:      Condition{ArnLike: ConditionValue{"acs:SourceArn": []string{"*"}}},
+		expectedResult: false,
+	},
+	{
+		name:           "ARN Not - 全部不匹配",
+		conditionCtx:   ConditionContext{"acs:SourceArn": "arn:acs:ecs:cn-hangzhou:456:instance/i-001"},
+		condition:      Condition{ArnNotLike: ConditionValue{"acs:SourceArn": []string{"arn:acs:ecs:*:123:*", "arn:acs:rds:*:*:*"}}},
+		expectedResult: true,
+	},
+	{
+		name:           "ARN Not - 匹配其中一个",
+		conditionCtx:   ConditionContext{"acs:SourceArn": "arn:acs:ecs:cn-hangzhou:123:instance/i-001"},
+		condition:      Condition{ArnNotLike: ConditionValue{"acs:SourceArn": []string{"arn:acs:ecs:*:123:*", "arn:acs:rds:*:*:*"}}},
+		expectedResult: false,
+	},
+	{
+		name:           "IfExists - 键存在且匹配",
+		conditionCtx:   ConditionContext{"acs:UserRole": "admin"},
+		condition:      Condition{StringEquals + IfExists: ConditionValue{"acs:UserRole": []string{"admin"}}},
+		expectedResult: true,
+	},
+	{
+		name:           "IfExists - 键存在但不匹配",
+		conditionCtx:   ConditionContext{"acs:UserRole": "viewer"},
+		condition:      Condition{StringEquals + IfExists: ConditionValue{"acs:UserRole": []string{"admin"}}},
+		expectedResult: false,
+	},
+	{
+		name:           "IfExists - 键不存在",
+		conditionCtx:   ConditionContext{"acs:SourceIp": "10.0.0.1"},
+		condition:      Condition{StringEquals + IfExists: ConditionValue{"acs:UserRole": []string{"admin"}}},
+		expectedResult: true,
+	},
+	{
+		name:         "IfExists - 键不存在时其他条件仍需满足",
+		conditionCtx: ConditionContext{"acs:SourceIp": "192.168.1.1"},
+		condition: Condition{
+			NumericLessThan + IfExists: ConditionValue{"acs:MaxKeys": []string{"100"}},
+			IPAddress:                  ConditionValue{"acs:SourceIp": []string{"10.0.0.0/8"}},
+		},
+		expectedResult: false,
+	},
+}
 
-	for _, tt := range tests {
+func TestConditionMather(t *testing.T) {
+	for _, tt := range conditionMatherTests {
 		t.Run(tt.name, func(t *testing.T) {
 			// 序列化上下文和条件
 			ctxJSON, err := json.Marshal(tt.conditionCtx)
@@ -239,3 +503,251 @@ func TestConditionMatherWithUnknownOperator(t *testing.T) {
 		t.Errorf("ConditionMather() with unknown operator should return false, got %v", result)
 	}
 }
+
+func TestConditionContextSetters(t *testing.T) {
+	ctx := ConditionContext{}
+	ctx.SetString("inf:SourceIP", "192.168.1.10")
+	ctx.SetTime("inf:CurrentTime", time.Date(2024, 1, 12, 14, 59, 0, 0, time.FixedZone("CST", 8*3600)))
+	ctx.SetBool("inf:MultiFactorAuthPresent", true)
+	ctx.SetInt("inf:MaxKeys", 50)
+	roles := []string{"viewer", "admin"}
+	ctx.SetStringSlice("acs:UserRole", roles)
+	roles[0] = "changed"
+	if got := ctx["acs:UserRole"].([]string); got[0] != "viewer" {
+		t.Errorf("SetStringSlice() should copy the slice, got %v", got)
+	}
+
+	compiled, err := CompileCondition(Condition{
+		IPAddress:     ConditionValue{"inf:SourceIP": []string{"192.168.0.0/16"}},
+		DateLessThan:  ConditionValue{"inf:CurrentTime": []string{"2024-01-12T07:00:00Z"}},
+		StringEquals:  ConditionValue{"inf:SourceIP": []string{"192.168.1.10"}},
+		StringNotLike: ConditionValue{"inf:SourceIP": []string{"10.0."}},
+		StringLike:    ConditionValue{"acs:UserRole": []string{"admin"}},
+	})
+	if err != nil {
+		t.Fatalf("CompileCondition() error = %v", err)
+	}
+	if !compiled.Evaluate(ctx) {
+		t.Error("Evaluate() = false, want true")
+	}
+
+	numeric, err := CompileCondition(Condition{
+		Bool:                  ConditionValue{"inf:MultiFactorAuthPresent": []string{"true"}},
+		NumericLessThanEquals: ConditionValue{"inf:MaxKeys": []string{"100"}},
+	})
+	if err != nil {
+		t.Fatalf("CompileCondition() error = %v", err)
+	}
+	if !numeric.Evaluate(ctx) {
+		t.Error("Evaluate() for bool and numeric conditions = false, want true")
+	}
+	if NumericGreaterThanFunc(ctx["inf:MaxKeys"], []int{50}) {
+		t.Error("NumericGreaterThanFunc() = true, want false")
+	}
+
+	// 经过 JSON 序列化后仍可由 ConditionMather 求值
+	ctxJSON, err := json.Marshal(ctx)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	for _, cond := range []Condition{
+		{IPAddress: ConditionValue{"inf:SourceIP": []string{"192.168.0.0/16"}}, DateLessThan: ConditionValue{"inf:CurrentTime": []string{"2024-01-12T07:00:00Z"}}},
+		{Bool: ConditionValue{"inf:MultiFactorAuthPresent": []string{"true"}}, NumericLessThanEquals: ConditionValue{"inf:MaxKeys": []string{"100"}}},
+		{StringEquals: ConditionValue{"acs:UserRole": []string{"admin"}}},
+	} {
+		condJSON, _ := json.Marshal(cond)
+		if got, err := ConditionMather(string(ctxJSON), string(condJSON)); err != nil || got != true {
+			t.Errorf("ConditionMather(%s) = %v, %v, want true", condJSON, got, err)
+		}
+	}
+}
+
+func TestConditionNonStringValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		context string
+		cond    Condition
+		want    bool
+	}{
+		{
+			name:    "string slice matches any element",
+			context: `{"acs:UserRole":["viewer","admin"]}`,
+			cond:    Condition{StringEquals: ConditionValue{"acs:UserRole": []string{"admin"}}},
+			want:    true,
+		},
+		{
+			name:    "string slice without match",
+			context: `{"acs:UserRole":["viewer"]}`,
+			cond:    Condition{StringEquals: ConditionValue{"acs:UserRole": []string{"admin"}}},
+			want:    false,
+		},
+		{
+			name:    "non-string slice elements are ignored",
+			context: `{"acs:UserRole":[1,"admin"]}`,
+			cond:    Condition{StringEquals: ConditionValue{"acs:UserRole": []string{"admin"}}},
+			want:    true,
+		},
+		{
+			name:    "number with StringEquals",
+			context: `{"acs:UserRole":1}`,
+			cond:    Condition{StringEquals: ConditionValue{"acs:UserRole": []string{"1"}}},
+			want:    false,
+		},
+		{
+			name:    "number with StringNotEquals",
+			context: `{"acs:UserRole":1}`,
+			cond:    Condition{StringNotEquals: ConditionValue{"acs:UserRole": []string{"admin"}}},
+			want:    false,
+		},
+		{
+			name:    "number with StringEqualsIfExists",
+			context: `{"acs:UserRole":1}`,
+			cond:    Condition{StringEquals + IfExists: ConditionValue{"acs:UserRole": []string{"1"}}},
+			want:    false,
+		},
+		{
+			name:    "bool with DateLessThan",
+			context: `{"inf:CurrentTime":true}`,
+			cond:    Condition{DateLessThan: ConditionValue{"inf:CurrentTime": []string{"2024-01-12T07:00:00Z"}}},
+			want:    false,
+		},
+		{
+			name:    "date slice matches any element",
+			context: `{"inf:CurrentTime":["2025-01-01T00:00:00Z","2024-01-01T00:00:00Z"]}`,
+			cond:    Condition{DateLessThan: ConditionValue{"inf:CurrentTime": []string{"2024-01-12T07:00:00Z"}}},
+			want:    true,
+		},
+		{
+			name:    "number with IPAddress",
+			context: `{"inf:SourceIP":3232235786}`,
+			cond:    Condition{IPAddress: ConditionValue{"inf:SourceIP": []string{"192.168.0.0/16"}}},
+			want:    false,
+		},
+		{
+			name:    "number with NotIPAddress",
+			context: `{"inf:SourceIP":3232235786}`,
+			cond:    Condition{NotIPAddress: ConditionValue{"inf:SourceIP": []string{"10.0.0.0/8"}}},
+			want:    false,
+		},
+		{
+			name:    "number with ArnLike",
+			context: `{"acs:SourceArn":123}`,
+			cond:    Condition{ArnLike: ConditionValue{"acs:SourceArn": []string{"*"}}},
+			want:    false,
+		},
+		{
+			name:    "number with ArnNotLike",
+			context: `{"acs:SourceArn":123}`,
+			cond:    Condition{ArnNotLike: ConditionValue{"acs:SourceArn": []string{"arn:acs:ecs:*:123:*"}}},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condJSON, _ := json.Marshal(tt.cond)
+			got, err := ConditionMather(tt.context, string(condJSON))
+			if err != nil || got != tt.want {
+				t.Errorf("ConditionMather() = %v, %v, want %v", got, err, tt.want)
+			}
+
+			var ctx ConditionContext
+			if err := json.Unmarshal([]byte(tt.context), &ctx); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			compiled, err := CompileCondition(tt.cond)
+			if err != nil {
+				t.Fatalf("CompileCondition() error = %v", err)
+			}
+			if got := compiled.Evaluate(ctx); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 直接设置的整数 IP 不应导致 panic
+	compiled, err := CompileCondition(Condition{IPAddress: ConditionValue{"inf:SourceIP": []string{"192.168.0.0/16"}}})
+	if err != nil {
+		t.Fatalf("CompileCondition() error = %v", err)
+	}
+	if compiled.Evaluate(ConditionContext{"inf:SourceIP": 3232235786}) {
+		t.Error("Evaluate() with int source IP = true, want false")
+	}
+}
+
+func TestConditionMatherExplain(t *testing.T) {
+	ctx := ConditionContext{
+		"acs:SourceIp": "10.0.0.1",
+		"acs:UserRole": "viewer",
+	}
+	tests := []struct {
+		name      string
+		condition Condition
+		want      bool
+		reasons   []string
+	}{
+		{
+			name: "全部满足",
+			condition: Condition{
+				IPAddress:    ConditionValue{"acs:SourceIp": []string{"10.0.0.0/8"}},
+				StringEquals: ConditionValue{"acs:UserRole": []string{"viewer"}},
+			},
+			want: true,
+		},
+		{
+			name: "多个条件中的一个不满足",
+			condition: Condition{
+				IPAddress:    ConditionValue{"acs:SourceIp": []string{"10.0.0.0/8"}},
+				StringEquals: ConditionValue{"acs:UserRole": []string{"admin"}},
+			},
+			reasons: []string{"StringEquals acs:UserRole did not match"},
+		},
+		{
+			name: "IfExists 键不存在",
+			condition: Condition{
+				StringEquals + IfExists: ConditionValue{"acs:Tenant": []string{"t1"}, "acs:UserRole": []string{"viewer"}},
+			},
+			want: true,
+		},
+		{
+			name: "多个原因",
+			condition: Condition{
+				IPAddress:    ConditionValue{"acs:SourceIp": []string{"192.168.0.0/16"}},
+				StringEquals: ConditionValue{"acs:UserRole": []string{"viewer"}, "acs:Tenant": []string{"t1"}},
+				Null:         ConditionValue{"acs:UserRole": []string{"true"}},
+				"Unknown":    ConditionValue{"acs:UserRole": []string{"viewer"}},
+			},
+			reasons: []string{
+				"IPAddress acs:SourceIp did not match",
+				"Null acs:UserRole did not match",
+				"key acs:Tenant not present",
+				"unknown condition operator Unknown",
+			},
+		},
+	}
+	ctxJSON, _ := json.Marshal(ctx)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condJSON, _ := json.Marshal(tt.condition)
+			got, reasons, err := ConditionMatherExplain(string(ctxJSON), string(condJSON))
+			if err != nil {
+				t.Fatalf("ConditionMatherExplain() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConditionMatherExplain() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(reasons, tt.reasons) {
+				t.Errorf("reasons = %q, want %q", reasons, tt.reasons)
+			}
+
+			// 结果与 ConditionMather 一致
+			want, _ := ConditionMather(string(ctxJSON), string(condJSON))
+			if got != want.(bool) {
+				t.Errorf("ConditionMatherExplain() = %v, ConditionMather() = %v", got, want)
+			}
+		})
+	}
+
+	if _, _, err := ConditionMatherExplain("{invalid json}", `{"StringEquals":{"key":["value"]}}`); err == nil {
+		t.Error("ConditionMatherExplain() should return error for invalid JSON")
+	}
+}
@@ -200,6 +200,11 @@ func CompileRegex(tpl string, delimiterStart, delimiterEnd byte) (*regexp2.Regex
 //   - "*" matches any string
 //   - "ecs:*:instance/*" matches "ecs:cn-hangzhou:instance/i-001", etc.
 func CompileWildcardRegex(pattern string) (*regexp2.Regexp, error) {
+	return compileWildcardRegex(pattern, false)
+}
+
+// compileWildcardRegex is CompileWildcardRegex, optionally treating ? as a single character wildcard.
+func compileWildcardRegex(pattern string, single bool) (*regexp2.Regexp, error) {
 	// Escape all regex special characters except *
 	var buf bytes.Buffer
 	buf.WriteByte('^')
@@ -209,6 +214,8 @@ func CompileWildcardRegex(pattern string) (*regexp2.Regexp, error) {
 		if c == '*' {
 			// Convert * to .*
 			buf.WriteString(".*")
+		} else if c == '?' && single {
+			buf.WriteByte('.')
 		} else {
 			// Quote special regex characters
 			buf.WriteString(regexp.QuoteMeta(string(c)))
@@ -1,5 +1,20 @@
 package policy
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+var ErrInvalidPolicy = errors.New("invalid policy")
+
 type Principal struct {
 	IAM       []string `json:"IAM,omitempty"`
 	Service   []string `json:"Service,omitempty"`
@@ -15,6 +30,69 @@ type PolicyStatement struct {
 	Conditions Condition  `json:"conditions,omitempty"`
 }
 
+// Validate 校验策略语句：effect 必须为 allow 或 deny（不区分大小写），
+// actions 和 resources 不能为空，conditions 只能使用已知的操作符
+func (s *PolicyStatement) Validate() error {
+	if !strings.EqualFold(s.Effect, EffectAllow) && !strings.EqualFold(s.Effect, EffectDeny) {
+		return fmt.Errorf("%w: unknown effect %q", ErrInvalidPolicy, s.Effect)
+	}
+	if len(s.Actions) == 0 {
+		return fmt.Errorf("%w: actions is required", ErrInvalidPolicy)
+	}
+	if len(s.Resources) == 0 {
+		return fmt.Errorf("%w: resources is required", ErrInvalidPolicy)
+	}
+	for op := range s.Conditions {
+		if _, ok := lookupConditionOperator(op); !ok {
+			return fmt.Errorf("%w: unknown condition operator %q", ErrInvalidPolicy, op)
+		}
+	}
+	return nil
+}
+
+// Policy 策略文档
+//
+//	{
+//		"version": "1",
+//		"statements": [...]
+//	}
+type Policy struct {
+	Version    string            `json:"version,omitempty"`
+	Statements []PolicyStatement `json:"statements"`
+}
+
+// ParsePolicy 解析 JSON 策略文档，支持 Policy 格式和 PolicyStatement 数组格式，
+// 并校验每条策略语句
+func ParsePolicy(data []byte) ([]PolicyStatement, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty document", ErrInvalidPolicy)
+	}
+
+	var statements []PolicyStatement
+	switch data[0] {
+	case '[':
+		if err := json.Unmarshal(data, &statements); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+		}
+	case '{':
+		var p Policy
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+		}
+		statements = p.Statements
+	default:
+		return nil, fmt.Errorf("%w: document must be a JSON object or array", ErrInvalidPolicy)
+	}
+
+	for i := range statements {
+		if err := statements[i].Validate(); err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i, err)
+		}
+	}
+	return statements, nil
+}
+
 /*
 Conditions: {
 	IpAddress: {
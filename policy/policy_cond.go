@@ -1,10 +1,15 @@
 package policy
 
 import (
+	"math"
 	"net"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/dlclark/regexp2"
 )
 
 const (
@@ -33,6 +38,12 @@ const (
 
 	IPAddress    = "IPAddress"
 	NotIPAddress = "NotIPAddress"
+
+	ArnLike    = "ArnLike"
+	ArnNotLike = "ArnNotLike"
+
+	// Null 检查条件键是否存在，值为 "true" 表示键必须不存在，"false" 表示键必须存在
+	Null = "Null"
 )
 
 type ConditionOperatorFunc func(param1, param2 interface{}) bool
@@ -59,6 +70,33 @@ var conditionOperatorFuncMap = map[string]ConditionOperatorFunc{
 	Bool:                      BoolFunc,
 	IPAddress:                 IPAddressFunc,
 	NotIPAddress:              NotIPAddressFunc,
+	ArnLike:                   ArnLikeFunc,
+	ArnNotLike:                ArnNotLikeFunc,
+	Null:                      NullFunc,
+}
+
+// IfExists 操作符后缀，如 StringEqualsIfExists，上下文中没有条件键时条件视为满足，Null 不支持该后缀
+const IfExists = "IfExists"
+
+// conditionOperator 解析后的条件操作符
+type conditionOperator struct {
+	// name 去掉 IfExists 后缀的操作符名称
+	name     string
+	fn       ConditionOperatorFunc
+	ifExists bool
+}
+
+// lookupConditionOperator 查找操作符，支持 IfExists 后缀
+func lookupConditionOperator(op string) (conditionOperator, bool) {
+	if fn, ok := conditionOperatorFuncMap[op]; ok {
+		return conditionOperator{name: op, fn: fn}, true
+	}
+	name, found := strings.CutSuffix(op, IfExists)
+	if !found || name == Null {
+		return conditionOperator{}, false
+	}
+	fn, ok := conditionOperatorFuncMap[name]
+	return conditionOperator{name: name, fn: fn, ifExists: true}, ok
 }
 
 // 泛型辅助函数：对列表中的任意元素进行匹配
@@ -121,201 +159,401 @@ func greaterThanEquals[T Ordered](value T, values []T) bool {
 	})
 }
 
+// matchStrings 对字符串类的请求值求值，请求值可以是 string、[]string 或 JSON 解码得到的 []interface{}，
+// 多值时任一值满足 match 即匹配，其他类型的请求值和非字符串元素不匹配
+func matchStrings(param1 interface{}, match func(value string) bool) bool {
+	switch v := param1.(type) {
+	case string:
+		return match(v)
+	case []string:
+		return slices.ContainsFunc(v, match)
+	case []interface{}:
+		for _, e := range v {
+			if s, ok := e.(string); ok && match(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // 字符串比较函数
 func StringEqualsFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return equals(value, values)
+	return matchStrings(param1, func(value string) bool {
+		return equals(value, values)
+	})
 }
 
 func StringNotEqualsFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return notEquals(value, values)
+	return matchStrings(param1, func(value string) bool {
+		return notEquals(value, values)
+	})
 }
 
 func StringEqualsIgnoreCaseFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return anyMatch(value, values, func(a, b string) bool {
-		return strings.EqualFold(a, b)
+	return matchStrings(param1, func(value string) bool {
+		return anyMatch(value, values, func(a, b string) bool {
+			return strings.EqualFold(a, b)
+		})
 	})
 }
 
 func StringNotEqualsIgnoreCaseFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return anyMatch(value, values, func(a, b string) bool {
-		return !strings.EqualFold(a, b)
+	return matchStrings(param1, func(value string) bool {
+		return anyMatch(value, values, func(a, b string) bool {
+			return !strings.EqualFold(a, b)
+		})
 	})
 }
 
 func StringLikeFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return anyMatch(value, values, func(a, b string) bool {
-		return strings.Contains(a, b)
+	return matchStrings(param1, func(value string) bool {
+		return anyMatch(value, values, func(a, b string) bool {
+			return strings.Contains(a, b)
+		})
 	})
 }
 
 func StringNotLikeFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return anyMatch(value, values, func(a, b string) bool {
-		return !strings.Contains(a, b)
+	return matchStrings(param1, func(value string) bool {
+		return anyMatch(value, values, func(a, b string) bool {
+			return !strings.Contains(a, b)
+		})
 	})
 }
 
-// 数值比较函数
+// 数值比较函数，请求值可以是整数或数字字符串，条件值可以是 []int 或 []string，
+// 请求值无法解析时不匹配，无法解析的条件值被忽略
 func NumericEqualsFunc(param1, param2 interface{}) bool {
-	value := param1.(int)
-	values := param2.([]int)
-	return equals(value, values)
+	value, values, ok := numericParams(param1, param2)
+	return ok && equals(value, values)
 }
 
 func NumericNotEqualsFunc(param1, param2 interface{}) bool {
-	value := param1.(int)
-	values := param2.([]int)
-	return notEquals(value, values)
+	value, values, ok := numericParams(param1, param2)
+	return ok && notEquals(value, values)
 }
 
 func NumericLessThanFunc(param1, param2 interface{}) bool {
-	value := param1.(int)
-	values := param2.([]int)
-	return lessThan(value, values)
+	value, values, ok := numericParams(param1, param2)
+	return ok && lessThan(value, values)
 }
 
 func NumericLessThanEqualsFunc(param1, param2 interface{}) bool {
-	value := param1.(int)
-	values := param2.([]int)
-	return lessThanEquals(value, values)
+	value, values, ok := numericParams(param1, param2)
+	return ok && lessThanEquals(value, values)
 }
 
 func NumericGreaterThanFunc(param1, param2 interface{}) bool {
-	value := param1.(int)
-	values := param2.([]int)
-	return greaterThan(value, values)
+	value, values, ok := numericParams(param1, param2)
+	return ok && greaterThan(value, values)
 }
 
 func NumericGreaterThanEqualsFunc(param1, param2 interface{}) bool {
-	value := param1.(int)
-	values := param2.([]int)
-	return greaterThanEquals(value, values)
+	value, values, ok := numericParams(param1, param2)
+	return ok && greaterThanEquals(value, values)
 }
 
 // 日期比较函数
 func DateEqualsFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return anyMatch(value, values, func(a, b string) bool {
-		aTime, _ := time.Parse(time.RFC3339, a)
-		bTime, _ := time.Parse(time.RFC3339, b)
-		return aTime.Equal(bTime)
+	return matchStrings(param1, func(value string) bool {
+		return anyMatch(value, values, func(a, b string) bool {
+			aTime, _ := time.Parse(time.RFC3339, a)
+			bTime, _ := time.Parse(time.RFC3339, b)
+			return aTime.Equal(bTime)
+		})
 	})
 }
 
 func DateNotEqualsFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return anyMatch(value, values, func(a, b string) bool {
-		aTime, _ := time.Parse(time.RFC3339, a)
-		bTime, _ := time.Parse(time.RFC3339, b)
-		return !aTime.Equal(bTime)
+	return matchStrings(param1, func(value string) bool {
+		return anyMatch(value, values, func(a, b string) bool {
+			aTime, _ := time.Parse(time.RFC3339, a)
+			bTime, _ := time.Parse(time.RFC3339, b)
+			return !aTime.Equal(bTime)
+		})
 	})
 }
 
 func DateLessThanFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return anyMatch(value, values, func(a, b string) bool {
-		aTime, _ := time.Parse(time.RFC3339, a)
-		bTime, _ := time.Parse(time.RFC3339, b)
-		return aTime.Before(bTime)
+	return matchStrings(param1, func(value string) bool {
+		return anyMatch(value, values, func(a, b string) bool {
+			aTime, _ := time.Parse(time.RFC3339, a)
+			bTime, _ := time.Parse(time.RFC3339, b)
+			return aTime.Before(bTime)
+		})
 	})
 }
 
 func DateLessThanEqualsFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return anyMatch(value, values, func(a, b string) bool {
-		aTime, _ := time.Parse(time.RFC3339, a)
-		bTime, _ := time.Parse(time.RFC3339, b)
-		return aTime.Before(bTime) || aTime.Equal(bTime)
+	return matchStrings(param1, func(value string) bool {
+		return anyMatch(value, values, func(a, b string) bool {
+			aTime, _ := time.Parse(time.RFC3339, a)
+			bTime, _ := time.Parse(time.RFC3339, b)
+			return aTime.Before(bTime) || aTime.Equal(bTime)
+		})
 	})
 }
 
 func DateGreaterThanFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return anyMatch(value, values, func(a, b string) bool {
-		aTime, _ := time.Parse(time.RFC3339, a)
-		bTime, _ := time.Parse(time.RFC3339, b)
-		return aTime.After(bTime)
+	return matchStrings(param1, func(value string) bool {
+		return anyMatch(value, values, func(a, b string) bool {
+			aTime, _ := time.Parse(time.RFC3339, a)
+			bTime, _ := time.Parse(time.RFC3339, b)
+			return aTime.After(bTime)
+		})
 	})
 }
 
 func DateGreaterThanEqualsFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
-	return anyMatch(value, values, func(a, b string) bool {
-		aTime, _ := time.Parse(time.RFC3339, a)
-		bTime, _ := time.Parse(time.RFC3339, b)
-		return aTime.After(bTime) || aTime.Equal(bTime)
+	return matchStrings(param1, func(value string) bool {
+		return anyMatch(value, values, func(a, b string) bool {
+			aTime, _ := time.Parse(time.RFC3339, a)
+			bTime, _ := time.Parse(time.RFC3339, b)
+			return aTime.After(bTime) || aTime.Equal(bTime)
+		})
 	})
 }
 
-// 布尔值比较函数
+// 布尔值比较函数，请求值可以是 bool 或 "true"/"false"，条件值可以是 []bool 或 []string，
+// 请求值无法解析时不匹配，无法解析的条件值被忽略
 func BoolFunc(param1, param2 interface{}) bool {
-	value := param1.(bool)
-	values := param2.([]bool)
-	return equals(value, values)
+	value, ok := toBool(param1)
+	if !ok {
+		return false
+	}
+	return equals(value, parseBools(param2))
 }
 
-// IP 地址比较函数
-func IPAddressFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
-	values := param2.([]string)
+// numericParams 将请求值和条件值转换为整数
+func numericParams(param1, param2 interface{}) (int, []int, bool) {
+	value, ok := toInt(param1)
+	if !ok {
+		return 0, nil, false
+	}
+	return value, parseInts(param2), true
+}
+
+// toInt 转换请求值，JSON 解码的数字为 float64，只接受整数值
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, false
+		}
+		return int(n), true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
 
-	requestIP := net.ParseIP(value)
-	if requestIP == nil {
-		return false
+func parseInts(values interface{}) []int {
+	switch v := values.(type) {
+	case []int:
+		return v
+	case []string:
+		ints := make([]int, 0, len(v))
+		for _, s := range v {
+			if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+				ints = append(ints, n)
+			}
+		}
+		return ints
+	default:
+		return nil
+	}
+}
+
+func toBool(v interface{}) (bool, bool) {
+	switch b := v.(type) {
+	case bool:
+		return b, true
+	case string:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(b))
+		return parsed, err == nil
+	default:
+		return false, false
+	}
+}
+
+func parseBools(values interface{}) []bool {
+	switch v := values.(type) {
+	case []bool:
+		return v
+	case []string:
+		bools := make([]bool, 0, len(v))
+		for _, s := range v {
+			if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				bools = append(bools, b)
+			}
+		}
+		return bools
+	default:
+		return nil
 	}
+}
 
-	return anyMatch(value, values, func(_, policyValue string) bool {
-		policyIP := net.ParseIP(policyValue)
-		if policyIP == nil {
-			// 尝试解析为 CIDR
-			_, policyNet, err := net.ParseCIDR(policyValue)
-			if err != nil {
-				return false
+// IP 地址比较函数，支持 IPv4、IPv6 地址和 CIDR，请求 IP 中的 IPv6 zone 会被忽略
+func IPAddressFunc(param1, param2 interface{}) bool {
+	values := param2.([]string)
+	return matchStrings(param1, func(value string) bool {
+		requestIP := parseConditionIP(value)
+		if requestIP == nil {
+			return false
+		}
+		for _, v := range values {
+			if matched, ok := parsePolicyIP(v).match(requestIP); ok && matched {
+				return true
 			}
-			return policyNet.Contains(requestIP)
 		}
-		return requestIP.Equal(policyIP)
+		return false
 	})
 }
 
 func NotIPAddressFunc(param1, param2 interface{}) bool {
-	value := param1.(string)
 	values := param2.([]string)
+	return matchStrings(param1, func(value string) bool {
+		requestIP := parseConditionIP(value)
+		if requestIP == nil {
+			return false
+		}
+		for _, v := range values {
+			if matched, ok := parsePolicyIP(v).match(requestIP); ok && !matched {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// policyIP 策略中的 IP 地址或 CIDR，都为空表示条件值无效
+type policyIP struct {
+	ip    net.IP
+	ipNet *net.IPNet
+}
 
-	requestIP := net.ParseIP(value)
-	if requestIP == nil {
+// parsePolicyIP 解析策略中的 IP 地址或 CIDR
+func parsePolicyIP(value string) policyIP {
+	if ip := parseConditionIP(value); ip != nil {
+		return policyIP{ip: ip}
+	}
+	if _, ipNet, err := net.ParseCIDR(strings.TrimSpace(value)); err == nil {
+		return policyIP{ipNet: ipNet}
+	}
+	return policyIP{}
+}
+
+// match 判断 ip 是否与策略值匹配，策略值无效时 ok 为 false
+func (p policyIP) match(ip net.IP) (matched, ok bool) {
+	switch {
+	case p.ip != nil:
+		return p.ip.Equal(ip), true
+	case p.ipNet != nil:
+		return p.ipNet.Contains(ip), true
+	default:
+		return false, false
+	}
+}
+
+// parseConditionIP 解析 IP 地址，去掉 IPv6 zone（如 fe80::1%eth0）
+func parseConditionIP(value string) net.IP {
+	value = strings.TrimSpace(value)
+	if i := strings.IndexByte(value, '%'); i >= 0 {
+		value = value[:i]
+	}
+	return net.ParseIP(value)
+}
+
+// arnComponents ARN 由冒号分隔的组件数，arn:partition:service:region:account:resource，resource 中可以包含冒号
+const arnComponents = 6
+
+// ARN 比较函数，与 AWS ArnLike 一致，六个组件分别匹配，支持 * 和 ? 通配符，通配符不跨越组件
+// 请求值不是字符串时不匹配
+func ArnLikeFunc(param1, param2 interface{}) bool {
+	arn, ok := param1.(string)
+	return ok && arnLike(arn, param2.([]string))
+}
+
+// ArnNotLikeFunc 请求 ARN 与所有条件值都不匹配时返回 true，请求值不是字符串时返回 false
+func ArnNotLikeFunc(param1, param2 interface{}) bool {
+	arn, ok := param1.(string)
+	return ok && !arnLike(arn, param2.([]string))
+}
+
+func arnLike(arn string, values []string) bool {
+	for _, v := range values {
+		if compileArnPattern(v).match(arn) {
+			return true
+		}
+	}
+	return false
+}
+
+// arnPattern 编译后的 ARN 模式，每个组件一个正则表达式，为空表示模式无效
+type arnPattern []*regexp2.Regexp
+
+func compileArnPattern(pattern string) arnPattern {
+	parts := strings.SplitN(pattern, ":", arnComponents)
+	if len(parts) != arnComponents {
+		return nil
+	}
+	p := make(arnPattern, len(parts))
+	for i, part := range parts {
+		reg, err := compileWildcardRegex(part, true)
+		if err != nil {
+			return nil
+		}
+		p[i] = reg
+	}
+	return p
+}
+
+func (p arnPattern) match(arn string) bool {
+	if p == nil {
+		return false
+	}
+	parts := strings.SplitN(arn, ":", arnComponents)
+	if len(parts) != arnComponents {
 		return false
 	}
+	for i, part := range parts {
+		if ok, err := p[i].MatchString(part); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
 
-	return anyMatch(value, values, func(_, policyValue string) bool {
-		policyIP := net.ParseIP(policyValue)
-		if policyIP == nil {
-			// 尝试解析为 CIDR
-			_, policyNet, err := net.ParseCIDR(policyValue)
-			if err != nil {
-				return false
-			}
-			return !policyNet.Contains(requestIP)
+// 键存在性检查函数，param1 为条件键是否存在
+func NullFunc(param1, param2 interface{}) bool {
+	exists := param1.(bool)
+	values := param2.([]string)
+	for _, v := range values {
+		isNull, err := strconv.ParseBool(v)
+		if err == nil && isNull != exists {
+			return true
 		}
-		return !requestIP.Equal(policyIP)
-	})
+	}
+	return false
 }
 
 type ConditionParser interface {
@@ -329,3 +567,28 @@ var ConditionKeyMap = map[string]ConditionParser{
 }
 
 type ConditionContext map[string]any
+
+// SetString 设置字符串类型的条件键，用于字符串、日期和 IP 地址操作符
+func (c ConditionContext) SetString(key, value string) {
+	c[key] = value
+}
+
+// SetTime 将时间格式化为 RFC3339 字符串后设置，用于日期操作符
+func (c ConditionContext) SetTime(key string, value time.Time) {
+	c[key] = value.Format(time.RFC3339)
+}
+
+// SetBool 设置布尔类型的条件键，用于 Bool 操作符
+func (c ConditionContext) SetBool(key string, value bool) {
+	c[key] = value
+}
+
+// SetInt 设置整数类型的条件键，用于数值操作符
+func (c ConditionContext) SetInt(key string, value int) {
+	c[key] = value
+}
+
+// SetStringSlice 设置多值条件键，保存 value 的副本，任一值满足字符串、日期或 IP 地址操作符时条件即满足
+func (c ConditionContext) SetStringSlice(key string, value []string) {
+	c[key] = append([]string(nil), value...)
+}
@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/x893675/valhalla-common/authentication/user"
+)
+
+// Decision 多条策略语句的求值结果
+type Decision int
+
+const (
+	// DecisionNotApplicable 没有匹配的策略语句，调用方应按默认拒绝处理
+	DecisionNotApplicable Decision = iota
+	// DecisionAllow 至少一条 allow 语句匹配且没有 deny 语句匹配
+	DecisionAllow
+	// DecisionDeny 至少一条 deny 语句匹配
+	DecisionDeny
+)
+
+func (d Decision) String() string {
+	switch d {
+	case DecisionAllow:
+		return "Allow"
+	case DecisionDeny:
+		return "Deny"
+	default:
+		return "NotApplicable"
+	}
+}
+
+// EvaluatePolicies 对多条策略语句求值，匹配的 deny 语句优先于 allow 语句，
+// 没有语句匹配时返回 DecisionNotApplicable
+func EvaluatePolicies(statements []PolicyStatement, action, resource string, ctx ConditionContext) (Decision, error) {
+	return evaluatePolicies(statements, nil, action, resource, ctx)
+}
+
+// EvaluatePrincipalPolicies 与 EvaluatePolicies 相同，但只对 Principal 与 requester 匹配的语句求值，
+// 匹配规则见 MatchPrincipal
+func EvaluatePrincipalPolicies(statements []PolicyStatement, requester user.Info, action, resource string, ctx ConditionContext) (Decision, error) {
+	if requester == nil {
+		return DecisionNotApplicable, fmt.Errorf("requester is required")
+	}
+	return evaluatePolicies(statements, requester, action, resource, ctx)
+}
+
+// evaluatePolicies requester 为空时不检查 Principal
+func evaluatePolicies(statements []PolicyStatement, requester user.Info, action, resource string, ctx ConditionContext) (Decision, error) {
+	decision := DecisionNotApplicable
+	for i := range statements {
+		matched, err := statements[i].matches(action, resource, ctx)
+		if err == nil && matched && requester != nil {
+			matched, err = MatchPrincipal(statements[i].Principal, requester)
+		}
+		if err != nil {
+			return DecisionNotApplicable, fmt.Errorf("statement %d: %w", i, err)
+		}
+		if !matched {
+			continue
+		}
+		if strings.EqualFold(statements[i].Effect, EffectDeny) {
+			return DecisionDeny, nil
+		}
+		if strings.EqualFold(statements[i].Effect, EffectAllow) {
+			decision = DecisionAllow
+		}
+	}
+	return decision, nil
+}
+
+// MatchPrincipal 判断请求者是否与策略语句的 Principal 匹配，Principal 为空时匹配所有请求者
+// 服务身份（UserTypeService）使用名称与 Principal.Service 匹配，如 "ecs.example.com"；
+// 其他身份使用 "类型/名称" 与 Principal.IAM 匹配，如 "user/alice"，两者都支持 * 通配符
+func MatchPrincipal(p *Principal, requester user.Info) (bool, error) {
+	if p == nil {
+		return true, nil
+	}
+	if requester == nil {
+		return false, nil
+	}
+	if requester.UserType() == user.UserTypeService {
+		return DefaultMatcher.matches(requester.GetName(), p.Service)
+	}
+	return DefaultMatcher.matches(requester.UserType().String()+"/"+requester.GetName(), p.IAM)
+}
+
+// EvaluateStatement 判断策略语句是否允许对 resource 执行 action
+// action 和 resource 分别与 Actions 和 Resources 匹配，支持 * 通配符，条件使用 ctx 求值，
+// 只有 effect 为 allow 且全部匹配时返回 true，匹配的 deny 语句返回 false
+func EvaluateStatement(stmt PolicyStatement, action, resource string, ctx ConditionContext) (bool, error) {
+	matched, err := stmt.matches(action, resource, ctx)
+	if err != nil {
+		return false, err
+	}
+	return matched && strings.EqualFold(stmt.Effect, EffectAllow), nil
+}
+
+// matches 判断策略语句的 Actions、Resources 和 Conditions 是否都与请求匹配，不考虑 effect
+func (s *PolicyStatement) matches(action, resource string, ctx ConditionContext) (bool, error) {
+	if ok, err := DefaultMatcher.matches(action, s.Actions); err != nil || !ok {
+		return false, err
+	}
+	if ok, err := DefaultMatcher.matches(resource, s.Resources); err != nil || !ok {
+		return false, err
+	}
+	if len(s.Conditions) == 0 {
+		return true, nil
+	}
+	cond, err := CompileCondition(s.Conditions)
+	if err != nil {
+		return false, err
+	}
+	return cond.Evaluate(ctx), nil
+}
@@ -0,0 +1,272 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/x893675/valhalla-common/authentication/user"
+)
+
+func TestPolicyStatementJSONRoundTrip(t *testing.T) {
+	stmt := PolicyStatement{
+		Version:   "1",
+		Effect:    EffectAllow,
+		Resources: []string{"acs:ecs:*:*:instance/*"},
+		Actions:   []string{"ecs:Describe*"},
+		Principal: &Principal{IAM: []string{"user/alice"}},
+		Conditions: Condition{
+			IPAddress: ConditionValue{"acs:SourceIp": []string{"10.0.0.0/8"}},
+		},
+	}
+
+	data, err := json.Marshal(stmt)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var got PolicyStatement
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, stmt) {
+		t.Errorf("round trip = %+v, want %+v", got, stmt)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "bare array",
+			data: `[
+				{"effect": "allow", "actions": ["ecs:*"], "resources": ["*"]},
+				{"effect": "Deny", "actions": ["ecs:Delete*"], "resources": ["*"]}
+			]`,
+			want: 2,
+		},
+		{
+			name: "wrapped document",
+			data: `{"version": "1", "statements": [
+				{"effect": "allow", "actions": ["ecs:*"], "resources": ["*"],
+				 "conditions": {"IPAddress": {"acs:SourceIp": ["10.0.0.1"]}}}
+			]}`,
+			want: 1,
+		},
+		{name: "empty array", data: `[]`, want: 0},
+		{name: "empty document", data: ``, wantErr: true},
+		{name: "malformed json", data: `[{"effect": "allow",`, wantErr: true},
+		{name: "not an object or array", data: `"allow"`, wantErr: true},
+		{name: "wrong field type", data: `{"statements": {"effect": "allow"}}`, wantErr: true},
+		{name: "unknown effect", data: `[{"effect": "maybe", "actions": ["*"], "resources": ["*"]}]`, wantErr: true},
+		{name: "missing actions", data: `[{"effect": "allow", "resources": ["*"]}]`, wantErr: true},
+		{name: "missing resources", data: `[{"effect": "allow", "actions": ["*"]}]`, wantErr: true},
+		{
+			name:    "unknown condition operator",
+			data:    `[{"effect": "allow", "actions": ["*"], "resources": ["*"], "conditions": {"Foo": {"k": ["v"]}}}]`,
+			wantErr: true,
+		},
+		{
+			name: "IfExists operator",
+			data: `[{"effect": "allow", "actions": ["*"], "resources": ["*"],
+				"conditions": {"StringEqualsIfExists": {"k": ["v"]}}}]`,
+			want: 1,
+		},
+		{
+			name:    "Null does not support IfExists",
+			data:    `[{"effect": "allow", "actions": ["*"], "resources": ["*"], "conditions": {"NullIfExists": {"k": ["true"]}}}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolicy([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPolicy) {
+					t.Errorf("ParsePolicy() error = %v, want ErrInvalidPolicy", err)
+				}
+				return
+			}
+			if len(got) != tt.want {
+				t.Errorf("ParsePolicy() returned %d statements, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateStatement(t *testing.T) {
+	allow := PolicyStatement{
+		Effect:    EffectAllow,
+		Actions:   []string{"ecs:Describe*", "ecs:StartInstance"},
+		Resources: []string{"acs:ecs:*:123:instance/*"},
+	}
+	deny := allow
+	deny.Effect = "Deny"
+	gated := allow
+	gated.Conditions = Condition{
+		IPAddress: ConditionValue{"acs:SourceIp": []string{"10.0.0.0/8"}},
+	}
+	unknown := allow
+	unknown.Conditions = Condition{"Unknown": ConditionValue{"acs:SourceIp": []string{"10.0.0.1"}}}
+
+	const resource = "acs:ecs:cn-hangzhou:123:instance/i-001"
+	inside := ConditionContext{"acs:SourceIp": "10.1.2.3"}
+	outside := ConditionContext{"acs:SourceIp": "192.168.1.1"}
+
+	tests := []struct {
+		name     string
+		stmt     PolicyStatement
+		action   string
+		resource string
+		ctx      ConditionContext
+		want     bool
+		wantErr  bool
+	}{
+		{name: "allow wildcard action", stmt: allow, action: "ecs:DescribeInstances", resource: resource, want: true},
+		{name: "allow exact action", stmt: allow, action: "ecs:StartInstance", resource: resource, want: true},
+		{name: "action not matched", stmt: allow, action: "ecs:DeleteInstance", resource: resource},
+		{name: "resource not matched", stmt: allow, action: "ecs:DescribeInstances", resource: "acs:ecs:cn-hangzhou:456:instance/i-001"},
+		{name: "explicit deny", stmt: deny, action: "ecs:DescribeInstances", resource: resource},
+		{name: "condition satisfied", stmt: gated, action: "ecs:DescribeInstances", resource: resource, ctx: inside, want: true},
+		{name: "condition not satisfied", stmt: gated, action: "ecs:DescribeInstances", resource: resource, ctx: outside},
+		{name: "condition key missing", stmt: gated, action: "ecs:DescribeInstances", resource: resource},
+		{name: "unknown operator", stmt: unknown, action: "ecs:DescribeInstances", resource: resource, ctx: inside, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateStatement(tt.stmt, tt.action, tt.resource, tt.ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvaluateStatement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateStatement() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluatePolicies(t *testing.T) {
+	statements := []PolicyStatement{
+		{Effect: EffectAllow, Actions: []string{"ecs:*"}, Resources: []string{"*"}},
+		{Effect: EffectDeny, Actions: []string{"ecs:Delete*"}, Resources: []string{"acs:ecs:*:*:instance/prod-*"}},
+		{
+			Effect:     EffectAllow,
+			Actions:    []string{"oss:GetObject"},
+			Resources:  []string{"*"},
+			Conditions: Condition{IPAddress: ConditionValue{"acs:SourceIp": []string{"10.0.0.0/8"}}},
+		},
+	}
+	ctx := ConditionContext{"acs:SourceIp": "192.168.1.1"}
+
+	tests := []struct {
+		name     string
+		action   string
+		resource string
+		want     Decision
+	}{
+		{name: "allow", action: "ecs:DescribeInstances", resource: "acs:ecs:cn:1:instance/prod-1", want: DecisionAllow},
+		{name: "deny overrides allow", action: "ecs:DeleteInstance", resource: "acs:ecs:cn:1:instance/prod-1", want: DecisionDeny},
+		{name: "deny does not match", action: "ecs:DeleteInstance", resource: "acs:ecs:cn:1:instance/dev-1", want: DecisionAllow},
+		{name: "condition not satisfied", action: "oss:GetObject", resource: "acs:oss:bucket/a", want: DecisionNotApplicable},
+		{name: "no statement matches", action: "rds:CreateDB", resource: "acs:rds:cn:1:db/a", want: DecisionNotApplicable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluatePolicies(statements, tt.action, tt.resource, ctx)
+			if err != nil {
+				t.Fatalf("EvaluatePolicies() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluatePolicies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// deny 语句在 allow 语句之前时结果相同
+	reversed := []PolicyStatement{statements[1], statements[0]}
+	if got, _ := EvaluatePolicies(reversed, "ecs:DeleteInstance", "acs:ecs:cn:1:instance/prod-1", ctx); got != DecisionDeny {
+		t.Errorf("EvaluatePolicies() with deny first = %v, want Deny", got)
+	}
+	if got, _ := EvaluatePolicies(nil, "ecs:DescribeInstances", "*", ctx); got != DecisionNotApplicable {
+		t.Errorf("EvaluatePolicies() without statements = %v, want NotApplicable", got)
+	}
+
+	invalid := []PolicyStatement{{Effect: EffectAllow, Actions: []string{"*"}, Resources: []string{"*"}, Conditions: Condition{"Unknown": nil}}}
+	if _, err := EvaluatePolicies(invalid, "ecs:DescribeInstances", "*", ctx); err == nil {
+		t.Error("EvaluatePolicies() with an unknown operator should fail")
+	}
+}
+
+func TestMatchPrincipal(t *testing.T) {
+	principal := &Principal{
+		IAM:     []string{"user/alice", "admin/*"},
+		Service: []string{"ecs.example.com", "*.internal.example.com"},
+	}
+	tests := []struct {
+		name      string
+		principal *Principal
+		requester user.Info
+		want      bool
+	}{
+		{name: "service matches", principal: principal, requester: &user.DefaultInfo{Type: user.UserTypeService, Name: "ecs.example.com"}, want: true},
+		{name: "service wildcard", principal: principal, requester: &user.DefaultInfo{Type: user.UserTypeService, Name: "billing.internal.example.com"}, want: true},
+		{name: "service not listed", principal: principal, requester: &user.DefaultInfo{Type: user.UserTypeService, Name: "oss.example.com"}},
+		{name: "service name is not an IAM principal", principal: &Principal{IAM: []string{"*"}}, requester: &user.DefaultInfo{Type: user.UserTypeService, Name: "ecs.example.com"}},
+		{name: "user matches", principal: principal, requester: &user.DefaultInfo{Type: user.UserTypeUser, Name: "alice"}, want: true},
+		{name: "admin wildcard", principal: principal, requester: &user.DefaultInfo{Type: user.UserTypeAdmin, Name: "root"}, want: true},
+		{name: "user named like a service", principal: principal, requester: &user.DefaultInfo{Type: user.UserTypeUser, Name: "ecs.example.com"}},
+		{name: "user not listed", principal: principal, requester: &user.DefaultInfo{Type: user.UserTypeUser, Name: "bob"}},
+		{name: "no principal", requester: &user.DefaultInfo{Type: user.UserTypeUser, Name: "bob"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MatchPrincipal(tt.principal, tt.requester)
+			if err != nil {
+				t.Fatalf("MatchPrincipal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MatchPrincipal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluatePrincipalPolicies(t *testing.T) {
+	statements := []PolicyStatement{
+		{Effect: EffectAllow, Actions: []string{"sts:AssumeRole"}, Resources: []string{"*"}, Principal: &Principal{Service: []string{"ecs.example.com"}}},
+		{Effect: EffectAllow, Actions: []string{"ecs:*"}, Resources: []string{"*"}, Principal: &Principal{IAM: []string{"user/alice"}}},
+	}
+	service := &user.DefaultInfo{Type: user.UserTypeService, Name: "ecs.example.com"}
+	alice := &user.DefaultInfo{Type: user.UserTypeUser, Name: "alice"}
+
+	tests := []struct {
+		name      string
+		requester user.Info
+		action    string
+		want      Decision
+	}{
+		{name: "service assumes role", requester: service, action: "sts:AssumeRole", want: DecisionAllow},
+		{name: "user cannot assume role", requester: alice, action: "sts:AssumeRole", want: DecisionNotApplicable},
+		{name: "user allowed", requester: alice, action: "ecs:DescribeInstances", want: DecisionAllow},
+		{name: "service not an IAM principal", requester: service, action: "ecs:DescribeInstances", want: DecisionNotApplicable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluatePrincipalPolicies(statements, tt.requester, tt.action, "acs:ecs:cn:1:instance/i-1", nil)
+			if err != nil {
+				t.Fatalf("EvaluatePrincipalPolicies() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluatePrincipalPolicies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package signer
+
+import (
+	"net/http"
+	"time"
+)
+
+// VerifyMiddleware 返回 AK/SK 签名校验中间件，AccessKey 的 AccessSecret 从 store 中查询，
+// store 实现了 CredentialStatusStore 时会先拒绝已禁用或已过期的 AccessKey，
+// 签名缺失、AccessKey 不存在、已失效或签名错误时响应 401
+// 需要校验时间戳、Nonce 或请求头时使用 NewVerifier 创建的 Verifier.Middleware
+func VerifyMiddleware(store CredentialStore) func(http.Handler) http.Handler {
+	v := &Verifier{now: time.Now}
+	return v.Middleware(store)
+}
@@ -0,0 +1,229 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/x893675/valhalla-common/cache"
+	"github.com/x893675/valhalla-common/constant"
+	"github.com/x893675/valhalla-common/errdetails"
+	"github.com/x893675/valhalla-common/logger"
+)
+
+var (
+	// ErrSignatureExpired 签名时间戳超出 Options.MaxAge 允许的范围
+	ErrSignatureExpired = errors.New("signature expired")
+	// ErrNonceReused 签名 Nonce 已被使用，请求可能被重放
+	ErrNonceReused = errors.New("signature nonce already used")
+	// ErrAlgorithmNotAllowed 请求的签名算法与 Options.Algorithm 不一致
+	ErrAlgorithmNotAllowed = errors.New("signature algorithm not allowed")
+)
+
+// NonceStore 记录已使用的签名 Nonce，用于拒绝重放的请求
+type NonceStore interface {
+	// UseNonce 标记 accessKey 的 nonce 已使用，在 ttl 内重复使用时返回 false
+	UseNonce(ctx context.Context, accessKey, nonce string, ttl time.Duration) (bool, error)
+}
+
+var _ NonceStore = (*CacheNonceStore)(nil)
+
+// CacheNonceStore 基于 cache.Interface 的 NonceStore，
+// Nonce 以 constant.SignerNonceCacheKeyFormat 为 key 存储
+type CacheNonceStore struct {
+	cache cache.Interface
+}
+
+// NewCacheNonceStore 创建基于 cache.Interface 的 NonceStore
+func NewCacheNonceStore(c cache.Interface) *CacheNonceStore {
+	return &CacheNonceStore{cache: c}
+}
+
+func (s *CacheNonceStore) UseNonce(ctx context.Context, accessKey, nonce string, ttl time.Duration) (bool, error) {
+	return s.cache.SetNX(ctx, fmt.Sprintf(constant.SignerNonceCacheKeyFormat, accessKey, nonce), 1, ttl)
+}
+
+// Options 签名和验签的配置，签名双方需要使用相同的 Region、Service 和 SignedHeaders
+type Options struct {
+	// Algorithm 签名算法，需要已通过 Register 注册，为空时使用 HMAC-SHA256；
+	// 验签时为空表示接受所有已注册的算法，否则只接受该算法
+	Algorithm string
+	// MaxAge 验签时允许的签名时间戳与当前时间的最大偏差，为 0 时不校验
+	MaxAge time.Duration
+	// SignedHeaders 参与签名的请求头，不区分大小写
+	SignedHeaders []string
+	// Region 和 Service 签名密钥的作用域，见 Credential.WithScope
+	Region  string
+	Service string
+	// NonceStore 验签时记录已使用的 Nonce，为空时不校验重放；设置时 MaxAge 必须大于 0
+	NonceStore NonceStore
+}
+
+// Validate 校验配置是否有效
+func (o Options) Validate() error {
+	if o.Algorithm != "" {
+		if _, ok := Load(o.Algorithm); !ok {
+			return fmt.Errorf("unsupport signature algorithm: %s", o.Algorithm)
+		}
+	}
+	if o.MaxAge < 0 {
+		return fmt.Errorf("max age must not be negative: %s", o.MaxAge)
+	}
+	if o.NonceStore != nil && o.MaxAge == 0 {
+		return errors.New("max age is required when nonce store is set")
+	}
+	for _, h := range o.SignedHeaders {
+		if strings.TrimSpace(h) == "" {
+			return errors.New("signed header name must not be empty")
+		}
+	}
+	return nil
+}
+
+// apply 将签名作用域和请求头设置到 Credential
+func (o Options) apply(a *Credential) *Credential {
+	a.SignedHeaders = o.SignedHeaders
+	return a.WithScope(o.Region, o.Service)
+}
+
+// Signer 使用 Options 对请求签名，可并发使用
+type Signer struct {
+	accessKey    string
+	accessSecret string
+	opts         Options
+}
+
+// NewSigner 使用 AccessKey、AccessSecret 和 Options 创建 Signer
+func NewSigner(accessKey, accessSecret string, opts Options) (*Signer, error) {
+	if accessKey == "" || accessSecret == "" {
+		return nil, errors.New("access key and secret are required")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return &Signer{accessKey: accessKey, accessSecret: accessSecret, opts: opts}, nil
+}
+
+// Sign 使用新的时间戳和 Nonce 对请求签名
+func (s *Signer) Sign(req *http.Request) error {
+	return s.opts.apply(NewAccessKeyAuth(s.accessKey, s.accessSecret, s.opts.Algorithm)).SignRequest(req)
+}
+
+// Verifier 使用 Options 校验签名请求，可并发使用
+type Verifier struct {
+	opts Options
+	now  func() time.Time
+}
+
+// NewVerifier 使用 Options 创建 Verifier
+func NewVerifier(opts Options) (*Verifier, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return &Verifier{opts: opts, now: time.Now}, nil
+}
+
+// Verify 使用 accessSecret 校验请求的签名，成功时返回请求中的凭证
+func (v *Verifier) Verify(req *http.Request, accessSecret string) (*Credential, error) {
+	cred, err := v.parse(req)
+	if err != nil {
+		return nil, err
+	}
+	cred.AccessSecret = accessSecret
+	if err := v.check(req, cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// parse 解析请求中的凭证，并校验签名算法和时间戳
+func (v *Verifier) parse(req *http.Request) (*Credential, error) {
+	cred, err := NewAccessKeyAuthRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if v.opts.Algorithm != "" && cred.SignatureAlgorithm != v.opts.Algorithm {
+		return nil, fmt.Errorf("%w: %s", ErrAlgorithmNotAllowed, cred.SignatureAlgorithm)
+	}
+	if v.opts.MaxAge > 0 {
+		age := v.now().Sub(cred.TimestampTime)
+		if age > v.opts.MaxAge || age < -v.opts.MaxAge {
+			return nil, ErrSignatureExpired
+		}
+	}
+	return v.opts.apply(cred), nil
+}
+
+// check 校验签名，签名正确后记录 Nonce
+func (v *Verifier) check(req *http.Request, cred *Credential) error {
+	if err := cred.CheckSignature(req); err != nil {
+		return err
+	}
+	if v.opts.NonceStore == nil {
+		return nil
+	}
+	// 时间戳允许前后偏差 MaxAge，Nonce 需要保留到签名过期
+	ok, err := v.opts.NonceStore.UseNonce(req.Context(), cred.AccessKey, cred.SignatureNonce, 2*v.opts.MaxAge)
+	if err != nil {
+		return fmt.Errorf("failed to record signature nonce: %w", err)
+	}
+	if !ok {
+		return ErrNonceReused
+	}
+	return nil
+}
+
+// Middleware 返回 AK/SK 签名校验中间件，行为与 VerifyMiddleware 一致，并按 Options 校验签名
+func (v *Verifier) Middleware(store CredentialStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			cred, err := v.parse(req)
+			if err != nil {
+				logger.Debugf("invalid signed request: %s", err)
+				errdetails.WriteError(w, errdetails.Unauthorized("%s", err))
+				return
+			}
+			secret, ok, err := store.Secret(req.Context(), cred.AccessKey)
+			if err != nil {
+				logger.Errorf("failed to get secret of access key %s: %s", cred.AccessKey, err)
+				errdetails.WriteError(w, errdetails.Unauthorized("unauthorized"))
+				return
+			}
+			if !ok {
+				errdetails.WriteError(w, errdetails.Unauthorized("access key not found"))
+				return
+			}
+			if statusStore, ok := store.(CredentialStatusStore); ok {
+				active, expiresAt, err := statusStore.Status(req.Context(), cred.AccessKey)
+				if err != nil {
+					logger.Errorf("failed to get status of access key %s: %s", cred.AccessKey, err)
+					errdetails.WriteError(w, errdetails.Unauthorized("unauthorized"))
+					return
+				}
+				if !active || (!expiresAt.IsZero() && !v.now().Before(expiresAt)) {
+					errdetails.WriteError(w, errdetails.Unauthorized("access key is inactive or expired"))
+					return
+				}
+			}
+			cred.AccessSecret = secret
+			if err := v.check(req, cred); err != nil {
+				switch {
+				case errors.Is(err, ErrSignatureMismatch):
+					logger.Info("signature check failed", cred.AuditFields()...)
+					errdetails.WriteError(w, errdetails.Unauthorized("signature check failed"))
+				case errors.Is(err, ErrNonceReused):
+					logger.Info("signature nonce reused", cred.AuditFields()...)
+					errdetails.WriteError(w, errdetails.Unauthorized("%s", err))
+				default:
+					logger.Errorf("failed to verify access key %s: %s", cred.AccessKey, err)
+					errdetails.WriteError(w, errdetails.Unauthorized("unauthorized"))
+				}
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
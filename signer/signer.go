@@ -15,6 +15,9 @@ import (
 	"strings"
 	"time"
 
+	"go.uber.org/zap"
+
+	"github.com/x893675/valhalla-common/logger"
 	"github.com/x893675/valhalla-common/utils/random"
 )
 
@@ -34,6 +37,8 @@ var (
 
 var (
 	ErrExist = errors.New("algorithms already exist")
+	// ErrSignatureMismatch 签名校验失败，错误信息中不包含签名值
+	ErrSignatureMismatch = errors.New("ak/sk signature check failed")
 )
 
 func defaultSignatureAlgorithms() signatureAlgorithms {
@@ -85,6 +90,12 @@ type Credential struct {
 	AccessSecret       string    `json:"accessSecret"`
 	TimestampTime      time.Time `json:"time"`
 	AlgorithmFn        SignatureAlgorithmFn
+	// Region 和 Service 组成签名密钥的作用域，类似 AWS SigV4 的 date/region/service/request，
+	// 签名双方需要使用相同的作用域；都为空时不参与签名
+	Region  string `json:"region,omitempty"`
+	Service string `json:"service,omitempty"`
+	// SignedHeaders 参与签名的请求头，签名双方需要使用相同的请求头列表；为空时请求头不参与签名
+	SignedHeaders []string `json:"signedHeaders,omitempty"`
 }
 
 var lf = []byte{'\n'}
@@ -143,6 +154,23 @@ func writeBody(fn SignatureAlgorithmFn, r *http.Request, requestData io.StringWr
 	_, _ = requestData.WriteString(hex.EncodeToString(gHash(fn(), b)))
 }
 
+// writeHeaders 按名称排序写入参与签名的请求头，每行格式为 name:value，host 取自 r.Host
+func writeHeaders(r *http.Request, headers []string, requestData io.Writer) {
+	names := make([]string, 0, len(headers))
+	for _, h := range headers {
+		names = append(names, strings.ToLower(h))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := r.Host
+		if name != "host" {
+			value = strings.Join(r.Header.Values(name), ",")
+		}
+		_, _ = requestData.Write([]byte(name + ":" + strings.TrimSpace(value)))
+		_, _ = requestData.Write(lf)
+	}
+}
+
 func gHash(h hash.Hash, data []byte) []byte {
 	_, _ = h.Write(data)
 	return h.Sum(nil)
@@ -208,10 +236,35 @@ func NewAccessKeyAuthRequest(req *http.Request) (*Credential, error) {
 	return a, nil
 }
 
+// WithScope 设置签名密钥的作用域
+func (a *Credential) WithScope(region, service string) *Credential {
+	a.Region = region
+	a.Service = service
+	return a
+}
+
+// AuditFields 返回用于审计日志的字段，包含 AccessKey、签名算法、Nonce 和时间戳，
+// 不包含 AccessSecret 和 Signature
+func (a *Credential) AuditFields() []zap.Field {
+	fields := []zap.Field{
+		zap.String("access_key", a.AccessKey),
+		zap.String("algorithm", a.SignatureAlgorithm),
+		zap.String("nonce", a.SignatureNonce),
+		zap.String("timestamp", a.Timestamp),
+	}
+	if a.Region != "" || a.Service != "" {
+		fields = append(fields, zap.String("region", a.Region), zap.String("service", a.Service))
+	}
+	return fields
+}
+
 func (a *Credential) CheckSignature(req *http.Request) error {
 	result := a.stringToSign(req)
-	if a.Signature != result {
-		return fmt.Errorf("ak/sk signature check failed. expected: %s, got: %s", a.Signature, result)
+	if !hmac.Equal([]byte(a.Signature), []byte(result)) {
+		// 计算出的签名即为该请求的有效签名，只在 debug 级别输出
+		logger.Debugf("ak/sk signature check failed, access key: %s, nonce: %s, signature: %s, computed: %s",
+			a.AccessKey, a.SignatureNonce, a.Signature, result)
+		return fmt.Errorf("%w: access key: %s, nonce: %s", ErrSignatureMismatch, a.AccessKey, a.SignatureNonce)
 	}
 	return nil
 }
@@ -235,13 +288,29 @@ func (a *Credential) stringToSign(req *http.Request) string {
 	lastData.Write(lf)
 	lastData.Write([]byte(a.TimestampTime.Format(iso8601DateFormat)))
 	lastData.Write(lf)
+	if scope := a.scope(); scope != "" {
+		lastData.WriteString(scope)
+		lastData.Write(lf)
+	}
 	lastData.WriteString(hex.EncodeToString(a.signRequest(req)))
 	data := gHmac(a.AlgorithmFn, a.signKey(), lastData.Bytes())
 	return hex.EncodeToString(data)
 }
 
+// scope 返回签名作用域 date/region/service/request，未设置 Region 和 Service 时为空
+func (a *Credential) scope() string {
+	if a.Region == "" && a.Service == "" {
+		return ""
+	}
+	return strings.Join([]string{a.TimestampTime.Format(yyyymmdd), a.Region, a.Service, "request"}, "/")
+}
+
 func (a *Credential) signKey() []byte {
 	data := gHmac(a.AlgorithmFn, []byte(a.AccessSecret), []byte(a.TimestampTime.Format(yyyymmdd)))
+	if a.Region != "" || a.Service != "" {
+		data = gHmac(a.AlgorithmFn, data, []byte(a.Region))
+		data = gHmac(a.AlgorithmFn, data, []byte(a.Service))
+	}
 	return gHmac(a.AlgorithmFn, data, []byte("request"))
 }
 
@@ -257,6 +326,10 @@ func (a *Credential) signRequest(r *http.Request) []byte {
 	writeQuery(r, requestData)
 	requestData.Write(lf)
 
+	if len(a.SignedHeaders) > 0 {
+		writeHeaders(r, a.SignedHeaders, requestData)
+	}
+
 	writeBody(a.AlgorithmFn, r, requestData)
 
 	return gHash(a.AlgorithmFn(), requestData.Bytes())
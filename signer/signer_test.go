@@ -0,0 +1,228 @@
+package signer
+
+import (
+	"crypto/sha512"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/x893675/valhalla-common/cache"
+)
+
+func TestCheckSignatureErrorOmitsSignature(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users?name=alice", nil)
+	if err := NewAccessKeyAuth("ak", "wrong", defaultAlgorithm).SignRequest(req); err != nil {
+		t.Fatalf("SignRequest() error = %v", err)
+	}
+
+	cred, err := NewAccessKeyAuthRequest(req)
+	if err != nil {
+		t.Fatalf("NewAccessKeyAuthRequest() error = %v", err)
+	}
+	cred.AccessSecret = "sk"
+	expected := cred.stringToSign(req)
+
+	err = cred.CheckSignature(req)
+	if !errors.Is(err, ErrSignatureMismatch) {
+		t.Fatalf("CheckSignature() error = %v, want %v", err, ErrSignatureMismatch)
+	}
+	msg := err.Error()
+	if strings.Contains(msg, expected) || strings.Contains(msg, cred.Signature) {
+		t.Errorf("error message should not contain signatures: %s", msg)
+	}
+	if !strings.Contains(msg, "ak") || !strings.Contains(msg, cred.SignatureNonce) {
+		t.Errorf("error message should contain access key and nonce: %s", msg)
+	}
+
+	cred.Signature = expected
+	if err := cred.CheckSignature(req); err != nil {
+		t.Errorf("CheckSignature() error = %v", err)
+	}
+}
+
+func TestSignatureScope(t *testing.T) {
+	sign := func(region, service string) string {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{"name":"alice"}`))
+		cred := NewAccessKeyAuth("ak", "sk", defaultAlgorithm).WithScope(region, service)
+		// 固定时间和 nonce，保证只有作用域不同
+		cred.TimestampTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		cred.SignatureNonce = "nonce"
+		if err := cred.SignRequest(req); err != nil {
+			t.Fatalf("SignRequest() error = %v", err)
+		}
+		return req.URL.Query().Get(queryKeySignature)
+	}
+
+	unscoped := sign("", "")
+	seen := map[string]string{"": unscoped}
+	for _, scope := range [][2]string{{"cn-north-1", "iam"}, {"cn-north-1", "oss"}, {"cn-east-1", "iam"}, {"iam", ""}, {"", "iam"}} {
+		got := sign(scope[0], scope[1])
+		for prev, sig := range seen {
+			if got == sig {
+				t.Errorf("scope %v produces the same signature as %q", scope, prev)
+			}
+		}
+		seen[scope[0]+"/"+scope[1]] = got
+	}
+
+	// 验证方需要使用相同的作用域
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	if err := NewAccessKeyAuth("ak", "sk", defaultAlgorithm).WithScope("cn-north-1", "iam").SignRequest(req); err != nil {
+		t.Fatalf("SignRequest() error = %v", err)
+	}
+	cred, err := NewAccessKeyAuthRequest(req)
+	if err != nil {
+		t.Fatalf("NewAccessKeyAuthRequest() error = %v", err)
+	}
+	cred.AccessSecret = "sk"
+	if err := cred.CheckSignature(req); err == nil {
+		t.Error("CheckSignature() without scope should fail")
+	}
+	if err := cred.WithScope("cn-north-1", "iam").CheckSignature(req); err != nil {
+		t.Errorf("CheckSignature() with scope error = %v", err)
+	}
+}
+
+func TestCredentialAuditFields(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", nil)
+	if err := NewAccessKeyAuth("ak", "top-secret", defaultAlgorithm).WithScope("cn-north-1", "iam").SignRequest(req); err != nil {
+		t.Fatalf("SignRequest() error = %v", err)
+	}
+	cred, err := NewAccessKeyAuthRequest(req)
+	if err != nil {
+		t.Fatalf("NewAccessKeyAuthRequest() error = %v", err)
+	}
+	cred.AccessSecret = "top-secret"
+	cred.WithScope("cn-north-1", "iam")
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range cred.AuditFields() {
+		f.AddTo(enc)
+	}
+	want := map[string]string{
+		"access_key": "ak",
+		"algorithm":  cred.SignatureAlgorithm,
+		"nonce":      cred.SignatureNonce,
+		"timestamp":  cred.Timestamp,
+		"region":     "cn-north-1",
+		"service":    "iam",
+	}
+	if len(enc.Fields) != len(want) {
+		t.Errorf("AuditFields() = %v, want %d fields", enc.Fields, len(want))
+	}
+	for k, v := range want {
+		if enc.Fields[k] != v {
+			t.Errorf("AuditFields()[%s] = %v, want %s", k, enc.Fields[k], v)
+		}
+	}
+	for k, v := range enc.Fields {
+		if s, _ := v.(string); s == cred.AccessSecret || s == cred.Signature {
+			t.Errorf("AuditFields() leaks the secret or signature in %s", k)
+		}
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	c, _ := cache.NewMemory()
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "zero", opts: Options{}},
+		{name: "full", opts: Options{Algorithm: defaultAlgorithm, MaxAge: time.Minute, SignedHeaders: []string{"Host"}, Region: "cn-north-1", Service: "iam", NonceStore: NewCacheNonceStore(c)}},
+		{name: "unknown algorithm", opts: Options{Algorithm: "HMAC-MD4"}, wantErr: true},
+		{name: "negative max age", opts: Options{MaxAge: -time.Second}, wantErr: true},
+		{name: "nonce store without max age", opts: Options{NonceStore: NewCacheNonceStore(c)}, wantErr: true},
+		{name: "empty header", opts: Options{SignedHeaders: []string{" "}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, err := NewVerifier(tt.opts); (err != nil) != tt.wantErr {
+				t.Errorf("NewVerifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := NewSigner("ak", "", Options{}); err == nil {
+		t.Error("NewSigner() without secret should fail")
+	}
+}
+
+func TestSignerVerifierRoundTrip(t *testing.T) {
+	const algorithm = "HMAC-SHA512-TEST"
+	if err := Register(algorithm, sha512.New); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	c, _ := cache.NewMemory()
+	opts := Options{
+		Algorithm:     algorithm,
+		MaxAge:        time.Minute,
+		SignedHeaders: []string{"Host", "X-Request-Id"},
+		Region:        "cn-north-1",
+		Service:       "iam",
+		NonceStore:    NewCacheNonceStore(c),
+	}
+	s, err := NewSigner("ak", "sk", opts)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	v, err := NewVerifier(opts)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://api.example.com/api/v1/users?name=alice", strings.NewReader(`{"name":"alice"}`))
+		req.Header.Set("X-Request-Id", "req-1")
+		if err := s.Sign(req); err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return req
+	}
+
+	req := newRequest()
+	cred, err := v.Verify(req, "sk")
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if cred.SignatureAlgorithm != algorithm || cred.Region != "cn-north-1" || cred.Service != "iam" {
+		t.Errorf("Verify() credential = %+v", cred)
+	}
+	if _, err := v.Verify(req, "sk"); !errors.Is(err, ErrNonceReused) {
+		t.Errorf("Verify() replay error = %v, want %v", err, ErrNonceReused)
+	}
+
+	req = newRequest()
+	req.Header.Set("X-Request-Id", "req-2")
+	if _, err := v.Verify(req, "sk"); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("Verify() with modified header error = %v, want %v", err, ErrSignatureMismatch)
+	}
+
+	if _, err := v.Verify(newRequest(), "wrong"); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("Verify() with wrong secret error = %v, want %v", err, ErrSignatureMismatch)
+	}
+
+	unscoped, _ := NewVerifier(Options{Algorithm: algorithm, SignedHeaders: opts.SignedHeaders})
+	if _, err := unscoped.Verify(newRequest(), "sk"); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("Verify() without scope error = %v, want %v", err, ErrSignatureMismatch)
+	}
+
+	sha256Only, _ := NewVerifier(Options{Algorithm: defaultAlgorithm})
+	if _, err := sha256Only.Verify(newRequest(), "sk"); !errors.Is(err, ErrAlgorithmNotAllowed) {
+		t.Errorf("Verify() with other algorithm error = %v, want %v", err, ErrAlgorithmNotAllowed)
+	}
+
+	req = newRequest()
+	v.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := v.Verify(req, "sk"); !errors.Is(err, ErrSignatureExpired) {
+		t.Errorf("Verify() expired error = %v, want %v", err, ErrSignatureExpired)
+	}
+}
@@ -0,0 +1,131 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/x893675/valhalla-common/cache"
+	"github.com/x893675/valhalla-common/constant"
+)
+
+// CredentialStore 根据 AccessKey 查询对应的 AccessSecret
+// AccessKey 不存在时返回 ok 为 false 且 err 为 nil
+type CredentialStore interface {
+	Secret(ctx context.Context, accessKey string) (secret string, ok bool, err error)
+}
+
+// CredentialStatusStore 可选实现的接口，用于查询凭证的启用状态和过期时间，
+// 签名校验前会拒绝已禁用或已过期的 AccessKey。expiresAt 为零值表示不过期
+type CredentialStatusStore interface {
+	CredentialStore
+	Status(ctx context.Context, accessKey string) (active bool, expiresAt time.Time, err error)
+}
+
+var (
+	_ CredentialStatusStore = (*MemoryCredentialStore)(nil)
+	_ CredentialStore       = (*CacheCredentialStore)(nil)
+)
+
+type memoryCredential struct {
+	secret    string
+	disabled  bool
+	expiresAt time.Time
+}
+
+// MemoryCredentialStore 基于内存的 CredentialStore，可并发使用
+type MemoryCredentialStore struct {
+	mu          sync.RWMutex
+	credentials map[string]memoryCredential
+}
+
+// NewMemoryCredentialStore 使用 AccessKey 到 AccessSecret 的映射创建 MemoryCredentialStore
+func NewMemoryCredentialStore(secrets map[string]string) *MemoryCredentialStore {
+	s := &MemoryCredentialStore{credentials: make(map[string]memoryCredential, len(secrets))}
+	for k, v := range secrets {
+		s.credentials[k] = memoryCredential{secret: v}
+	}
+	return s
+}
+
+// Set 添加或更新凭证，更新后凭证为启用且不过期的状态
+func (s *MemoryCredentialStore) Set(accessKey, secret string) {
+	s.SetWithExpiry(accessKey, secret, time.Time{})
+}
+
+// SetWithExpiry 添加或更新凭证，expiresAt 之后凭证失效，为零值时不过期
+func (s *MemoryCredentialStore) SetWithExpiry(accessKey, secret string, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.credentials[accessKey] = memoryCredential{secret: secret, expiresAt: expiresAt}
+}
+
+// SetActive 启用或禁用凭证，凭证不存在时返回 false
+func (s *MemoryCredentialStore) SetActive(accessKey string, active bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.credentials[accessKey]
+	if !ok {
+		return false
+	}
+	c.disabled = !active
+	s.credentials[accessKey] = c
+	return true
+}
+
+// Delete 删除凭证
+func (s *MemoryCredentialStore) Delete(accessKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.credentials, accessKey)
+}
+
+func (s *MemoryCredentialStore) Secret(_ context.Context, accessKey string) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.credentials[accessKey]
+	return c.secret, ok, nil
+}
+
+func (s *MemoryCredentialStore) Status(_ context.Context, accessKey string) (bool, time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.credentials[accessKey]
+	if !ok {
+		return false, time.Time{}, nil
+	}
+	return !c.disabled, c.expiresAt, nil
+}
+
+// CacheCredentialStore 基于 cache.Interface 的 CredentialStore，
+// 凭证以 constant.AccessKeyCacheKeyFormat 为 key 存储
+type CacheCredentialStore struct {
+	cache cache.Interface
+}
+
+// NewCacheCredentialStore 创建基于 cache.Interface 的 CredentialStore
+func NewCacheCredentialStore(c cache.Interface) *CacheCredentialStore {
+	return &CacheCredentialStore{cache: c}
+}
+
+// Set 添加或更新凭证，expire 为 cache.NoExpiration 时不过期
+func (s *CacheCredentialStore) Set(ctx context.Context, accessKey, secret string, expire time.Duration) error {
+	return s.cache.Set(ctx, fmt.Sprintf(constant.AccessKeyCacheKeyFormat, accessKey), secret, expire)
+}
+
+// Delete 删除凭证
+func (s *CacheCredentialStore) Delete(ctx context.Context, accessKey string) error {
+	return s.cache.Remove(ctx, fmt.Sprintf(constant.AccessKeyCacheKeyFormat, accessKey))
+}
+
+func (s *CacheCredentialStore) Secret(ctx context.Context, accessKey string) (string, bool, error) {
+	var secret string
+	if err := s.cache.Get(ctx, fmt.Sprintf(constant.AccessKeyCacheKeyFormat, accessKey), &secret); err != nil {
+		if cache.IsNotExists(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return secret, true, nil
+}
@@ -0,0 +1,119 @@
+package signer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/x893675/valhalla-common/cache"
+)
+
+func TestMemoryCredentialStore(t *testing.T) {
+	s := NewMemoryCredentialStore(map[string]string{"ak": "sk"})
+	ctx := context.Background()
+
+	secret, ok, err := s.Secret(ctx, "ak")
+	if err != nil || !ok || secret != "sk" {
+		t.Errorf("Secret(ak) = %q, %v, %v", secret, ok, err)
+	}
+	if _, ok, err := s.Secret(ctx, "unknown"); ok || err != nil {
+		t.Errorf("Secret(unknown) = %v, %v, want not found", ok, err)
+	}
+
+	s.Delete("ak")
+	if _, ok, _ := s.Secret(ctx, "ak"); ok {
+		t.Error("Secret(ak) should not be found after Delete")
+	}
+}
+
+func TestCacheCredentialStore(t *testing.T) {
+	c, _ := cache.NewMemory()
+	s := NewCacheCredentialStore(c)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "ak", "sk", cache.NoExpiration); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	secret, ok, err := s.Secret(ctx, "ak")
+	if err != nil || !ok || secret != "sk" {
+		t.Errorf("Secret(ak) = %q, %v, %v", secret, ok, err)
+	}
+	if _, ok, err := s.Secret(ctx, "unknown"); ok || err != nil {
+		t.Errorf("Secret(unknown) = %v, %v, want not found", ok, err)
+	}
+}
+
+func TestVerifyMiddleware(t *testing.T) {
+	store := NewMemoryCredentialStore(map[string]string{"ak": "sk"})
+	handler := VerifyMiddleware(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	newSignedRequest := func(accessKey, secret string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users?name=alice", nil)
+		if err := NewAccessKeyAuth(accessKey, secret, defaultAlgorithm).SignRequest(req); err != nil {
+			t.Fatalf("SignRequest() error = %v", err)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{name: "valid signature", req: newSignedRequest("ak", "sk"), want: http.StatusOK},
+		{name: "wrong secret", req: newSignedRequest("ak", "wrong"), want: http.StatusUnauthorized},
+		{name: "unknown access key", req: newSignedRequest("unknown", "sk"), want: http.StatusUnauthorized},
+		{name: "unsigned", req: httptest.NewRequest(http.MethodGet, "/api/v1/users", nil), want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyMiddlewareCredentialStatus(t *testing.T) {
+	store := NewMemoryCredentialStore(nil)
+	store.SetWithExpiry("active", "sk", time.Now().Add(time.Hour))
+	store.SetWithExpiry("expired", "sk", time.Now().Add(-time.Minute))
+	store.Set("disabled", "sk")
+	store.SetActive("disabled", false)
+
+	handler := VerifyMiddleware(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		accessKey string
+		want      int
+	}{
+		{accessKey: "active", want: http.StatusOK},
+		{accessKey: "expired", want: http.StatusUnauthorized},
+		{accessKey: "disabled", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accessKey, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			if err := NewAccessKeyAuth(tt.accessKey, "sk", defaultAlgorithm).SignRequest(req); err != nil {
+				t.Fatalf("SignRequest() error = %v", err)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	if store.SetActive("unknown", true) {
+		t.Error("SetActive() should return false for unknown access key")
+	}
+}
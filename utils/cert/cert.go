@@ -1,19 +1,24 @@
 package cert
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"math"
 	"math/big"
 	"net"
+	"net/url"
+	"runtime"
+	"sync"
 	"time"
 )
 
@@ -47,6 +52,20 @@ var (
 	ErrNoCertificateFound = errors.New("no certificate found in PEM data")
 	// ErrNoPrivateKeyFound 未找到私钥
 	ErrNoPrivateKeyFound = errors.New("no private key found in PEM data")
+	// ErrInvalidKeySize 不支持的 RSA 密钥长度
+	ErrInvalidKeySize = errors.New("invalid RSA key size, must be one of 2048, 3072, 4096")
+	// ErrKeyMismatch 私钥与证书不匹配
+	ErrKeyMismatch = errors.New("key does not match certificate")
+	// ErrNoCertificateRequestFound 未找到证书请求
+	ErrNoCertificateRequestFound = errors.New("no certificate request found in PEM data")
+	// ErrInvalidCertificateRequest 无效的证书请求
+	ErrInvalidCertificateRequest = errors.New("invalid certificate request")
+	// ErrCertificateExpired 证书已过期
+	ErrCertificateExpired = errors.New("certificate has expired")
+	// ErrCertificateNotYetValid 证书尚未生效
+	ErrCertificateNotYetValid = errors.New("certificate is not yet valid")
+	// ErrNotSignedByCA 证书不是由指定 CA 签发的
+	ErrNotSignedByCA = errors.New("certificate is not signed by the CA")
 )
 
 // KeyType 密钥类型
@@ -59,10 +78,112 @@ const (
 	KeyTypeECDSA KeyType = "ECDSA"
 )
 
+// Curve ECDSA 椭圆曲线
+type Curve string
+
+const (
+	// CurveP256 NIST P-256 曲线
+	CurveP256 Curve = "P256"
+	// CurveP384 NIST P-384 曲线
+	CurveP384 Curve = "P384"
+	// CurveP521 NIST P-521 曲线
+	CurveP521 Curve = "P521"
+)
+
 // AltNames 证书的备用名称（SAN - Subject Alternative Names）
 type AltNames struct {
 	DNSNames []string `json:"dnsNames,omitempty" yaml:"dnsNames"`
 	IPs      []net.IP `json:"ips,omitempty" yaml:"ips"`
+	// EmailAddresses 邮箱地址，用于客户端证书和 S/MIME 证书
+	EmailAddresses []string `json:"emailAddresses,omitempty" yaml:"emailAddresses"`
+	// URIs URI 地址，如 SPIFFE ID spiffe://example.org/ns/default/sa/app
+	URIs []*url.URL `json:"uris,omitempty" yaml:"uris"`
+}
+
+// isEmpty 是否未设置任何备用名称
+func (a AltNames) isEmpty() bool {
+	return len(a.DNSNames) == 0 && len(a.IPs) == 0 && len(a.EmailAddresses) == 0 && len(a.URIs) == 0
+}
+
+// validate 校验备用名称，URI 必须是包含 scheme 的绝对地址
+func (a AltNames) validate() error {
+	for _, u := range a.URIs {
+		if u == nil {
+			return errors.New("invalid URI SAN: nil URI")
+		}
+		if !u.IsAbs() || (u.Host == "" && u.Opaque == "") {
+			return fmt.Errorf("invalid URI SAN %q: must be an absolute URI with a host", u.String())
+		}
+	}
+	return nil
+}
+
+// altNamesText AltNames 的序列化格式，URI 以字符串表示
+type altNamesText struct {
+	DNSNames       []string `json:"dnsNames,omitempty" yaml:"dnsNames,omitempty"`
+	IPs            []net.IP `json:"ips,omitempty" yaml:"ips,omitempty"`
+	EmailAddresses []string `json:"emailAddresses,omitempty" yaml:"emailAddresses,omitempty"`
+	URIs           []string `json:"uris,omitempty" yaml:"uris,omitempty"`
+}
+
+func (a AltNames) toText() altNamesText {
+	t := altNamesText{
+		DNSNames:       a.DNSNames,
+		IPs:            a.IPs,
+		EmailAddresses: a.EmailAddresses,
+	}
+	for _, u := range a.URIs {
+		if u != nil {
+			t.URIs = append(t.URIs, u.String())
+		}
+	}
+	return t
+}
+
+func (a *AltNames) fromText(t altNamesText) error {
+	var uris []*url.URL
+	for _, raw := range t.URIs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("invalid URI SAN %q: %w", raw, err)
+		}
+		uris = append(uris, u)
+	}
+	*a = AltNames{
+		DNSNames:       t.DNSNames,
+		IPs:            t.IPs,
+		EmailAddresses: t.EmailAddresses,
+		URIs:           uris,
+	}
+	return a.validate()
+}
+
+// MarshalJSON 将 URI 序列化为字符串
+func (a AltNames) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.toText())
+}
+
+// UnmarshalJSON 从字符串解析 URI，URI 无效时返回错误
+func (a *AltNames) UnmarshalJSON(data []byte) error {
+	var t altNamesText
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	return a.fromText(t)
+}
+
+// MarshalYAML 将 URI 序列化为字符串
+func (a AltNames) MarshalYAML() (interface{}, error) {
+	return a.toText(), nil
+}
+
+// UnmarshalYAML 从字符串解析 URI，URI 无效时返回错误
+func (a *AltNames) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var t altNamesText
+	if err := unmarshal(&t); err != nil {
+		return err
+	}
+	return a.fromText(t)
 }
 
 // Config 证书配置
@@ -71,6 +192,16 @@ type Config struct {
 	CommonName string `json:"commonName" yaml:"commonName"`
 	// Organization 组织名称列表
 	Organization []string `json:"organization,omitempty" yaml:"organization"`
+	// OrganizationalUnit 组织单位列表
+	OrganizationalUnit []string `json:"organizationalUnit,omitempty" yaml:"organizationalUnit"`
+	// Country 国家代码列表
+	Country []string `json:"country,omitempty" yaml:"country"`
+	// Province 省份列表
+	Province []string `json:"province,omitempty" yaml:"province"`
+	// Locality 城市列表
+	Locality []string `json:"locality,omitempty" yaml:"locality"`
+	// SubjectSerialNumber 主题中的序列号属性，与证书序列号无关
+	SubjectSerialNumber string `json:"subjectSerialNumber,omitempty" yaml:"subjectSerialNumber"`
 	// ValidYears 证书有效期（年）
 	ValidYears int `json:"validYears,omitempty" yaml:"validYears"`
 	// AltNames 备用名称
@@ -79,12 +210,106 @@ type Config struct {
 	Usages []x509.ExtKeyUsage `json:"usages,omitempty" yaml:"usages"`
 	// KeyType 密钥类型
 	KeyType KeyType `json:"keyType,omitempty" yaml:"keyType"`
+	// RSAKeySize RSA 密钥长度（位），可选 2048、3072、4096，为 0 时使用默认值 2048，仅对 RSA 密钥有效
+	RSAKeySize int `json:"rsaKeySize,omitempty" yaml:"rsaKeySize"`
+	// Curve ECDSA 曲线，可选 P256、P384、P521，为空时使用 P256，仅对 ECDSA 密钥有效
+	Curve Curve `json:"curve,omitempty" yaml:"curve"`
+	// NotBefore 证书生效时间，为空时使用当前时间，可用于向前调整以容忍时钟偏差
+	NotBefore time.Time `json:"notBefore,omitempty" yaml:"notBefore"`
+	// ValidFor 证书有效时长，从 NotBefore 开始计算，设置后优先于 ValidYears
+	ValidFor time.Duration `json:"validFor,omitempty" yaml:"validFor"`
+	// NotBeforeSkew 证书生效时间向前调整的时长，用于容忍时钟偏差，不影响过期时间
+	NotBeforeSkew time.Duration `json:"notBeforeSkew,omitempty" yaml:"notBeforeSkew"`
+	// CRLDistributionPoints CRL 分发点地址，仅对 CA 签发的证书有效
+	CRLDistributionPoints []string `json:"crlDistributionPoints,omitempty" yaml:"crlDistributionPoints"`
+	// OCSPServers OCSP 服务地址，仅对 CA 签发的证书有效
+	OCSPServers []string `json:"ocspServers,omitempty" yaml:"ocspServers"`
+	// KeyIDMethod SubjectKeyId 的计算方法，为空时使用 KeyIDMethodSHA1
+	KeyIDMethod KeyIDMethod `json:"keyIDMethod,omitempty" yaml:"keyIDMethod"`
+}
+
+// validity 根据配置计算证书的有效期
+func (cfg Config) validity(now time.Time) (notBefore, notAfter time.Time, err error) {
+	if cfg.ValidFor < 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid validity duration: %s", cfg.ValidFor)
+	}
+	if cfg.NotBeforeSkew < 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid not before skew: %s", cfg.NotBeforeSkew)
+	}
+
+	start := now
+	if !cfg.NotBefore.IsZero() {
+		start = cfg.NotBefore
+	}
+	if cfg.ValidFor > 0 {
+		notAfter = start.Add(cfg.ValidFor)
+	} else {
+		notAfter = start.AddDate(cfg.ValidYears, 0, 0)
+	}
+	notBefore = start.Add(-cfg.NotBeforeSkew)
+
+	if !notAfter.After(notBefore) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid validity window: %s - %s", notBefore, notAfter)
+	}
+	return notBefore.UTC(), notAfter.UTC(), nil
+}
+
+// subject 返回证书主题，未设置的字段为空
+func (cfg Config) subject() pkix.Name {
+	return pkix.Name{
+		CommonName:         cfg.CommonName,
+		Organization:       cfg.Organization,
+		OrganizationalUnit: cfg.OrganizationalUnit,
+		Country:            cfg.Country,
+		Province:           cfg.Province,
+		Locality:           cfg.Locality,
+		SerialNumber:       cfg.SubjectSerialNumber,
+	}
 }
 
 // CA 表示一个证书颁发机构
 type CA struct {
 	Certificate *x509.Certificate
 	PrivateKey  crypto.Signer
+	// SerialSource 签发证书时使用的序列号分配器，为空时使用 128 位随机序列号
+	SerialSource SerialSource
+}
+
+// SerialSource 证书序列号分配器，可接入持久化计数器或数据库，保证重启后签发的序列号仍然唯一
+type SerialSource interface {
+	// NextSerial 返回下一个序列号，必须为正数且不超过 20 字节
+	NextSerial() (*big.Int, error)
+}
+
+// serialNumberLimit 随机序列号上限，RFC 5280 允许最长 20 字节，这里使用 128 位
+var serialNumberLimit = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// randomSerialNumber 生成 128 位随机序列号，序列号始终为正数
+func randomSerialNumber() (*big.Int, error) {
+	for {
+		serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate serial number: %w", err)
+		}
+		if serialNumber.Sign() > 0 {
+			return serialNumber, nil
+		}
+	}
+}
+
+// nextSerialNumber 返回 CA 签发证书使用的序列号
+func (ca *CA) nextSerialNumber() (*big.Int, error) {
+	if ca.SerialSource == nil {
+		return randomSerialNumber()
+	}
+	serialNumber, err := ca.SerialSource.NextSerial()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	if serialNumber == nil || serialNumber.Sign() <= 0 || len(serialNumber.Bytes()) > 20 {
+		return nil, fmt.Errorf("invalid serial number: %v", serialNumber)
+	}
+	return serialNumber, nil
 }
 
 // CertKeyPair 表示证书和私钥对
@@ -93,18 +318,55 @@ type CertKeyPair struct {
 	PrivateKey  crypto.Signer
 }
 
-// NewPrivateKey 生成新的私钥
+// NewPrivateKey 生成新的私钥，RSA 密钥使用默认长度
 func NewPrivateKey(keyType KeyType) (crypto.Signer, error) {
+	return NewPrivateKeyWithBits(keyType, 0)
+}
+
+// NewPrivateKeyWithBits 生成新的私钥，bits 指定 RSA 密钥长度
+// bits 为 0 时使用默认值 2048，只支持 2048、3072、4096；ECDSA 密钥忽略 bits
+func NewPrivateKeyWithBits(keyType KeyType, bits int) (crypto.Signer, error) {
 	switch keyType {
 	case KeyTypeECDSA:
-		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		return NewECDSAPrivateKey(CurveP256)
 	case KeyTypeRSA, "":
-		return rsa.GenerateKey(rand.Reader, defaultRSAKeySize)
+		switch bits {
+		case 0:
+			bits = defaultRSAKeySize
+		case 2048, 3072, 4096:
+		default:
+			return nil, fmt.Errorf("%w: %d", ErrInvalidKeySize, bits)
+		}
+		return rsa.GenerateKey(rand.Reader, bits)
 	default:
 		return nil, fmt.Errorf("unsupported key type: %s", keyType)
 	}
 }
 
+// NewECDSAPrivateKey 使用指定曲线生成 ECDSA 私钥，curve 为空时使用 P256
+func NewECDSAPrivateKey(curve Curve) (crypto.Signer, error) {
+	var c elliptic.Curve
+	switch curve {
+	case CurveP256, "":
+		c = elliptic.P256()
+	case CurveP384:
+		c = elliptic.P384()
+	case CurveP521:
+		c = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported ECDSA curve: %s", curve)
+	}
+	return ecdsa.GenerateKey(c, rand.Reader)
+}
+
+// newPrivateKeyFromConfig 按照证书配置生成私钥
+func newPrivateKeyFromConfig(cfg Config) (crypto.Signer, error) {
+	if cfg.KeyType == KeyTypeECDSA {
+		return NewECDSAPrivateKey(cfg.Curve)
+	}
+	return NewPrivateKeyWithBits(cfg.KeyType, cfg.RSAKeySize)
+}
+
 // NewCA 创建新的 CA 证书和私钥
 func NewCA(cfg Config) (*CA, error) {
 	if cfg.CommonName == "" {
@@ -117,13 +379,13 @@ func NewCA(cfg Config) (*CA, error) {
 	}
 
 	// 生成私钥
-	key, err := NewPrivateKey(cfg.KeyType)
+	key, err := newPrivateKeyFromConfig(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate private key: %w", err)
 	}
 
 	// 生成 CA 证书
-	cert, err := newSelfSignedCACert(key, cfg)
+	cert, err := newCACert(key, cfg, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate CA certificate: %w", err)
 	}
@@ -134,28 +396,74 @@ func NewCA(cfg Config) (*CA, error) {
 	}, nil
 }
 
-// newSelfSignedCACert 创建自签名 CA 证书
-func newSelfSignedCACert(key crypto.Signer, cfg Config) (*x509.Certificate, error) {
-	now := time.Now()
-	serialNumber, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+// NewIntermediateCA 创建由当前 CA 签发的中间 CA
+// 中间 CA 的 MaxPathLen 为 0，只能签发终端证书，不能再签发下级 CA
+func (ca *CA) NewIntermediateCA(cfg Config) (*CA, error) {
+	if cfg.CommonName == "" {
+		return nil, errors.New("common name is required")
+	}
+
+	// 设置默认值
+	if cfg.ValidYears == 0 {
+		cfg.ValidYears = defaultValidYears
+	}
+
+	// 生成私钥
+	key, err := newPrivateKeyFromConfig(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	// 生成中间 CA 证书
+	cert, err := newCACert(key, cfg, ca)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate intermediate CA certificate: %w", err)
+	}
+
+	return &CA{
+		Certificate: cert,
+		PrivateKey:  key,
+	}, nil
+}
+
+// newCACert 创建 CA 证书，parent 为空时创建自签名根证书，否则由 parent 签发中间证书
+func newCACert(key crypto.Signer, cfg Config, parent *CA) (*x509.Certificate, error) {
+	notBefore, notAfter, err := cfg.validity(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var serialNumber *big.Int
+	if parent != nil {
+		serialNumber, err = parent.nextSerialNumber()
+	} else {
+		serialNumber, err = randomSerialNumber()
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	tmpl := x509.Certificate{
-		SerialNumber: serialNumber,
-		Subject: pkix.Name{
-			CommonName:   cfg.CommonName,
-			Organization: cfg.Organization,
-		},
-		NotBefore:             now.UTC(),
-		NotAfter:              now.AddDate(cfg.ValidYears, 0, 0).UTC(),
+		SerialNumber:          serialNumber,
+		Subject:               cfg.subject(),
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
 		BasicConstraintsValid: true,
 		IsCA:                  true,
 	}
+	if tmpl.SubjectKeyId, err = SubjectKeyID(key.Public(), cfg.KeyIDMethod); err != nil {
+		return nil, err
+	}
+
+	issuer, signer := &tmpl, key
+	if parent != nil {
+		tmpl.MaxPathLen = 0
+		tmpl.MaxPathLenZero = true
+		tmpl.AuthorityKeyId = parent.Certificate.SubjectKeyId
+		issuer, signer = parent.Certificate, parent.PrivateKey
+	}
 
-	certDERBytes, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, key.Public(), key)
+	certDERBytes, err := x509.CreateCertificate(rand.Reader, &tmpl, issuer, key.Public(), signer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create certificate: %w", err)
 	}
@@ -165,26 +473,19 @@ func newSelfSignedCACert(key crypto.Signer, cfg Config) (*x509.Certificate, erro
 
 // NewSignedCert 使用 CA 签发新证书
 func (ca *CA) NewSignedCert(cfg Config) (*CertKeyPair, error) {
-	if cfg.CommonName == "" {
-		return nil, errors.New("common name is required")
-	}
-	if len(cfg.Usages) == 0 {
-		return nil, errors.New("at least one key usage is required")
-	}
-
-	// 设置默认值
-	if cfg.ValidYears == 0 {
-		cfg.ValidYears = defaultValidYears
+	cfg, err := leafConfig(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	// 生成私钥
-	key, err := NewPrivateKey(cfg.KeyType)
+	key, err := newPrivateKeyFromConfig(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate private key: %w", err)
 	}
 
 	// 生成证书
-	cert, err := ca.signCert(key, cfg)
+	cert, err := ca.signCert(key.Public(), cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign certificate: %w", err)
 	}
@@ -195,29 +496,166 @@ func (ca *CA) NewSignedCert(cfg Config) (*CertKeyPair, error) {
 	}, nil
 }
 
-// signCert 使用 CA 签发证书
-func (ca *CA) signCert(key crypto.Signer, cfg Config) (*x509.Certificate, error) {
-	serialNumber, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+// NewSignedCerts 使用最多 concurrency 个 goroutine 并行签发证书，适用于批量初始化集群证书
+// 返回结果与 cfgs 顺序一致，签发失败的位置为 nil，所有失败项的错误通过 errors.Join 合并返回
+// concurrency 小于等于 0 时使用 GOMAXPROCS；CA 配置了 SerialSource 时需要保证其可并发调用
+func (ca *CA) NewSignedCerts(cfgs []Config, concurrency int) ([]*CertKeyPair, error) {
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	concurrency = min(concurrency, len(cfgs))
+
+	pairs := make([]*CertKeyPair, len(cfgs))
+	errs := make([]error, len(cfgs))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				pair, err := ca.NewSignedCert(cfgs[i])
+				if err != nil {
+					errs[i] = fmt.Errorf("config %d (%s): %w", i, cfgs[i].CommonName, err)
+					continue
+				}
+				pairs[i] = pair
+			}
+		}()
+	}
+	for i := range cfgs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return pairs, errors.Join(errs...)
+}
+
+// NewSelfSignedCert 创建自签名的非 CA 证书，适用于本地开发和测试
+// 校验规则与 NewSignedCert 相同，需要 CommonName 和至少一个密钥用途
+func NewSelfSignedCert(cfg Config) (*CertKeyPair, error) {
+	cfg, err := leafConfig(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+		return nil, err
+	}
+	if err := cfg.AltNames.validate(); err != nil {
+		return nil, err
 	}
 
-	now := time.Now()
-	certTmpl := x509.Certificate{
-		Subject: pkix.Name{
-			CommonName:   cfg.CommonName,
-			Organization: cfg.Organization,
+	key, err := newPrivateKeyFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+	serialNumber, err := randomSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := createLeafCert(key.Public(), cfg, serialNumber, nil, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create self-signed certificate: %w", err)
+	}
+
+	return &CertKeyPair{
+		Certificate: cert,
+		PrivateKey:  key,
+	}, nil
+}
+
+// leafConfig 校验非 CA 证书的配置并设置默认值
+func leafConfig(cfg Config) (Config, error) {
+	if cfg.CommonName == "" {
+		return cfg, errors.New("common name is required")
+	}
+	if len(cfg.Usages) == 0 {
+		return cfg, errors.New("at least one key usage is required")
+	}
+
+	// 设置默认值
+	if cfg.ValidYears == 0 {
+		cfg.ValidYears = defaultValidYears
+	}
+	return cfg, nil
+}
+
+// RenewCert 使用 CA 为旧证书签发新证书
+// 新证书沿用旧证书的主题、备用名称和 ExtKeyUsage，
+// 并使用新的私钥、序列号和有效期；旧证书必须由该 CA 签发
+func (ca *CA) RenewCert(old *x509.Certificate, keyType KeyType, validYears int) (*CertKeyPair, error) {
+	if old == nil {
+		return nil, ErrInvalidCertificate
+	}
+	if err := old.CheckSignatureFrom(ca.Certificate); err != nil {
+		return nil, fmt.Errorf("certificate was not signed by this CA: %w", err)
+	}
+
+	return ca.NewSignedCert(Config{
+		CommonName:          old.Subject.CommonName,
+		Organization:        old.Subject.Organization,
+		OrganizationalUnit:  old.Subject.OrganizationalUnit,
+		Country:             old.Subject.Country,
+		Province:            old.Subject.Province,
+		Locality:            old.Subject.Locality,
+		SubjectSerialNumber: old.Subject.SerialNumber,
+		ValidYears:          validYears,
+		AltNames: AltNames{
+			DNSNames:       old.DNSNames,
+			IPs:            old.IPAddresses,
+			EmailAddresses: old.EmailAddresses,
+			URIs:           old.URIs,
 		},
-		DNSNames:     cfg.AltNames.DNSNames,
-		IPAddresses:  cfg.AltNames.IPs,
-		SerialNumber: serialNumber,
-		NotBefore:    now.UTC(),
-		NotAfter:     now.AddDate(cfg.ValidYears, 0, 0).UTC(),
-		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:  cfg.Usages,
+		Usages:  old.ExtKeyUsage,
+		KeyType: keyType,
+	})
+}
+
+// signCert 使用 CA 为公钥签发证书
+func (ca *CA) signCert(pub crypto.PublicKey, cfg Config) (*x509.Certificate, error) {
+	if err := cfg.AltNames.validate(); err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := ca.nextSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	return createLeafCert(pub, cfg, serialNumber, ca.Certificate, ca.PrivateKey)
+}
+
+// createLeafCert 创建非 CA 证书，issuer 为空时使用 signer 自签名
+func createLeafCert(pub crypto.PublicKey, cfg Config, serialNumber *big.Int, issuer *x509.Certificate, signer crypto.Signer) (*x509.Certificate, error) {
+	notBefore, notAfter, err := cfg.validity(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	certTmpl := x509.Certificate{
+		Subject:        cfg.subject(),
+		DNSNames:       cfg.AltNames.DNSNames,
+		IPAddresses:    cfg.AltNames.IPs,
+		EmailAddresses: cfg.AltNames.EmailAddresses,
+		URIs:           cfg.AltNames.URIs,
+		SerialNumber:   serialNumber,
+		NotBefore:      notBefore,
+		NotAfter:       notAfter,
+		KeyUsage:       x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    cfg.Usages,
+
+		CRLDistributionPoints: cfg.CRLDistributionPoints,
+		OCSPServer:            cfg.OCSPServers,
+	}
+	if certTmpl.SubjectKeyId, err = SubjectKeyID(pub, cfg.KeyIDMethod); err != nil {
+		return nil, err
+	}
+	if issuer != nil {
+		certTmpl.AuthorityKeyId = issuer.SubjectKeyId
+	} else {
+		issuer = &certTmpl
 	}
 
-	certDERBytes, err := x509.CreateCertificate(rand.Reader, &certTmpl, ca.Certificate, key.Public(), ca.PrivateKey)
+	certDERBytes, err := x509.CreateCertificate(rand.Reader, &certTmpl, issuer, pub, signer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create certificate: %w", err)
 	}
@@ -283,6 +721,22 @@ func EncodePublicKeyPEM(key crypto.PublicKey) ([]byte, error) {
 	return pem.EncodeToMemory(&block), nil
 }
 
+// PublicKeyPEM 将 CA 私钥对应的公钥编码为 PEM 格式
+func (ca *CA) PublicKeyPEM() ([]byte, error) {
+	if ca == nil || ca.PrivateKey == nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	return EncodePublicKeyPEM(ca.PrivateKey.Public())
+}
+
+// PublicKeyPEM 将私钥对应的公钥编码为 PEM 格式，可用于发布 JWKS 或公钥固定
+func (ckp *CertKeyPair) PublicKeyPEM() ([]byte, error) {
+	if ckp == nil || ckp.PrivateKey == nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	return EncodePublicKeyPEM(ckp.PrivateKey.Public())
+}
+
 // ParseCertsPEM 从 PEM 数据中解析证书
 func ParseCertsPEM(pemData []byte) ([]*x509.Certificate, error) {
 	var certs []*x509.Certificate
@@ -314,7 +768,7 @@ func ParseCertsPEM(pemData []byte) ([]*x509.Certificate, error) {
 	return certs, nil
 }
 
-// ParsePrivateKeyPEM 从 PEM 数据中解析私钥
+// ParsePrivateKeyPEM 从 PEM 数据中解析私钥，遇到加密的私钥时返回 ErrEncryptedKey
 func ParsePrivateKeyPEM(pemData []byte) (crypto.Signer, error) {
 	for len(pemData) > 0 {
 		var block *pem.Block
@@ -323,6 +777,11 @@ func ParsePrivateKeyPEM(pemData []byte) (crypto.Signer, error) {
 			break
 		}
 
+		// 加密的私钥需要使用 ParsePrivateKeyPEMWithPassword 解析
+		if block.Type == EncryptedPrivateKeyBlockType || block.Headers["Proc-Type"] == "4,ENCRYPTED" {
+			return nil, ErrEncryptedKey
+		}
+
 		switch block.Type {
 		case ECPrivateKeyBlockType:
 			key, err := x509.ParseECPrivateKey(block.Bytes)
@@ -348,6 +807,37 @@ func ParsePrivateKeyPEM(pemData []byte) (crypto.Signer, error) {
 	return nil, ErrNoPrivateKeyFound
 }
 
+// ParseCertDER 从 DER 数据中解析证书
+func ParseCertDER(derData []byte) (*x509.Certificate, error) {
+	cert, err := x509.ParseCertificate(derData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// ParsePrivateKeyDER 从 DER 数据中解析私钥，依次尝试 PKCS#8、PKCS#1 和 EC 格式
+func ParsePrivateKeyDER(derData []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(derData); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, ErrInvalidPrivateKey
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(derData); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(derData); err == nil {
+		return key, nil
+	}
+	return nil, ErrNoPrivateKeyFound
+}
+
+// isPEM 判断数据是否为 PEM 格式
+func isPEM(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN"))
+}
+
 // ParsePublicKeyPEM 从 PEM 数据中解析公钥
 func ParsePublicKeyPEM(pemData []byte) (crypto.PublicKey, error) {
 	for len(pemData) > 0 {
@@ -368,6 +858,97 @@ func ParsePublicKeyPEM(pemData []byte) (crypto.PublicKey, error) {
 	return nil, ErrInvalidPublicKey
 }
 
+// KeyMatchesCert 检查私钥是否与证书的公钥匹配
+func KeyMatchesCert(key crypto.Signer, cert *x509.Certificate) bool {
+	return VerifyCertKeyMatch(cert, key) == nil
+}
+
+// VerifyCertKeyMatch 校验私钥是否与证书的公钥匹配，支持 RSA、ECDSA 和 Ed25519
+// 不匹配时返回 ErrKeyMismatch
+func VerifyCertKeyMatch(cert *x509.Certificate, key crypto.Signer) error {
+	if cert == nil {
+		return ErrInvalidCertificate
+	}
+	if key == nil {
+		return ErrInvalidPrivateKey
+	}
+
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return fmt.Errorf("%w: unsupported public key type %T", ErrInvalidPublicKey, key.Public())
+	}
+	if !pub.Equal(cert.PublicKey) {
+		return fmt.Errorf("%w: certificate public key is %T, private key is %T", ErrKeyMismatch, cert.PublicKey, key)
+	}
+	return nil
+}
+
+// CertExpiresWithin 判断证书是否会在 d 时间内过期（包括已过期），证书为 nil 时返回 true
+func CertExpiresWithin(cert *x509.Certificate, d time.Duration) bool {
+	return certExpiredAt(cert, time.Now().Add(d))
+}
+
+// certExpiredAt 判断证书在 at 时刻是否已过期，证书为 nil 时视为已过期
+func certExpiredAt(cert *x509.Certificate, at time.Time) bool {
+	if cert == nil {
+		return true
+	}
+	return at.After(cert.NotAfter)
+}
+
+// IsExpired 判断 CA 证书在 at 时刻是否已过期，证书为空时返回 true
+func (ca *CA) IsExpired(at time.Time) bool {
+	if ca == nil {
+		return true
+	}
+	return certExpiredAt(ca.Certificate, at)
+}
+
+// NeedsRenewal 判断 CA 证书是否会在 threshold 时间内过期，证书为空时返回 true
+func (ca *CA) NeedsRenewal(threshold time.Duration) bool {
+	return ca.IsExpired(time.Now().Add(threshold))
+}
+
+// IsExpired 判断证书在 at 时刻是否已过期，证书为空时返回 true
+func (ckp *CertKeyPair) IsExpired(at time.Time) bool {
+	if ckp == nil {
+		return true
+	}
+	return certExpiredAt(ckp.Certificate, at)
+}
+
+// NeedsRenewal 判断证书是否会在 threshold 时间内过期，证书为空时返回 true
+func (ckp *CertKeyPair) NeedsRenewal(threshold time.Duration) bool {
+	return ckp.IsExpired(time.Now().Add(threshold))
+}
+
+// VerifyChain 校验证书和私钥对：私钥与证书匹配、证书由 ca 签发且当前处于有效期内
+// 不同的失败原因分别返回 ErrKeyMismatch、ErrNotSignedByCA、ErrCertificateExpired 和 ErrCertificateNotYetValid
+func (ckp *CertKeyPair) VerifyChain(ca *CA) error {
+	if ckp == nil || ckp.Certificate == nil {
+		return ErrInvalidCertificate
+	}
+	if ca == nil || ca.Certificate == nil {
+		return fmt.Errorf("%w: CA certificate is required", ErrInvalidCertificate)
+	}
+
+	if err := VerifyCertKeyMatch(ckp.Certificate, ckp.PrivateKey); err != nil {
+		return err
+	}
+	if err := ckp.Certificate.CheckSignatureFrom(ca.Certificate); err != nil {
+		return fmt.Errorf("%w: %q: %w", ErrNotSignedByCA, ca.Certificate.Subject.CommonName, err)
+	}
+
+	now := time.Now()
+	if now.Before(ckp.Certificate.NotBefore) {
+		return fmt.Errorf("%w: valid from %s", ErrCertificateNotYetValid, ckp.Certificate.NotBefore.Format(time.RFC3339))
+	}
+	if certExpiredAt(ckp.Certificate, now) {
+		return fmt.Errorf("%w: expired at %s", ErrCertificateExpired, ckp.Certificate.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
+
 // NewCertPool 创建证书池
 func NewCertPool(certs ...*x509.Certificate) *x509.CertPool {
 	pool := x509.NewCertPool()
@@ -377,6 +958,31 @@ func NewCertPool(certs ...*x509.Certificate) *x509.CertPool {
 	return pool
 }
 
+// AppendCertsToPool 将 PEM 数据中的所有证书加入证书池
+func AppendCertsToPool(pool *x509.CertPool, pemData []byte) error {
+	certs, err := ParseCertsPEM(pemData)
+	if err != nil {
+		return err
+	}
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return nil
+}
+
+// CertPool 返回只包含 CA 证书的证书池，可用于校验该 CA 签发的证书
+func (ca *CA) CertPool() *x509.CertPool {
+	return NewCertPool(ca.Certificate)
+}
+
+// TrustedClientTLSConfig 返回信任该 CA 的客户端 TLS 配置
+func (ca *CA) TrustedClientTLSConfig() *tls.Config {
+	return &tls.Config{
+		RootCAs:    ca.CertPool(),
+		MinVersion: tls.VersionTLS12,
+	}
+}
+
 // NewCertPoolFromPEM 从 PEM 数据创建证书池
 func NewCertPoolFromPEM(pemData []byte) (*x509.CertPool, error) {
 	certs, err := ParseCertsPEM(pemData)
@@ -32,6 +32,18 @@ func BenchmarkNewPrivateKey_ECDSA(b *testing.B) {
 	}
 }
 
+// BenchmarkNewPrivateKey_RSA4096 4096 位 RSA 私钥生成性能
+func BenchmarkNewPrivateKey_RSA4096(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := NewPrivateKeyWithBits(KeyTypeRSA, 4096)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 // BenchmarkNewCA_RSA 创建 RSA CA 的性能
 func BenchmarkNewCA_RSA(b *testing.B) {
 	cfg := Config{
@@ -556,3 +568,36 @@ func BenchmarkCertKeyPair_ToBase64(b *testing.B) {
 		}
 	}
 }
+
+// benchmarkNewSignedCerts 批量签发 50 个 RSA 证书的性能，concurrency 为 1 时等同于串行签发
+func benchmarkNewSignedCerts(b *testing.B, concurrency int) {
+	ca, err := NewCA(Config{CommonName: "Bench CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		b.Fatal(err)
+	}
+	cfgs := make([]Config, 50)
+	for i := range cfgs {
+		cfgs[i] = Config{
+			CommonName: "host",
+			KeyType:    KeyTypeRSA,
+			Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ca.NewSignedCerts(cfgs, concurrency); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkNewSignedCerts_Serial 串行签发 50 个 RSA 证书
+func BenchmarkNewSignedCerts_Serial(b *testing.B) {
+	benchmarkNewSignedCerts(b, 1)
+}
+
+// BenchmarkNewSignedCerts_Parallel 并行签发 50 个 RSA 证书
+func BenchmarkNewSignedCerts_Parallel(b *testing.B) {
+	benchmarkNewSignedCerts(b, 0)
+}
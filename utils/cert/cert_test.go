@@ -1,11 +1,25 @@
 package cert
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/big"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -52,6 +66,159 @@ func TestNewPrivateKey(t *testing.T) {
 	}
 }
 
+func TestNewPrivateKeyWithBits(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyType  KeyType
+		bits     int
+		wantBits int
+		wantErr  bool
+	}{
+		{name: "default size", keyType: KeyTypeRSA, bits: 0, wantBits: 2048},
+		{name: "default key type", keyType: "", bits: 3072, wantBits: 3072},
+		{name: "RSA 2048", keyType: KeyTypeRSA, bits: 2048, wantBits: 2048},
+		{name: "RSA 3072", keyType: KeyTypeRSA, bits: 3072, wantBits: 3072},
+		{name: "RSA 4096", keyType: KeyTypeRSA, bits: 4096, wantBits: 4096},
+		{name: "RSA 1024 rejected", keyType: KeyTypeRSA, bits: 1024, wantErr: true},
+		{name: "RSA 2560 rejected", keyType: KeyTypeRSA, bits: 2560, wantErr: true},
+		{name: "RSA 8192 rejected", keyType: KeyTypeRSA, bits: 8192, wantErr: true},
+		{name: "ECDSA ignores bits", keyType: KeyTypeECDSA, bits: 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewPrivateKeyWithBits(tt.keyType, tt.bits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewPrivateKeyWithBits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidKeySize) {
+					t.Errorf("NewPrivateKeyWithBits() error = %v, want %v", err, ErrInvalidKeySize)
+				}
+				return
+			}
+
+			if tt.wantBits > 0 {
+				rsaKey, ok := key.(*rsa.PrivateKey)
+				if !ok {
+					t.Fatalf("NewPrivateKeyWithBits() returned %T, want *rsa.PrivateKey", key)
+				}
+				if got := rsaKey.N.BitLen(); got != tt.wantBits {
+					t.Errorf("key size = %d, want %d", got, tt.wantBits)
+				}
+			}
+
+			// PEM 编码后应能解析回相同的私钥
+			pemData, err := EncodePrivateKeyPEM(key)
+			if err != nil {
+				t.Fatalf("EncodePrivateKeyPEM() error = %v", err)
+			}
+			parsed, err := ParsePrivateKeyPEM(pemData)
+			if err != nil {
+				t.Fatalf("ParsePrivateKeyPEM() error = %v", err)
+			}
+			if !parsed.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(key.Public()) {
+				t.Error("parsed private key doesn't match original")
+			}
+		})
+	}
+}
+
+func TestNewECDSAPrivateKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		curve    Curve
+		wantBits int
+		wantErr  bool
+	}{
+		{name: "default curve", curve: "", wantBits: 256},
+		{name: "P256", curve: CurveP256, wantBits: 256},
+		{name: "P384", curve: CurveP384, wantBits: 384},
+		{name: "P521", curve: CurveP521, wantBits: 521},
+		{name: "unknown curve", curve: "P224", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewECDSAPrivateKey(tt.curve)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewECDSAPrivateKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := key.(*ecdsa.PrivateKey).Curve.Params().BitSize; got != tt.wantBits {
+				t.Errorf("curve size = %d, want %d", got, tt.wantBits)
+			}
+
+			pemData, err := EncodePrivateKeyPEM(key)
+			if err != nil {
+				t.Fatalf("EncodePrivateKeyPEM() error = %v", err)
+			}
+			parsed, err := ParsePrivateKeyPEM(pemData)
+			if err != nil {
+				t.Fatalf("ParsePrivateKeyPEM() error = %v", err)
+			}
+			if !parsed.(*ecdsa.PrivateKey).Equal(key) {
+				t.Error("parsed private key doesn't match original")
+			}
+		})
+	}
+}
+
+func TestNewCAWithCurve(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA, Curve: CurveP384})
+	if err != nil {
+		t.Fatalf("NewCA() error = %v", err)
+	}
+	if got := ca.PrivateKey.(*ecdsa.PrivateKey).Curve; got != elliptic.P384() {
+		t.Errorf("CA curve = %v, want P384", got.Params().Name)
+	}
+
+	pair, err := ca.NewSignedCert(Config{
+		CommonName: "server",
+		KeyType:    KeyTypeECDSA,
+		Curve:      CurveP521,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+	if err := pair.Certificate.CheckSignatureFrom(ca.Certificate); err != nil {
+		t.Errorf("Certificate signature verification failed: %v", err)
+	}
+
+	if _, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA, Curve: "P999"}); err == nil {
+		t.Error("NewCA() should fail with unknown curve")
+	}
+}
+
+func TestNewCAWithRSAKeySize(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", RSAKeySize: 3072})
+	if err != nil {
+		t.Fatalf("NewCA() error = %v", err)
+	}
+	if got := ca.PrivateKey.(*rsa.PrivateKey).N.BitLen(); got != 3072 {
+		t.Errorf("CA key size = %d, want 3072", got)
+	}
+
+	if _, err := NewCA(Config{CommonName: "Test CA", RSAKeySize: 1024}); err == nil {
+		t.Error("NewCA() should fail with 1024 bit RSA key")
+	}
+
+	pair, err := ca.NewSignedCert(Config{
+		CommonName: "server",
+		RSAKeySize: 3072,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+	if got := pair.PrivateKey.(*rsa.PrivateKey).N.BitLen(); got != 3072 {
+		t.Errorf("signed certificate key size = %d, want 3072", got)
+	}
+}
+
 func TestNewCA(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -220,8 +387,8 @@ func TestCA_NewSignedCert(t *testing.T) {
 				}
 
 				// 验证证书是由 CA 签发的
-				if err := certPair.Certificate.CheckSignatureFrom(ca.Certificate); err != nil {
-					t.Errorf("Certificate signature verification failed: %v", err)
+				if err := certPair.VerifyChain(ca); err != nil {
+					t.Errorf("CertKeyPair.VerifyChain() error = %v", err)
 				}
 
 				// 验证 SAN
@@ -491,6 +658,113 @@ func TestNewCertPoolFromPEM(t *testing.T) {
 	}
 }
 
+func TestKeyMatchesCert(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA"})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+
+	otherKey, err := NewPrivateKey(KeyTypeECDSA)
+	if err != nil {
+		t.Fatalf("NewPrivateKey() error = %v", err)
+	}
+
+	if !KeyMatchesCert(ca.PrivateKey, ca.Certificate) {
+		t.Error("KeyMatchesCert() should return true for the certificate's own key")
+	}
+	if KeyMatchesCert(otherKey, ca.Certificate) {
+		t.Error("KeyMatchesCert() should return false for a different key")
+	}
+	if KeyMatchesCert(nil, ca.Certificate) {
+		t.Error("KeyMatchesCert() should return false for nil key")
+	}
+}
+
+func TestLoadCAKeyMismatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	keyPath := filepath.Join(tmpDir, "ca.key")
+
+	ca, err := NewCA(Config{CommonName: "Test CA"})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	otherKey, err := NewPrivateKey(KeyTypeRSA)
+	if err != nil {
+		t.Fatalf("NewPrivateKey() error = %v", err)
+	}
+
+	if err := WriteCertAndKeyToFile(certPath, keyPath, ca.Certificate, otherKey); err != nil {
+		t.Fatalf("WriteCertAndKeyToFile() error = %v", err)
+	}
+
+	_, err = LoadCA(certPath, keyPath)
+	if !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("LoadCA() error = %v, want %v", err, ErrKeyMismatch)
+	}
+}
+
+func TestReadDERFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(ca.PrivateKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+
+	derCertPath := filepath.Join(tmpDir, "ca.der")
+	derKeyPath := filepath.Join(tmpDir, "ca.key.der")
+	if err := os.WriteFile(derCertPath, ca.Certificate.Raw, 0644); err != nil {
+		t.Fatalf("Failed to write DER certificate: %v", err)
+	}
+	if err := os.WriteFile(derKeyPath, keyDER, 0600); err != nil {
+		t.Fatalf("Failed to write DER private key: %v", err)
+	}
+
+	t.Run("DER", func(t *testing.T) {
+		cert, key, err := ReadCertAndKeyFromFile(derCertPath, derKeyPath)
+		if err != nil {
+			t.Fatalf("ReadCertAndKeyFromFile() error = %v", err)
+		}
+		if cert.Subject.CommonName != "Test CA" {
+			t.Errorf("Certificate CommonName = %v, want Test CA", cert.Subject.CommonName)
+		}
+		if !KeyMatchesCert(key, cert) {
+			t.Error("DER key should match DER certificate")
+		}
+	})
+
+	t.Run("PEM", func(t *testing.T) {
+		pemCertPath := filepath.Join(tmpDir, "ca.crt")
+		pemKeyPath := filepath.Join(tmpDir, "ca.key")
+		if err := ca.SaveToFile(pemCertPath, pemKeyPath); err != nil {
+			t.Fatalf("CA.SaveToFile() error = %v", err)
+		}
+
+		cert, key, err := ReadCertAndKeyFromFile(pemCertPath, pemKeyPath)
+		if err != nil {
+			t.Fatalf("ReadCertAndKeyFromFile() error = %v", err)
+		}
+		if !KeyMatchesCert(key, cert) {
+			t.Error("PEM key should match PEM certificate")
+		}
+	})
+
+	t.Run("InvalidDER", func(t *testing.T) {
+		if _, err := ParseCertDER([]byte("invalid data")); err == nil {
+			t.Error("ParseCertDER() should fail with invalid data")
+		}
+		if _, err := ParsePrivateKeyDER([]byte("invalid data")); err == nil {
+			t.Error("ParsePrivateKeyDER() should fail with invalid data")
+		}
+	})
+}
+
 // 示例：创建 CA
 func ExampleNewCA() {
 	ca, err := NewCA(Config{
@@ -734,3 +1008,1187 @@ func Example_base64Transfer() {
 	// Output:
 	// Received certificate: server
 }
+
+func TestConfigValidity(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA, ValidFor: 2 * time.Hour})
+	if err != nil {
+		t.Fatalf("NewCA() error = %v", err)
+	}
+	if got := ca.Certificate.NotAfter.Sub(ca.Certificate.NotBefore); got != 2*time.Hour {
+		t.Errorf("CA validity = %s, want 2h", got)
+	}
+
+	usages := []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	backdated := time.Now().Add(-5 * time.Minute).Truncate(time.Second)
+	tests := []struct {
+		name          string
+		cfg           Config
+		wantValidity  time.Duration
+		wantNotBefore time.Time
+		wantErr       bool
+	}{
+		{
+			name:         "valid for 90 minutes",
+			cfg:          Config{CommonName: "short", KeyType: KeyTypeECDSA, Usages: usages, ValidFor: 90 * time.Minute},
+			wantValidity: 90 * time.Minute,
+		},
+		{
+			name:          "backdated five minutes",
+			cfg:           Config{CommonName: "skew", KeyType: KeyTypeECDSA, Usages: usages, NotBefore: backdated, ValidFor: time.Hour},
+			wantValidity:  time.Hour,
+			wantNotBefore: backdated,
+		},
+		{
+			name:    "negative duration",
+			cfg:     Config{CommonName: "bad", KeyType: KeyTypeECDSA, Usages: usages, ValidFor: -time.Hour},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pair, err := ca.NewSignedCert(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CA.NewSignedCert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			cert := pair.Certificate
+			if got := cert.NotAfter.Sub(cert.NotBefore); got != tt.wantValidity {
+				t.Errorf("validity = %s, want %s", got, tt.wantValidity)
+			}
+			if !tt.wantNotBefore.IsZero() && !cert.NotBefore.Equal(tt.wantNotBefore) {
+				t.Errorf("NotBefore = %s, want %s", cert.NotBefore, tt.wantNotBefore)
+			}
+			if cert.NotBefore.After(time.Now()) {
+				t.Errorf("NotBefore = %s should not be in the future", cert.NotBefore)
+			}
+		})
+	}
+}
+
+func TestConfigValiditySkew(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("NewCA() error = %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	usages := []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	tests := []struct {
+		name          string
+		cfg           Config
+		wantNotBefore time.Time
+		wantNotAfter  time.Time
+		wantErr       bool
+	}{
+		{
+			name:          "30 days with skew",
+			cfg:           Config{NotBefore: start, ValidFor: 30 * 24 * time.Hour, NotBeforeSkew: 5 * time.Minute},
+			wantNotBefore: start.Add(-5 * time.Minute),
+			wantNotAfter:  start.Add(30 * 24 * time.Hour),
+		},
+		{
+			name:          "years with skew",
+			cfg:           Config{NotBefore: start, ValidYears: 1, NotBeforeSkew: time.Hour},
+			wantNotBefore: start.Add(-time.Hour),
+			wantNotAfter:  start.AddDate(1, 0, 0),
+		},
+		{
+			name:    "negative skew",
+			cfg:     Config{NotBefore: start, ValidFor: time.Hour, NotBeforeSkew: -time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "negative years",
+			cfg:     Config{NotBefore: start, ValidYears: -1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.CommonName = "server"
+			tt.cfg.KeyType = KeyTypeECDSA
+			tt.cfg.Usages = usages
+
+			pair, err := ca.NewSignedCert(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CA.NewSignedCert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !pair.Certificate.NotBefore.Equal(tt.wantNotBefore) {
+				t.Errorf("NotBefore = %s, want %s", pair.Certificate.NotBefore, tt.wantNotBefore)
+			}
+			if !pair.Certificate.NotAfter.Equal(tt.wantNotAfter) {
+				t.Errorf("NotAfter = %s, want %s", pair.Certificate.NotAfter, tt.wantNotAfter)
+			}
+		})
+	}
+
+	caCfg := Config{CommonName: "Skewed CA", KeyType: KeyTypeECDSA, NotBefore: start, ValidFor: time.Hour, NotBeforeSkew: time.Minute}
+	skewedCA, err := NewCA(caCfg)
+	if err != nil {
+		t.Fatalf("NewCA() error = %v", err)
+	}
+	if !skewedCA.Certificate.NotBefore.Equal(start.Add(-time.Minute)) || !skewedCA.Certificate.NotAfter.Equal(start.Add(time.Hour)) {
+		t.Errorf("CA validity = %s - %s", skewedCA.Certificate.NotBefore, skewedCA.Certificate.NotAfter)
+	}
+}
+
+func TestCertKeyPair_SaveChainToFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	rootCA, err := NewCA(Config{CommonName: "Root CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	pair, err := ca.NewSignedCert(Config{
+		CommonName: "server",
+		KeyType:    KeyTypeECDSA,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+
+	certPath := filepath.Join(tmpDir, "server.crt")
+	keyPath := filepath.Join(tmpDir, "server.key")
+	if err := pair.SaveChainToFile(certPath, keyPath, ca.Certificate, rootCA.Certificate); err != nil {
+		t.Fatalf("CertKeyPair.SaveChainToFile() error = %v", err)
+	}
+
+	certs, err := ReadCertsFromFile(certPath)
+	if err != nil {
+		t.Fatalf("ReadCertsFromFile() error = %v", err)
+	}
+	wantCNs := []string{"server", "Test CA", "Root CA"}
+	if len(certs) != len(wantCNs) {
+		t.Fatalf("ReadCertsFromFile() returned %d certificates, want %d", len(certs), len(wantCNs))
+	}
+	for i, cert := range certs {
+		if cert.Subject.CommonName != wantCNs[i] {
+			t.Errorf("certs[%d] CommonName = %s, want %s", i, cert.Subject.CommonName, wantCNs[i])
+		}
+	}
+
+	// 读取证书和私钥时使用第一个（叶子）证书
+	cert, key, err := ReadCertAndKeyFromFile(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("ReadCertAndKeyFromFile() error = %v", err)
+	}
+	if cert.Subject.CommonName != "server" || !KeyMatchesCert(key, cert) {
+		t.Error("leaf certificate should be first and match the private key")
+	}
+
+	if err := pair.SaveChainToFile(certPath, keyPath, nil); !errors.Is(err, ErrInvalidCertificate) {
+		t.Errorf("CertKeyPair.SaveChainToFile() error = %v, want %v", err, ErrInvalidCertificate)
+	}
+}
+
+func TestCertExpiry(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	pair, err := ca.NewSignedCert(Config{
+		CommonName: "server",
+		KeyType:    KeyTypeECDSA,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		ValidFor:   24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+
+	now := time.Now()
+	if pair.IsExpired(now) {
+		t.Error("IsExpired(now) = true, want false")
+	}
+	if !pair.IsExpired(now.Add(25 * time.Hour)) {
+		t.Error("IsExpired(now+25h) = false, want true")
+	}
+	if pair.NeedsRenewal(time.Hour) {
+		t.Error("NeedsRenewal(1h) = true, want false")
+	}
+	if !pair.NeedsRenewal(48 * time.Hour) {
+		t.Error("NeedsRenewal(48h) = false, want true")
+	}
+	if ca.NeedsRenewal(30 * 24 * time.Hour) {
+		t.Error("CA.NeedsRenewal(30d) = true, want false")
+	}
+	if !CertExpiresWithin(pair.Certificate, 48*time.Hour) || CertExpiresWithin(pair.Certificate, time.Hour) {
+		t.Error("CertExpiresWithin() returned unexpected result")
+	}
+
+	// 空证书视为已过期
+	var nilPair *CertKeyPair
+	if !nilPair.IsExpired(now) || !nilPair.NeedsRenewal(0) {
+		t.Error("nil CertKeyPair should be treated as expired")
+	}
+	if !(&CA{}).IsExpired(now) {
+		t.Error("CA without certificate should be treated as expired")
+	}
+	if !CertExpiresWithin(nil, 0) {
+		t.Error("CertExpiresWithin(nil) = false, want true")
+	}
+}
+
+func TestCA_RenewCert(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	old, err := ca.NewSignedCert(Config{
+		CommonName:   "test.example.com",
+		Organization: []string{"Test Org"},
+		KeyType:      KeyTypeECDSA,
+		AltNames: AltNames{
+			DNSNames: []string{"test.example.com", "*.test.example.com"},
+			IPs:      []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+		},
+		Usages:   []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		ValidFor: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+
+	renewed, err := ca.RenewCert(old.Certificate, KeyTypeRSA, 1)
+	if err != nil {
+		t.Fatalf("CA.RenewCert() error = %v", err)
+	}
+	got, want := renewed.Certificate, old.Certificate
+	if err := got.CheckSignatureFrom(ca.Certificate); err != nil {
+		t.Errorf("Certificate signature verification failed: %v", err)
+	}
+	if got.Subject.CommonName != want.Subject.CommonName || !reflect.DeepEqual(got.Subject.Organization, want.Subject.Organization) {
+		t.Errorf("Subject = %v, want %v", got.Subject, want.Subject)
+	}
+	if !reflect.DeepEqual(got.DNSNames, want.DNSNames) {
+		t.Errorf("DNSNames = %v, want %v", got.DNSNames, want.DNSNames)
+	}
+	if len(got.IPAddresses) != len(want.IPAddresses) {
+		t.Fatalf("IPAddresses = %v, want %v", got.IPAddresses, want.IPAddresses)
+	}
+	for i := range want.IPAddresses {
+		if !got.IPAddresses[i].Equal(want.IPAddresses[i]) {
+			t.Errorf("IPAddresses = %v, want %v", got.IPAddresses, want.IPAddresses)
+		}
+	}
+	if !reflect.DeepEqual(got.ExtKeyUsage, want.ExtKeyUsage) {
+		t.Errorf("ExtKeyUsage = %v, want %v", got.ExtKeyUsage, want.ExtKeyUsage)
+	}
+	if got.SerialNumber.Cmp(want.SerialNumber) == 0 {
+		t.Error("renewed certificate should have a new serial number")
+	}
+	if _, ok := renewed.PrivateKey.(*rsa.PrivateKey); !ok || KeyMatchesCert(old.PrivateKey, got) {
+		t.Error("renewed certificate should use a new RSA key")
+	}
+	if !got.NotAfter.After(want.NotAfter) {
+		t.Errorf("NotAfter = %s, should be after %s", got.NotAfter, want.NotAfter)
+	}
+
+	otherCA, err := NewCA(Config{CommonName: "Other CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	if _, err := otherCA.RenewCert(old.Certificate, KeyTypeECDSA, 1); err == nil {
+		t.Error("RenewCert() should fail for a certificate signed by another CA")
+	}
+	if _, err := ca.RenewCert(nil, KeyTypeECDSA, 1); !errors.Is(err, ErrInvalidCertificate) {
+		t.Errorf("RenewCert(nil) error = %v, want %v", err, ErrInvalidCertificate)
+	}
+}
+
+func TestCA_NewIntermediateCA(t *testing.T) {
+	root, err := NewCA(Config{CommonName: "Root CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	intermediate, err := root.NewIntermediateCA(Config{CommonName: "Intermediate CA", KeyType: KeyTypeRSA})
+	if err != nil {
+		t.Fatalf("CA.NewIntermediateCA() error = %v", err)
+	}
+
+	cert := intermediate.Certificate
+	if !cert.IsCA || cert.MaxPathLen != 0 || !cert.MaxPathLenZero {
+		t.Errorf("IsCA = %v, MaxPathLen = %d, MaxPathLenZero = %v", cert.IsCA, cert.MaxPathLen, cert.MaxPathLenZero)
+	}
+	if cert.Issuer.CommonName != "Root CA" {
+		t.Errorf("Issuer = %s, want Root CA", cert.Issuer.CommonName)
+	}
+	if err := cert.CheckSignatureFrom(root.Certificate); err != nil {
+		t.Errorf("Intermediate signature verification failed: %v", err)
+	}
+
+	leaf, err := intermediate.NewSignedCert(Config{
+		CommonName: "test.example.com",
+		KeyType:    KeyTypeECDSA,
+		AltNames:   AltNames{DNSNames: []string{"test.example.com"}},
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(intermediate.Certificate)
+	chains, err := leaf.Certificate.Verify(x509.VerifyOptions{
+		DNSName:       "test.example.com",
+		Roots:         NewCertPool(root.Certificate),
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(chains) != 1 || len(chains[0]) != 3 {
+		t.Errorf("Verify() chains = %v, want one chain of length 3", chains)
+	}
+
+	// 中间 CA 不能再签发下级 CA
+	sub, err := intermediate.NewIntermediateCA(Config{CommonName: "Sub CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("CA.NewIntermediateCA() error = %v", err)
+	}
+	subLeaf, err := sub.NewSignedCert(Config{CommonName: "leaf", KeyType: KeyTypeECDSA, Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+	intermediates.AddCert(sub.Certificate)
+	if _, err := subLeaf.Certificate.Verify(x509.VerifyOptions{Roots: NewCertPool(root.Certificate), Intermediates: intermediates}); err == nil {
+		t.Error("Verify() should fail when the path length constraint is exceeded")
+	}
+
+	if _, err := root.NewIntermediateCA(Config{}); err == nil {
+		t.Error("CA.NewIntermediateCA() should fail without common name")
+	}
+}
+
+func TestVerifyCertKeyMatch(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	rsaKey, err := NewPrivateKey(KeyTypeRSA)
+	if err != nil {
+		t.Fatalf("NewPrivateKey() error = %v", err)
+	}
+	ecKey, err := NewPrivateKey(KeyTypeECDSA)
+	if err != nil {
+		t.Fatalf("NewPrivateKey() error = %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() error = %v", err)
+	}
+	edTmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "ed25519"}}
+	edDER, err := x509.CreateCertificate(nil, edTmpl, edTmpl, edKey.Public(), edKey)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() error = %v", err)
+	}
+	edCert, err := x509.ParseCertificate(edDER)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cert    *x509.Certificate
+		key     crypto.Signer
+		wantErr error
+	}{
+		{name: "ECDSA matched", cert: ca.Certificate, key: ca.PrivateKey},
+		{name: "Ed25519 matched", cert: edCert, key: edKey},
+		{name: "ECDSA mismatched", cert: ca.Certificate, key: ecKey, wantErr: ErrKeyMismatch},
+		{name: "key type mismatched", cert: ca.Certificate, key: rsaKey, wantErr: ErrKeyMismatch},
+		{name: "Ed25519 mismatched", cert: edCert, key: ecKey, wantErr: ErrKeyMismatch},
+		{name: "nil certificate", key: ecKey, wantErr: ErrInvalidCertificate},
+		{name: "nil key", cert: ca.Certificate, wantErr: ErrInvalidPrivateKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyCertKeyMatch(tt.cert, tt.key)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("VerifyCertKeyMatch() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 从 base64 加载不匹配的证书和私钥时失败
+	certBase64, err := EncodeCertToBase64(ca.Certificate)
+	if err != nil {
+		t.Fatalf("EncodeCertToBase64() error = %v", err)
+	}
+	keyBase64, err := EncodePrivateKeyToBase64(ecKey)
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyToBase64() error = %v", err)
+	}
+	if _, err := LoadCertKeyPairFromBase64(certBase64, keyBase64); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("LoadCertKeyPairFromBase64() error = %v, want %v", err, ErrKeyMismatch)
+	}
+	if _, err := LoadCAFromBase64(certBase64, keyBase64); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("LoadCAFromBase64() error = %v, want %v", err, ErrKeyMismatch)
+	}
+}
+
+func TestNewCertPoolFromFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	usages := []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	var leaves []*x509.Certificate
+	var paths []string
+	for i, cn := range []string{"CA A", "CA B"} {
+		ca, err := NewCA(Config{CommonName: cn, KeyType: KeyTypeECDSA})
+		if err != nil {
+			t.Fatalf("Failed to create CA: %v", err)
+		}
+		pair, err := ca.NewSignedCert(Config{CommonName: fmt.Sprintf("leaf-%d", i), KeyType: KeyTypeECDSA, Usages: usages})
+		if err != nil {
+			t.Fatalf("CA.NewSignedCert() error = %v", err)
+		}
+		leaves = append(leaves, pair.Certificate)
+
+		path := filepath.Join(tmpDir, fmt.Sprintf("ca-%d.crt", i))
+		if err := WriteCertToFile(path, ca.Certificate); err != nil {
+			t.Fatalf("WriteCertToFile() error = %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	// 一个文件中包含多个证书
+	extraCA, err := NewCA(Config{CommonName: "CA C", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	extraLeaf, err := extraCA.NewSignedCert(Config{CommonName: "leaf-c", KeyType: KeyTypeECDSA, Usages: usages})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+	bundlePath := filepath.Join(tmpDir, "bundle.crt")
+	if err := WriteCertsToFile(bundlePath, extraCA.Certificate, extraLeaf.Certificate); err != nil {
+		t.Fatalf("WriteCertsToFile() error = %v", err)
+	}
+	leaves = append(leaves, extraLeaf.Certificate)
+	paths = append(paths, bundlePath)
+
+	pool, err := NewCertPoolFromFiles(paths...)
+	if err != nil {
+		t.Fatalf("NewCertPoolFromFiles() error = %v", err)
+	}
+	for _, leaf := range leaves {
+		if _, err := leaf.Verify(x509.VerifyOptions{Roots: pool}); err != nil {
+			t.Errorf("Verify(%s) error = %v", leaf.Subject.CommonName, err)
+		}
+	}
+
+	if _, err := NewCertPoolFromFiles(paths[0], filepath.Join(tmpDir, "missing.crt")); err == nil {
+		t.Error("NewCertPoolFromFiles() should fail for missing file")
+	}
+
+	// AppendCertsToPool 合并 PEM 数据
+	pool = NewCertPool()
+	if err := AppendCertsToPool(pool, EncodeCertPEM(extraCA.Certificate)); err != nil {
+		t.Fatalf("AppendCertsToPool() error = %v", err)
+	}
+	if _, err := extraLeaf.Certificate.Verify(x509.VerifyOptions{Roots: pool}); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := AppendCertsToPool(pool, []byte("invalid")); !errors.Is(err, ErrNoCertificateFound) {
+		t.Errorf("AppendCertsToPool() error = %v, want %v", err, ErrNoCertificateFound)
+	}
+}
+
+func TestCA_NewSignedCertRevocationInfo(t *testing.T) {
+	ca, err := NewCA(Config{
+		CommonName:            "Test CA",
+		KeyType:               KeyTypeECDSA,
+		CRLDistributionPoints: []string{"http://ca.example.com/ignored.crl"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	if len(ca.Certificate.CRLDistributionPoints) != 0 {
+		t.Errorf("CA CRLDistributionPoints = %v, want none", ca.Certificate.CRLDistributionPoints)
+	}
+
+	crls := []string{"http://ca.example.com/ca.crl"}
+	ocsp := []string{"http://ocsp.example.com"}
+	pair, err := ca.NewSignedCert(Config{
+		CommonName:            "leaf",
+		KeyType:               KeyTypeECDSA,
+		Usages:                []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		CRLDistributionPoints: crls,
+		OCSPServers:           ocsp,
+	})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+
+	parsed, err := ParseCertsPEM(EncodeCertPEM(pair.Certificate))
+	if err != nil {
+		t.Fatalf("ParseCertsPEM() error = %v", err)
+	}
+	if !reflect.DeepEqual(parsed[0].CRLDistributionPoints, crls) {
+		t.Errorf("CRLDistributionPoints = %v, want %v", parsed[0].CRLDistributionPoints, crls)
+	}
+	if !reflect.DeepEqual(parsed[0].OCSPServer, ocsp) {
+		t.Errorf("OCSPServer = %v, want %v", parsed[0].OCSPServer, ocsp)
+	}
+}
+
+type counterSerialSource struct {
+	next  int64
+	calls int
+}
+
+func (s *counterSerialSource) NextSerial() (*big.Int, error) {
+	s.calls++
+	s.next++
+	return big.NewInt(s.next), nil
+}
+
+func TestSerialNumber(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	leafCfg := Config{CommonName: "leaf", KeyType: KeyTypeECDSA, Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}
+
+	// 默认随机序列号应超过 64 位
+	seen := map[string]bool{}
+	wide := 0
+	for i := 0; i < 8; i++ {
+		pair, err := ca.NewSignedCert(leafCfg)
+		if err != nil {
+			t.Fatalf("CA.NewSignedCert() error = %v", err)
+		}
+		serial := pair.Certificate.SerialNumber
+		if serial.BitLen() > 64 {
+			wide++
+		}
+		if seen[serial.String()] {
+			t.Errorf("duplicate serial number %s", serial)
+		}
+		seen[serial.String()] = true
+	}
+	if wide == 0 {
+		t.Error("random serial numbers should be wider than 64 bits")
+	}
+
+	source := &counterSerialSource{next: 100}
+	ca.SerialSource = source
+	pair, err := ca.NewSignedCert(leafCfg)
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+	if pair.Certificate.SerialNumber.Int64() != 101 {
+		t.Errorf("SerialNumber = %s, want 101", pair.Certificate.SerialNumber)
+	}
+	intermediate, err := ca.NewIntermediateCA(Config{CommonName: "Intermediate", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("CA.NewIntermediateCA() error = %v", err)
+	}
+	if intermediate.Certificate.SerialNumber.Int64() != 102 {
+		t.Errorf("intermediate SerialNumber = %s, want 102", intermediate.Certificate.SerialNumber)
+	}
+	if source.calls != 2 {
+		t.Errorf("SerialSource called %d times, want 2", source.calls)
+	}
+
+	source.next = -1
+	if _, err := ca.NewSignedCert(leafCfg); err == nil {
+		t.Error("CA.NewSignedCert() should reject non-positive serial numbers")
+	}
+}
+
+func TestConfigSubject(t *testing.T) {
+	cfg := Config{
+		CommonName:          "Test CA",
+		Organization:        []string{"Valhalla"},
+		OrganizationalUnit:  []string{"Platform"},
+		Country:             []string{"CN"},
+		Province:            []string{"Zhejiang"},
+		Locality:            []string{"Hangzhou"},
+		SubjectSerialNumber: "0001",
+		KeyType:             KeyTypeECDSA,
+	}
+	ca, err := NewCA(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	leafCfg := cfg
+	leafCfg.CommonName = "leaf"
+	leafCfg.Usages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	pair, err := ca.NewSignedCert(leafCfg)
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+
+	for _, c := range []struct {
+		cert *x509.Certificate
+		cn   string
+	}{{ca.Certificate, "Test CA"}, {pair.Certificate, "leaf"}} {
+		parsed, err := ParseCertsPEM(EncodeCertPEM(c.cert))
+		if err != nil {
+			t.Fatalf("ParseCertsPEM() error = %v", err)
+		}
+		subject := parsed[0].Subject
+		want := pkix.Name{
+			CommonName:         c.cn,
+			Organization:       cfg.Organization,
+			OrganizationalUnit: cfg.OrganizationalUnit,
+			Country:            cfg.Country,
+			Province:           cfg.Province,
+			Locality:           cfg.Locality,
+			SerialNumber:       cfg.SubjectSerialNumber,
+		}
+		subject.Names = nil
+		if !reflect.DeepEqual(subject, want) {
+			t.Errorf("Subject = %+v, want %+v", subject, want)
+		}
+	}
+
+	// 未设置的字段不出现在主题中
+	plain, err := NewCA(Config{CommonName: "Plain CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	if got := plain.Certificate.Subject.String(); got != "CN=Plain CA" {
+		t.Errorf("Subject = %s, want CN=Plain CA", got)
+	}
+}
+
+func TestCertKeyPair_BundleFile(t *testing.T) {
+	for _, keyType := range []KeyType{KeyTypeRSA, KeyTypeECDSA} {
+		t.Run(string(keyType), func(t *testing.T) {
+			ca, err := NewCA(Config{CommonName: "Test CA", KeyType: keyType})
+			if err != nil {
+				t.Fatalf("Failed to create CA: %v", err)
+			}
+			pair, err := ca.NewSignedCert(Config{CommonName: "server", KeyType: keyType, Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+			if err != nil {
+				t.Fatalf("CA.NewSignedCert() error = %v", err)
+			}
+			tmpDir := t.TempDir()
+
+			path := filepath.Join(tmpDir, "bundle.pem")
+			if err := pair.SaveToBundleFile(path); err != nil {
+				t.Fatalf("SaveToBundleFile() error = %v", err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("Stat() error = %v", err)
+			}
+			if info.Mode().Perm() != keyFileMode {
+				t.Errorf("bundle file mode = %o, want %o", info.Mode().Perm(), keyFileMode)
+			}
+
+			loaded, err := LoadCertKeyPairFromBundleFile(path)
+			if err != nil {
+				t.Fatalf("LoadCertKeyPairFromBundleFile() error = %v", err)
+			}
+			if !loaded.Certificate.Equal(pair.Certificate) || !KeyMatchesCert(loaded.PrivateKey, loaded.Certificate) {
+				t.Error("loaded pair does not match the saved pair")
+			}
+
+			// 私钥在前且包含中间证书
+			keyPEM, err := EncodePrivateKeyPEM(pair.PrivateKey)
+			if err != nil {
+				t.Fatalf("EncodePrivateKeyPEM() error = %v", err)
+			}
+			reordered := append(keyPEM, EncodeCertPEM(ca.Certificate)...)
+			reordered = append(reordered, EncodeCertPEM(pair.Certificate)...)
+			reorderedPath := filepath.Join(tmpDir, "reordered.pem")
+			if err := os.WriteFile(reorderedPath, reordered, 0600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			loaded, err = LoadCertKeyPairFromBundleFile(reorderedPath)
+			if err != nil {
+				t.Fatalf("LoadCertKeyPairFromBundleFile() error = %v", err)
+			}
+			if !loaded.Certificate.Equal(pair.Certificate) {
+				t.Errorf("loaded certificate = %s, want the leaf matching the key", loaded.Certificate.Subject.CommonName)
+			}
+
+			// 只有不匹配的证书
+			mismatched := append(keyPEM, EncodeCertPEM(ca.Certificate)...)
+			mismatchedPath := filepath.Join(tmpDir, "mismatched.pem")
+			if err := os.WriteFile(mismatchedPath, mismatched, 0600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			if _, err := LoadCertKeyPairFromBundleFile(mismatchedPath); !errors.Is(err, ErrKeyMismatch) {
+				t.Errorf("LoadCertKeyPairFromBundleFile() error = %v, want %v", err, ErrKeyMismatch)
+			}
+		})
+	}
+}
+
+func TestAltNamesEmailAndURI(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	spiffeID, err := url.Parse("spiffe://example.org/ns/default/sa/app")
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	altNames := AltNames{
+		EmailAddresses: []string{"alice@example.com"},
+		URIs:           []*url.URL{spiffeID},
+	}
+	pair, err := ca.NewSignedCert(Config{
+		CommonName: "app",
+		KeyType:    KeyTypeECDSA,
+		AltNames:   altNames,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+
+	parsed, err := ParseCertsPEM(EncodeCertPEM(pair.Certificate))
+	if err != nil {
+		t.Fatalf("ParseCertsPEM() error = %v", err)
+	}
+	if !reflect.DeepEqual(parsed[0].EmailAddresses, altNames.EmailAddresses) {
+		t.Errorf("EmailAddresses = %v, want %v", parsed[0].EmailAddresses, altNames.EmailAddresses)
+	}
+	if len(parsed[0].URIs) != 1 || parsed[0].URIs[0].String() != spiffeID.String() {
+		t.Errorf("URIs = %v, want %v", parsed[0].URIs, altNames.URIs)
+	}
+
+	// 续期时保留邮箱和 URI
+	renewed, err := ca.RenewCert(pair.Certificate, KeyTypeECDSA, 1)
+	if err != nil {
+		t.Fatalf("CA.RenewCert() error = %v", err)
+	}
+	if !reflect.DeepEqual(renewed.Certificate.EmailAddresses, altNames.EmailAddresses) || len(renewed.Certificate.URIs) != 1 {
+		t.Errorf("renewed SANs = %v, %v", renewed.Certificate.EmailAddresses, renewed.Certificate.URIs)
+	}
+}
+
+func TestAtomicWriteFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldCA, err := NewCA(Config{CommonName: "Old CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	newCA, err := NewCA(Config{CommonName: "New CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	keyPath := filepath.Join(tmpDir, "ca.key")
+	if err := oldCA.SaveToFile(certPath, keyPath); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+
+	// 轮换前已打开旧文件的读取方
+	reader, err := os.Open(certPath)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer reader.Close()
+
+	if err := newCA.SaveToFile(certPath, keyPath); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+
+	oldData, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if certs, err := ParseCertsPEM(oldData); err != nil || !certs[0].Equal(oldCA.Certificate) {
+		t.Errorf("open reader should still see the old certificate, err = %v", err)
+	}
+	loaded, err := LoadCA(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("LoadCA() error = %v", err)
+	}
+	if !loaded.Certificate.Equal(newCA.Certificate) {
+		t.Error("new readers should see the new certificate")
+	}
+
+	for path, want := range map[string]os.FileMode{certPath: certFileMode, keyPath: keyFileMode} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat() error = %v", err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("%s mode = %o, want %o", path, info.Mode().Perm(), want)
+		}
+	}
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("directory should only contain cert and key, got %d entries", len(entries))
+	}
+
+	// 证书写入失败时恢复原有私钥
+	if err := WriteCertAndKeyToFile(certPath, keyPath, nil, oldCA.PrivateKey); err == nil {
+		t.Fatal("WriteCertAndKeyToFile() should fail with nil certificate")
+	}
+	if _, _, err := ReadCertAndKeyFromFile(certPath, keyPath); err != nil {
+		t.Errorf("cert and key should still match after failed write: %v", err)
+	}
+
+	// 私钥原本不存在时删除
+	orphanKey := filepath.Join(tmpDir, "orphan.key")
+	if err := WriteCertAndKeyToFile(filepath.Join(tmpDir, "orphan.crt"), orphanKey, nil, oldCA.PrivateKey); err == nil {
+		t.Fatal("WriteCertAndKeyToFile() should fail with nil certificate")
+	}
+	if _, err := os.Stat(orphanKey); !os.IsNotExist(err) {
+		t.Errorf("orphaned key should be removed, stat error = %v", err)
+	}
+}
+
+func TestAtomicWriteFileReplacesSymlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "data", "tls.crt")
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpDir, "tls.crt")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlink not supported: %v", err)
+	}
+
+	if err := writeFile(link, []byte("new"), certFileMode); err != nil {
+		t.Fatalf("writeFile() error = %v", err)
+	}
+	// 替换链接本身，链接指向的文件不变
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("Lstat() = %v, %v, want a regular file", info, err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "old" {
+		t.Errorf("symlink target = %q, want it unchanged", data)
+	}
+	if data, _ := os.ReadFile(link); string(data) != "new" {
+		t.Errorf("file = %q, want new", data)
+	}
+}
+
+func TestRandomSerialNumber(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		serial, err := randomSerialNumber()
+		if err != nil {
+			t.Fatalf("randomSerialNumber() error = %v", err)
+		}
+		if serial.Sign() <= 0 {
+			t.Fatalf("serial number %s should be positive", serial)
+		}
+		if serial.Cmp(serialNumberLimit) >= 0 {
+			t.Fatalf("serial number %s exceeds 128 bits", serial)
+		}
+		if seen[serial.String()] {
+			t.Fatalf("duplicate serial number %s", serial)
+		}
+		seen[serial.String()] = true
+	}
+}
+
+func TestAltNamesSerialization(t *testing.T) {
+	spiffeID, _ := url.Parse("spiffe://example.org/workload")
+	cfg := Config{
+		CommonName: "workload",
+		AltNames: AltNames{
+			DNSNames:       []string{"workload.example.org"},
+			IPs:            []net.IP{net.ParseIP("10.0.0.1")},
+			EmailAddresses: []string{"ops@example.org"},
+			URIs:           []*url.URL{spiffeID},
+		},
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"uris":["spiffe://example.org/workload"]`) {
+		t.Errorf("URIs should be serialized as strings: %s", data)
+	}
+	var decoded Config
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded.AltNames.toText(), cfg.AltNames.toText()) {
+		t.Errorf("decoded AltNames = %+v, want %+v", decoded.AltNames, cfg.AltNames)
+	}
+
+	// YAML 使用同样的字符串格式
+	out, err := cfg.AltNames.MarshalYAML()
+	if err != nil {
+		t.Fatalf("MarshalYAML() error = %v", err)
+	}
+	var fromYAML AltNames
+	if err := fromYAML.UnmarshalYAML(func(v interface{}) error {
+		*(v.(*altNamesText)) = out.(altNamesText)
+		return nil
+	}); err != nil {
+		t.Fatalf("UnmarshalYAML() error = %v", err)
+	}
+	if len(fromYAML.URIs) != 1 || fromYAML.URIs[0].String() != spiffeID.String() {
+		t.Errorf("UnmarshalYAML() URIs = %v", fromYAML.URIs)
+	}
+
+	for _, raw := range []string{`{"uris":["relative/path"]}`, `{"uris":["://missing-scheme"]}`, `{"uris":["spiffe:"]}`} {
+		var a AltNames
+		if err := json.Unmarshal([]byte(raw), &a); err == nil {
+			t.Errorf("json.Unmarshal(%s) should fail", raw)
+		}
+	}
+
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	_, err = ca.NewSignedCert(Config{
+		CommonName: "workload",
+		KeyType:    KeyTypeECDSA,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		AltNames:   AltNames{URIs: []*url.URL{{Path: "relative"}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid URI SAN") {
+		t.Errorf("NewSignedCert() error = %v, want invalid URI SAN", err)
+	}
+}
+
+func TestCertKeyPair_VerifyChain(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	other, err := NewCA(Config{CommonName: "Other CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	newLeaf := func(cfg Config) *CertKeyPair {
+		cfg.CommonName = "leaf"
+		cfg.KeyType = KeyTypeECDSA
+		cfg.Usages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		pair, err := ca.NewSignedCert(cfg)
+		if err != nil {
+			t.Fatalf("CA.NewSignedCert() error = %v", err)
+		}
+		return pair
+	}
+
+	valid := newLeaf(Config{})
+	expired := newLeaf(Config{NotBefore: time.Now().Add(-2 * time.Hour), ValidFor: time.Hour})
+	notYetValid := newLeaf(Config{NotBefore: time.Now().Add(time.Hour), ValidFor: time.Hour})
+	otherKey, err := NewPrivateKey(KeyTypeECDSA)
+	if err != nil {
+		t.Fatalf("NewPrivateKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pair    *CertKeyPair
+		ca      *CA
+		wantErr error
+	}{
+		{name: "valid", pair: valid, ca: ca},
+		{name: "expired", pair: expired, ca: ca, wantErr: ErrCertificateExpired},
+		{name: "not yet valid", pair: notYetValid, ca: ca, wantErr: ErrCertificateNotYetValid},
+		{name: "key mismatch", pair: &CertKeyPair{Certificate: valid.Certificate, PrivateKey: otherKey}, ca: ca, wantErr: ErrKeyMismatch},
+		{name: "other CA", pair: valid, ca: other, wantErr: ErrNotSignedByCA},
+		{name: "nil CA", pair: valid, wantErr: ErrInvalidCertificate},
+		{name: "nil pair", ca: ca, wantErr: ErrInvalidCertificate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pair.VerifyChain(tt.ca)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("VerifyChain() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyChain() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSelfSignedCert(t *testing.T) {
+	if _, err := NewSelfSignedCert(Config{Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}); err == nil {
+		t.Error("NewSelfSignedCert() without common name should fail")
+	}
+	if _, err := NewSelfSignedCert(Config{CommonName: "localhost"}); err == nil {
+		t.Error("NewSelfSignedCert() without usages should fail")
+	}
+
+	ckp, err := NewSelfSignedCert(Config{
+		CommonName: "localhost",
+		KeyType:    KeyTypeECDSA,
+		AltNames: AltNames{
+			DNSNames: []string{"localhost"},
+			IPs:      []net.IP{net.ParseIP("127.0.0.1")},
+		},
+		Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		t.Fatalf("NewSelfSignedCert() error = %v", err)
+	}
+	cert := ckp.Certificate
+	if cert.IsCA {
+		t.Error("self-signed certificate should not be a CA")
+	}
+	if cert.Subject.String() != cert.Issuer.String() {
+		t.Errorf("issuer = %s, want %s", cert.Issuer, cert.Subject)
+	}
+	if cert.SerialNumber.Sign() <= 0 {
+		t.Errorf("serial number = %s, want positive", cert.SerialNumber)
+	}
+	if years := cert.NotAfter.Year() - cert.NotBefore.Year(); years != defaultValidYears {
+		t.Errorf("validity = %d years, want %d", years, defaultValidYears)
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		DNSName:   "localhost",
+		Roots:     NewCertPool(cert),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	keyPEM, err := EncodePrivateKeyPEM(ckp.PrivateKey)
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyPEM() error = %v", err)
+	}
+	tlsCert, err := tls.X509KeyPair(EncodeCertPEM(cert), keyPEM)
+	if err != nil {
+		t.Fatalf("tls.X509KeyPair() error = %v", err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	server := tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{tlsCert}})
+	client := tls.Client(clientConn, &tls.Config{ServerName: "localhost", RootCAs: NewCertPool(cert)})
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Handshake() }()
+	if err := client.Handshake(); err != nil {
+		t.Errorf("client Handshake() error = %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Errorf("server Handshake() error = %v", err)
+	}
+}
+
+func TestPublicKeyPEM(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("NewCA() error = %v", err)
+	}
+	ckp, err := ca.NewSignedCert(Config{
+		CommonName: "server",
+		KeyType:    KeyTypeRSA,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		t.Fatalf("NewSignedCert() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		export func() ([]byte, error)
+		cert   *x509.Certificate
+	}{
+		{name: "CA", export: ca.PublicKeyPEM, cert: ca.Certificate},
+		{name: "CertKeyPair", export: ckp.PublicKeyPEM, cert: ckp.Certificate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pemData, err := tt.export()
+			if err != nil {
+				t.Fatalf("PublicKeyPEM() error = %v", err)
+			}
+			pub, err := ParsePublicKeyPEM(pemData)
+			if err != nil {
+				t.Fatalf("ParsePublicKeyPEM() error = %v", err)
+			}
+			if !pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(tt.cert.PublicKey) {
+				t.Error("exported public key does not match the certificate")
+			}
+		})
+	}
+
+	if _, err := (&CertKeyPair{}).PublicKeyPEM(); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("PublicKeyPEM() without private key error = %v, want ErrInvalidPrivateKey", err)
+	}
+}
+
+func TestCA_NewSignedCerts(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("NewCA() error = %v", err)
+	}
+
+	var cfgs []Config
+	for i := 0; i < 8; i++ {
+		cfgs = append(cfgs, Config{
+			CommonName: fmt.Sprintf("host-%d", i),
+			KeyType:    KeyTypeECDSA,
+			Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		})
+	}
+	// 无效配置不影响其他证书的签发
+	cfgs[3].CommonName = ""
+	cfgs[5].Usages = nil
+
+	for _, concurrency := range []int{0, 1, 3, 100} {
+		t.Run(fmt.Sprintf("concurrency=%d", concurrency), func(t *testing.T) {
+			pairs, err := ca.NewSignedCerts(cfgs, concurrency)
+			if err == nil {
+				t.Fatal("NewSignedCerts() should report invalid configs")
+			}
+			for _, want := range []string{"config 3", "config 5 (host-5)"} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("NewSignedCerts() error = %v, missing %q", err, want)
+				}
+			}
+			if len(pairs) != len(cfgs) {
+				t.Fatalf("len(pairs) = %d, want %d", len(pairs), len(cfgs))
+			}
+			for i, pair := range pairs {
+				if i == 3 || i == 5 {
+					if pair != nil {
+						t.Errorf("pairs[%d] should be nil for an invalid config", i)
+					}
+					continue
+				}
+				if pair == nil {
+					t.Fatalf("pairs[%d] is nil", i)
+				}
+				if pair.Certificate.Subject.CommonName != cfgs[i].CommonName {
+					t.Errorf("pairs[%d] CN = %s, want %s", i, pair.Certificate.Subject.CommonName, cfgs[i].CommonName)
+				}
+				if err := pair.VerifyChain(ca); err != nil {
+					t.Errorf("pairs[%d].VerifyChain() error = %v", i, err)
+				}
+			}
+		})
+	}
+
+	if pairs, err := ca.NewSignedCerts(nil, 4); err != nil || len(pairs) != 0 {
+		t.Errorf("NewSignedCerts(nil) = %v, %v", pairs, err)
+	}
+}
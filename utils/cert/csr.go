@@ -0,0 +1,125 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// NewCertificateRequest 使用私钥生成 PEM 格式的证书请求（CSR）
+// 证书请求包含 cfg 中的主题字段和备用名称，私钥不需要离开请求方
+func NewCertificateRequest(cfg Config, key crypto.Signer) ([]byte, error) {
+	if key == nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	if cfg.CommonName == "" {
+		return nil, errors.New("common name is required")
+	}
+	if err := cfg.AltNames.validate(); err != nil {
+		return nil, err
+	}
+
+	tmpl := x509.CertificateRequest{
+		Subject:        cfg.subject(),
+		DNSNames:       cfg.AltNames.DNSNames,
+		IPAddresses:    cfg.AltNames.IPs,
+		EmailAddresses: cfg.AltNames.EmailAddresses,
+		URIs:           cfg.AltNames.URIs,
+	}
+
+	derBytes, err := x509.CreateCertificateRequest(rand.Reader, &tmpl, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+
+	block := pem.Block{
+		Type:  CertificateRequestBlockType,
+		Bytes: derBytes,
+	}
+	return pem.EncodeToMemory(&block), nil
+}
+
+// ParseCertificateRequestPEM 从 PEM 数据中解析证书请求并校验其签名
+func ParseCertificateRequestPEM(pemData []byte) (*x509.CertificateRequest, error) {
+	for len(pemData) > 0 {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+		if block.Type != CertificateRequestBlockType {
+			continue
+		}
+
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCertificateRequest, err)
+		}
+		if err := csr.CheckSignature(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCertificateRequest, err)
+		}
+		return csr, nil
+	}
+
+	return nil, ErrNoCertificateRequestFound
+}
+
+// SignCSR 使用 CA 签发证书请求
+// 证书的密钥用途和有效期由 cfg 指定；cfg 中的主题字段和 cfg.AltNames
+// 为空时使用证书请求中的值，否则以 cfg 为准
+func (ca *CA) SignCSR(csrPEM []byte, cfg Config) (*x509.Certificate, error) {
+	csr, err := ParseCertificateRequestPEM(csrPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.CommonName == "" {
+		cfg.CommonName = csr.Subject.CommonName
+	}
+	if len(cfg.Organization) == 0 {
+		cfg.Organization = csr.Subject.Organization
+	}
+	if len(cfg.OrganizationalUnit) == 0 {
+		cfg.OrganizationalUnit = csr.Subject.OrganizationalUnit
+	}
+	if len(cfg.Country) == 0 {
+		cfg.Country = csr.Subject.Country
+	}
+	if len(cfg.Province) == 0 {
+		cfg.Province = csr.Subject.Province
+	}
+	if len(cfg.Locality) == 0 {
+		cfg.Locality = csr.Subject.Locality
+	}
+	if cfg.SubjectSerialNumber == "" {
+		cfg.SubjectSerialNumber = csr.Subject.SerialNumber
+	}
+	if cfg.AltNames.isEmpty() {
+		cfg.AltNames = AltNames{
+			DNSNames:       csr.DNSNames,
+			IPs:            csr.IPAddresses,
+			EmailAddresses: csr.EmailAddresses,
+			URIs:           csr.URIs,
+		}
+	}
+	if cfg.CommonName == "" {
+		return nil, errors.New("common name is required")
+	}
+	if len(cfg.Usages) == 0 {
+		return nil, errors.New("at least one key usage is required")
+	}
+
+	// 设置默认值
+	if cfg.ValidYears == 0 {
+		cfg.ValidYears = defaultValidYears
+	}
+
+	cert, err := ca.signCert(csr.PublicKey, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+	return cert, nil
+}
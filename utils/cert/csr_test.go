@@ -0,0 +1,180 @@
+package cert
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestCA_SignCSR(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+
+	// 请求方生成私钥和证书请求
+	key, err := NewPrivateKey(KeyTypeECDSA)
+	if err != nil {
+		t.Fatalf("Failed to create private key: %v", err)
+	}
+	csrPEM, err := NewCertificateRequest(Config{
+		CommonName:   "test.example.com",
+		Organization: []string{"Test Org"},
+	}, key)
+	if err != nil {
+		t.Fatalf("NewCertificateRequest() error = %v", err)
+	}
+
+	cert, err := ca.SignCSR(csrPEM, Config{
+		AltNames: AltNames{
+			DNSNames: []string{"test.example.com"},
+			IPs:      []net.IP{net.ParseIP("127.0.0.1")},
+		},
+		Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		t.Fatalf("SignCSR() error = %v", err)
+	}
+
+	if err := cert.CheckSignatureFrom(ca.Certificate); err != nil {
+		t.Errorf("Certificate signature verification failed: %v", err)
+	}
+	if !KeyMatchesCert(key, cert) {
+		t.Error("Certificate public key doesn't match requester key")
+	}
+	if cert.Subject.CommonName != "test.example.com" {
+		t.Errorf("CommonName = %s, want test.example.com", cert.Subject.CommonName)
+	}
+	if len(cert.Subject.Organization) != 1 || cert.Subject.Organization[0] != "Test Org" {
+		t.Errorf("Organization = %v, want [Test Org]", cert.Subject.Organization)
+	}
+	if len(cert.DNSNames) != 1 || len(cert.IPAddresses) != 1 {
+		t.Errorf("SANs = %v %v, want one DNS name and one IP", cert.DNSNames, cert.IPAddresses)
+	}
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+		t.Errorf("ExtKeyUsage = %v, want [ServerAuth]", cert.ExtKeyUsage)
+	}
+}
+
+func TestCA_SignCSRAltNames(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	key, err := NewPrivateKey(KeyTypeECDSA)
+	if err != nil {
+		t.Fatalf("Failed to create private key: %v", err)
+	}
+	csrPEM, err := NewCertificateRequest(Config{
+		CommonName: "test.example.com",
+		AltNames: AltNames{
+			DNSNames: []string{"test.example.com", "www.example.com"},
+			IPs:      []net.IP{net.ParseIP("10.0.0.1")},
+		},
+	}, key)
+	if err != nil {
+		t.Fatalf("NewCertificateRequest() error = %v", err)
+	}
+
+	usages := []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantDNS []string
+		wantIPs int
+	}{
+		{
+			name:    "from csr",
+			cfg:     Config{Usages: usages},
+			wantDNS: []string{"test.example.com", "www.example.com"},
+			wantIPs: 1,
+		},
+		{
+			name:    "override by config",
+			cfg:     Config{Usages: usages, AltNames: AltNames{DNSNames: []string{"override.example.com"}}},
+			wantDNS: []string{"override.example.com"},
+			wantIPs: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, err := ca.SignCSR(csrPEM, tt.cfg)
+			if err != nil {
+				t.Fatalf("SignCSR() error = %v", err)
+			}
+			if len(cert.DNSNames) != len(tt.wantDNS) {
+				t.Fatalf("DNSNames = %v, want %v", cert.DNSNames, tt.wantDNS)
+			}
+			for i := range tt.wantDNS {
+				if cert.DNSNames[i] != tt.wantDNS[i] {
+					t.Errorf("DNSNames = %v, want %v", cert.DNSNames, tt.wantDNS)
+				}
+			}
+			if len(cert.IPAddresses) != tt.wantIPs {
+				t.Errorf("IPAddresses = %v, want %d addresses", cert.IPAddresses, tt.wantIPs)
+			}
+		})
+	}
+}
+
+func TestCA_SignCSRInvalid(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	key, err := NewPrivateKey(KeyTypeECDSA)
+	if err != nil {
+		t.Fatalf("Failed to create private key: %v", err)
+	}
+	csrPEM, err := NewCertificateRequest(Config{CommonName: "client"}, key)
+	if err != nil {
+		t.Fatalf("NewCertificateRequest() error = %v", err)
+	}
+
+	// 篡改签名
+	block, _ := pem.Decode(csrPEM)
+	tampered := append([]byte(nil), block.Bytes...)
+	tampered[len(tampered)-1] ^= 0xff
+	tamperedPEM := pem.EncodeToMemory(&pem.Block{Type: CertificateRequestBlockType, Bytes: tampered})
+
+	usages := []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	tests := []struct {
+		name    string
+		csr     []byte
+		cfg     Config
+		wantErr error
+	}{
+		{
+			name:    "bad signature",
+			csr:     tamperedPEM,
+			cfg:     Config{Usages: usages},
+			wantErr: ErrInvalidCertificateRequest,
+		},
+		{
+			name:    "no csr block",
+			csr:     EncodeCertPEM(ca.Certificate),
+			cfg:     Config{Usages: usages},
+			wantErr: ErrNoCertificateRequestFound,
+		},
+		{
+			name: "no usages",
+			csr:  csrPEM,
+			cfg:  Config{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ca.SignCSR(tt.csr, tt.cfg)
+			if err == nil {
+				t.Fatal("SignCSR() should fail")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("SignCSR() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
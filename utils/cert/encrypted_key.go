@@ -0,0 +1,270 @@
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+)
+
+const (
+	// EncryptedPrivateKeyBlockType PEM 加密私钥块类型（PKCS#8）
+	EncryptedPrivateKeyBlockType = "ENCRYPTED PRIVATE KEY"
+
+	// pbkdf2Iterations PBKDF2 迭代次数，参考 OWASP 对 PBKDF2-HMAC-SHA256 的建议
+	pbkdf2Iterations = 600000
+	pbkdf2SaltSize   = 16
+	// pbkdf2MinIterations 和 pbkdf2MaxIterations 解析时接受的迭代次数范围，
+	// 迭代次数来自文件内容，需要限制上限避免构造的文件长时间占用 CPU
+	pbkdf2MinIterations = 1000
+	pbkdf2MaxIterations = 10000000
+	// aes256KeySize AES-256 密钥长度
+	aes256KeySize = 32
+)
+
+// ErrEncryptedKey 私钥已加密，需要使用密码解析
+var ErrEncryptedKey = errors.New("private key is encrypted")
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// encryptedPrivateKeyInfo RFC 5958 EncryptedPrivateKeyInfo
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params RFC 8018 PBES2-params
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params RFC 8018 PBKDF2-params
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// EncodePrivateKeyPEMWithPassword 使用密码加密私钥并编码为 PEM 格式
+// 加密格式为 PKCS#8 + PBES2（PBKDF2-HMAC-SHA256、AES-256-CBC），与 OpenSSL 兼容
+func EncodePrivateKeyPEMWithPassword(key crypto.Signer, password []byte) ([]byte, error) {
+	if key == nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	if len(password) == 0 {
+		return nil, errors.New("password is required")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	salt := make([]byte, pbkdf2SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	encKey, err := pbkdf2.Key(sha256.New, string(password), salt, pbkdf2Iterations, aes256KeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	padded := pkcs7Pad(der, aes.BlockSize)
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: pbkdf2Iterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encrypted,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  EncryptedPrivateKeyBlockType,
+		Bytes: data,
+	}), nil
+}
+
+// ParsePrivateKeyPEMWithPassword 使用密码从 PEM 数据中解析加密的私钥
+// 如果 PEM 数据中的私钥未加密，则忽略密码直接解析；密码错误时返回 ErrIncorrectPassword
+func ParsePrivateKeyPEMWithPassword(pemData, password []byte) (crypto.Signer, error) {
+	rest := pemData
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == EncryptedPrivateKeyBlockType {
+			return decryptPrivateKey(block.Bytes, password)
+		}
+	}
+
+	// 未加密的私钥
+	return ParsePrivateKeyPEM(pemData)
+}
+
+// decryptPrivateKey 解密 PKCS#8 EncryptedPrivateKeyInfo
+func decryptPrivateKey(der, password []byte) (crypto.Signer, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPrivateKey, err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported key encryption algorithm: %s", info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPrivateKey, err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation function: %s", params.KeyDerivationFunc.Algorithm)
+	}
+	if !params.EncryptionScheme.Algorithm.Equal(oidAES256CBC) {
+		return nil, fmt.Errorf("unsupported encryption scheme: %s", params.EncryptionScheme.Algorithm)
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPrivateKey, err)
+	}
+	if kdf.IterationCount < pbkdf2MinIterations || kdf.IterationCount > pbkdf2MaxIterations {
+		return nil, fmt.Errorf("%w: PBKDF2 iteration count %d out of range", ErrInvalidPrivateKey, kdf.IterationCount)
+	}
+	// KeyLength 可选，存在时必须与 AES-256 密钥长度一致
+	if kdf.KeyLength != 0 && kdf.KeyLength != aes256KeySize {
+		return nil, fmt.Errorf("%w: PBKDF2 key length %d does not match AES-256", ErrInvalidPrivateKey, kdf.KeyLength)
+	}
+	var prf func() hash.Hash
+	switch {
+	case kdf.PRF.Algorithm == nil, kdf.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdf.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	default:
+		return nil, fmt.Errorf("unsupported PBKDF2 PRF: %s", kdf.PRF.Algorithm)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPrivateKey, err)
+	}
+	if len(iv) != aes.BlockSize || len(info.EncryptedData)%aes.BlockSize != 0 || len(info.EncryptedData) == 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	encKey, err := pbkdf2.Key(prf, string(password), kdf.Salt, kdf.IterationCount, aes256KeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	// 密码错误时填充或 PKCS#8 结构无法通过校验
+	plain, ok := pkcs7Unpad(decrypted, aes.BlockSize)
+	if !ok {
+		return nil, ErrIncorrectPassword
+	}
+	key, err := x509.ParsePKCS8PrivateKey(plain)
+	if err != nil {
+		return nil, ErrIncorrectPassword
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported private key type %T", ErrInvalidPrivateKey, key)
+	}
+	return signer, nil
+}
+
+func pkcs7Pad(data []byte, blockSize int) []byte {
+	pad := blockSize - len(data)%blockSize
+	return append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+}
+
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, bool) {
+	if len(data) == 0 {
+		return nil, false
+	}
+	pad := int(data[len(data)-1])
+	if pad == 0 || pad > blockSize || pad > len(data) {
+		return nil, false
+	}
+	for _, b := range data[len(data)-pad:] {
+		if int(b) != pad {
+			return nil, false
+		}
+	}
+	return data[:len(data)-pad], true
+}
+
+// WritePrivateKeyToFileEncrypted 使用密码加密私钥并写入文件
+func WritePrivateKeyToFileEncrypted(keyPath string, key crypto.Signer, password []byte) error {
+	pemData, err := EncodePrivateKeyPEMWithPassword(key, password)
+	if err != nil {
+		return err
+	}
+	return writeFile(keyPath, pemData, keyFileMode)
+}
+
+// ReadPrivateKeyFromFileEncrypted 从文件读取并使用密码解密私钥，未加密的私钥直接解析
+func ReadPrivateKeyFromFileEncrypted(keyPath string, password []byte) (crypto.Signer, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
+	}
+	return ParsePrivateKeyPEMWithPassword(data, password)
+}
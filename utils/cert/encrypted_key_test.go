@@ -0,0 +1,156 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedPrivateKeyPEM(t *testing.T) {
+	password := []byte("correct horse battery staple")
+
+	for _, keyType := range []KeyType{KeyTypeRSA, KeyTypeECDSA} {
+		t.Run(string(keyType), func(t *testing.T) {
+			key, err := NewPrivateKey(keyType)
+			if err != nil {
+				t.Fatalf("NewPrivateKey() error = %v", err)
+			}
+
+			pemData, err := EncodePrivateKeyPEMWithPassword(key, password)
+			if err != nil {
+				t.Fatalf("EncodePrivateKeyPEMWithPassword() error = %v", err)
+			}
+			block, _ := pem.Decode(pemData)
+			if block == nil || block.Type != EncryptedPrivateKeyBlockType {
+				t.Fatalf("PEM block type = %v, want %s", block, EncryptedPrivateKeyBlockType)
+			}
+			if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+				t.Error("encrypted block should not parse as a cleartext PKCS#8 key")
+			}
+
+			parsed, err := ParsePrivateKeyPEMWithPassword(pemData, password)
+			if err != nil {
+				t.Fatalf("ParsePrivateKeyPEMWithPassword() error = %v", err)
+			}
+			if !parsed.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(key.Public()) {
+				t.Error("parsed private key doesn't match original")
+			}
+
+			if _, err := ParsePrivateKeyPEMWithPassword(pemData, []byte("wrong")); !errors.Is(err, ErrIncorrectPassword) {
+				t.Errorf("ParsePrivateKeyPEMWithPassword() error = %v, want %v", err, ErrIncorrectPassword)
+			}
+			if _, err := ParsePrivateKeyPEM(pemData); !errors.Is(err, ErrEncryptedKey) {
+				t.Errorf("ParsePrivateKeyPEM() error = %v, want %v", err, ErrEncryptedKey)
+			}
+		})
+	}
+}
+
+func TestEncryptedPrivateKeyFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	password := []byte("secret")
+
+	key, err := NewPrivateKey(KeyTypeECDSA)
+	if err != nil {
+		t.Fatalf("NewPrivateKey() error = %v", err)
+	}
+
+	encryptedPath := filepath.Join(tmpDir, "encrypted.key")
+	if err := WritePrivateKeyToFileEncrypted(encryptedPath, key, password); err != nil {
+		t.Fatalf("WritePrivateKeyToFileEncrypted() error = %v", err)
+	}
+	if _, err := ReadPrivateKeyFromFileEncrypted(encryptedPath, password); err != nil {
+		t.Errorf("ReadPrivateKeyFromFileEncrypted() error = %v", err)
+	}
+	if _, err := ReadPrivateKeyFromFile(encryptedPath); !errors.Is(err, ErrEncryptedKey) {
+		t.Errorf("ReadPrivateKeyFromFile() error = %v, want %v", err, ErrEncryptedKey)
+	}
+
+	// 未加密的私钥忽略密码直接解析
+	plainPath := filepath.Join(tmpDir, "plain.key")
+	if err := WritePrivateKeyToFile(plainPath, key); err != nil {
+		t.Fatalf("WritePrivateKeyToFile() error = %v", err)
+	}
+	if _, err := ReadPrivateKeyFromFileEncrypted(plainPath, password); err != nil {
+		t.Errorf("ReadPrivateKeyFromFileEncrypted() with unencrypted key error = %v", err)
+	}
+
+	if _, err := EncodePrivateKeyPEMWithPassword(key, nil); err == nil {
+		t.Error("EncodePrivateKeyPEMWithPassword() should fail with empty password")
+	}
+}
+
+// rewritePBKDF2Params 修改加密私钥中的 PBKDF2 参数，模拟构造的文件
+func rewritePBKDF2Params(t *testing.T, pemData []byte, modify func(*pbkdf2Params)) []byte {
+	t.Helper()
+	block, _ := pem.Decode(pemData)
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		t.Fatal(err)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		t.Fatal(err)
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		t.Fatal(err)
+	}
+	modify(&kdf)
+
+	kdfParams, err := asn1.Marshal(kdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.KeyDerivationFunc.Parameters = asn1.RawValue{FullBytes: kdfParams}
+	paramsDER, err := asn1.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.Algorithm.Parameters = asn1.RawValue{FullBytes: paramsDER}
+	der, err := asn1.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: EncryptedPrivateKeyBlockType, Bytes: der})
+}
+
+func TestEncryptedPrivateKeyPBKDF2Params(t *testing.T) {
+	password := []byte("secret")
+	key, err := NewPrivateKey(KeyTypeECDSA)
+	if err != nil {
+		t.Fatalf("NewPrivateKey() error = %v", err)
+	}
+	pemData, err := EncodePrivateKeyPEMWithPassword(key, password)
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyPEMWithPassword() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*pbkdf2Params)
+		wantErr error
+	}{
+		{name: "key length 32", modify: func(p *pbkdf2Params) { p.KeyLength = aes256KeySize }},
+		{name: "zero iterations", modify: func(p *pbkdf2Params) { p.IterationCount = 0 }, wantErr: ErrInvalidPrivateKey},
+		{name: "negative iterations", modify: func(p *pbkdf2Params) { p.IterationCount = -1 }, wantErr: ErrInvalidPrivateKey},
+		{name: "too few iterations", modify: func(p *pbkdf2Params) { p.IterationCount = 1 }, wantErr: ErrInvalidPrivateKey},
+		{name: "too many iterations", modify: func(p *pbkdf2Params) { p.IterationCount = 1 << 31 }, wantErr: ErrInvalidPrivateKey},
+		{name: "wrong key length", modify: func(p *pbkdf2Params) { p.KeyLength = 16 }, wantErr: ErrInvalidPrivateKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePrivateKeyPEMWithPassword(rewritePBKDF2Params(t, pemData, tt.modify), password)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("ParsePrivateKeyPEMWithPassword() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParsePrivateKeyPEMWithPassword() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
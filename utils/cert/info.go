@@ -0,0 +1,116 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha512" // 注册 SHA-384 和 SHA-512，供 Fingerprint 使用
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CertInfo 证书的摘要信息，便于日志输出和排查证书部署情况
+type CertInfo struct {
+	CommonName     string    `json:"commonName" yaml:"commonName"`
+	DNSNames       []string  `json:"dnsNames,omitempty" yaml:"dnsNames,omitempty"`
+	IPs            []string  `json:"ips,omitempty" yaml:"ips,omitempty"`
+	EmailAddresses []string  `json:"emailAddresses,omitempty" yaml:"emailAddresses,omitempty"`
+	URIs           []string  `json:"uris,omitempty" yaml:"uris,omitempty"`
+	SerialNumber   string    `json:"serialNumber" yaml:"serialNumber"`
+	NotBefore      time.Time `json:"notBefore" yaml:"notBefore"`
+	NotAfter       time.Time `json:"notAfter" yaml:"notAfter"`
+	KeyType        string    `json:"keyType" yaml:"keyType"`
+	// KeySize RSA 为模数位数，ECDSA 为曲线位数，Ed25519 固定为 256
+	KeySize int  `json:"keySize" yaml:"keySize"`
+	IsCA    bool `json:"isCA" yaml:"isCA"`
+	// Fingerprint 证书 DER 编码的 SHA-256 指纹
+	Fingerprint string `json:"fingerprint" yaml:"fingerprint"`
+}
+
+// Fingerprint 计算证书 DER 编码的指纹，格式为冒号分隔的大写十六进制，与 openssl x509 -fingerprint 一致
+func Fingerprint(cert *x509.Certificate, algo crypto.Hash) (string, error) {
+	if cert == nil || len(cert.Raw) == 0 {
+		return "", ErrInvalidCertificate
+	}
+	if !algo.Available() {
+		return "", fmt.Errorf("unsupported fingerprint hash: %s", algo)
+	}
+
+	h := algo.New()
+	h.Write(cert.Raw)
+	sum := h.Sum(nil)
+
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":"), nil
+}
+
+// NewCertInfo 提取证书的摘要信息，cert 为空时返回零值
+func NewCertInfo(cert *x509.Certificate) CertInfo {
+	if cert == nil {
+		return CertInfo{}
+	}
+
+	info := CertInfo{
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		NotBefore:      cert.NotBefore,
+		NotAfter:       cert.NotAfter,
+		IsCA:           cert.IsCA,
+	}
+	for _, ip := range cert.IPAddresses {
+		info.IPs = append(info.IPs, ip.String())
+	}
+	for _, u := range cert.URIs {
+		info.URIs = append(info.URIs, u.String())
+	}
+	if cert.SerialNumber != nil {
+		info.SerialNumber = fmt.Sprintf("%X", cert.SerialNumber)
+	}
+	info.KeyType, info.KeySize = publicKeyInfo(cert.PublicKey)
+	info.Fingerprint, _ = Fingerprint(cert, crypto.SHA256)
+	return info
+}
+
+// Summary 返回证书摘要信息的单行文本
+func Summary(cert *x509.Certificate) string {
+	info := NewCertInfo(cert)
+
+	sans := make([]string, 0, len(info.DNSNames)+len(info.IPs)+len(info.EmailAddresses)+len(info.URIs))
+	sans = append(sans, info.DNSNames...)
+	sans = append(sans, info.IPs...)
+	sans = append(sans, info.EmailAddresses...)
+	sans = append(sans, info.URIs...)
+
+	return fmt.Sprintf("CN=%s SANs=[%s] serial=%s notBefore=%s notAfter=%s key=%s-%d ca=%t sha256=%s",
+		info.CommonName,
+		strings.Join(sans, ","),
+		info.SerialNumber,
+		info.NotBefore.UTC().Format(time.RFC3339),
+		info.NotAfter.UTC().Format(time.RFC3339),
+		info.KeyType,
+		info.KeySize,
+		info.IsCA,
+		info.Fingerprint,
+	)
+}
+
+// publicKeyInfo 返回公钥类型和长度
+func publicKeyInfo(pub crypto.PublicKey) (string, int) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return string(KeyTypeRSA), k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return string(KeyTypeECDSA), k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", ed25519.PublicKeySize * 8
+	default:
+		return fmt.Sprintf("%T", pub), 0
+	}
+}
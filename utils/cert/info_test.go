@@ -0,0 +1,129 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	ckp, err := NewSelfSignedCert(Config{
+		CommonName: "localhost",
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		t.Fatalf("NewSelfSignedCert() error = %v", err)
+	}
+	sha256Sum := sha256.Sum256(ckp.Certificate.Raw)
+	sha1Sum := sha1.Sum(ckp.Certificate.Raw)
+
+	tests := []struct {
+		name    string
+		cert    *x509.Certificate
+		algo    crypto.Hash
+		want    []byte
+		wantErr bool
+	}{
+		{name: "SHA256", cert: ckp.Certificate, algo: crypto.SHA256, want: sha256Sum[:]},
+		{name: "SHA1", cert: ckp.Certificate, algo: crypto.SHA1, want: sha1Sum[:]},
+		{name: "nil certificate", cert: nil, algo: crypto.SHA256, wantErr: true},
+		{name: "unavailable hash", cert: ckp.Certificate, algo: crypto.MD4, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Fingerprint(tt.cert, tt.algo)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fingerprint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want := strings.ToUpper(hex.EncodeToString(tt.want))
+			if strings.ReplaceAll(got, ":", "") != want {
+				t.Errorf("Fingerprint() = %s, want %s", got, want)
+			}
+			if strings.Count(got, ":") != len(tt.want)-1 {
+				t.Errorf("Fingerprint() = %s, want colon separated bytes", got)
+			}
+		})
+	}
+}
+
+func TestCertInfo(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("NewCA() error = %v", err)
+	}
+	leaf, err := ca.NewSignedCert(Config{
+		CommonName: "server",
+		KeyType:    KeyTypeRSA,
+		AltNames: AltNames{
+			DNSNames: []string{"server.example.com"},
+			IPs:      []net.IP{net.ParseIP("10.0.0.1")},
+		},
+		Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		t.Fatalf("NewSignedCert() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		cert        *x509.Certificate
+		wantKeyType string
+		wantKeySize int
+		wantCA      bool
+		wantSANs    []string
+	}{
+		{
+			name:        "ECDSA CA",
+			cert:        ca.Certificate,
+			wantKeyType: "ECDSA",
+			wantKeySize: 256,
+			wantCA:      true,
+		},
+		{
+			name:        "RSA leaf",
+			cert:        leaf.Certificate,
+			wantKeyType: "RSA",
+			wantKeySize: 2048,
+			wantSANs:    []string{"server.example.com", "10.0.0.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := NewCertInfo(tt.cert)
+			if info.CommonName != tt.cert.Subject.CommonName {
+				t.Errorf("CommonName = %s, want %s", info.CommonName, tt.cert.Subject.CommonName)
+			}
+			if info.KeyType != tt.wantKeyType || info.KeySize != tt.wantKeySize {
+				t.Errorf("key = %s-%d, want %s-%d", info.KeyType, info.KeySize, tt.wantKeyType, tt.wantKeySize)
+			}
+			if info.IsCA != tt.wantCA {
+				t.Errorf("IsCA = %v, want %v", info.IsCA, tt.wantCA)
+			}
+			if want := strings.ToUpper(tt.cert.SerialNumber.Text(16)); info.SerialNumber != want {
+				t.Errorf("SerialNumber = %s, want %s", info.SerialNumber, want)
+			}
+			if want, _ := Fingerprint(tt.cert, crypto.SHA256); info.Fingerprint != want {
+				t.Errorf("Fingerprint = %s, want %s", info.Fingerprint, want)
+			}
+
+			summary := Summary(tt.cert)
+			for _, want := range append([]string{"CN=" + info.CommonName, info.SerialNumber, info.Fingerprint}, tt.wantSANs...) {
+				if !strings.Contains(summary, want) {
+					t.Errorf("Summary() = %s, missing %s", summary, want)
+				}
+			}
+		})
+	}
+
+	if info := NewCertInfo(nil); info.CommonName != "" || info.Fingerprint != "" {
+		t.Errorf("NewCertInfo(nil) = %+v, want zero value", info)
+	}
+}
@@ -30,6 +30,23 @@ func WriteCertToFile(certPath string, cert *x509.Certificate) error {
 	return writeFile(certPath, pemData, certFileMode)
 }
 
+// WriteCertsToFile 将多个证书按顺序写入同一个 PEM 文件
+func WriteCertsToFile(certPath string, certs ...*x509.Certificate) error {
+	if len(certs) == 0 {
+		return ErrInvalidCertificate
+	}
+
+	var pemData []byte
+	for _, cert := range certs {
+		if cert == nil {
+			return ErrInvalidCertificate
+		}
+		pemData = append(pemData, EncodeCertPEM(cert)...)
+	}
+
+	return writeFile(certPath, pemData, certFileMode)
+}
+
 // WritePrivateKeyToFile 将私钥写入文件
 func WritePrivateKeyToFile(keyPath string, key crypto.Signer) error {
 	if key == nil {
@@ -59,14 +76,29 @@ func WritePublicKeyToFile(keyPath string, key crypto.PublicKey) error {
 }
 
 // WriteCertAndKeyToFile 将证书和私钥写入文件
+// 证书写入失败时恢复原有的私钥文件（不存在时删除），避免留下与证书不匹配的私钥
 // certPath: 证书文件路径
 // keyPath: 私钥文件路径
 func WriteCertAndKeyToFile(certPath, keyPath string, cert *x509.Certificate, key crypto.Signer) error {
+	return writeKeyThenCerts(keyPath, key, func() error {
+		return WriteCertToFile(certPath, cert)
+	})
+}
+
+// writeKeyThenCerts 先写入私钥再调用 writeCerts 写入证书，证书写入失败时回滚私钥文件
+func writeKeyThenCerts(keyPath string, key crypto.Signer, writeCerts func() error) error {
+	previous, readErr := os.ReadFile(keyPath)
+
 	if err := WritePrivateKeyToFile(keyPath, key); err != nil {
 		return fmt.Errorf("failed to write private key: %w", err)
 	}
 
-	if err := WriteCertToFile(certPath, cert); err != nil {
+	if err := writeCerts(); err != nil {
+		if readErr == nil {
+			_ = writeFile(keyPath, previous, keyFileMode)
+		} else {
+			_ = os.Remove(keyPath)
+		}
 		return fmt.Errorf("failed to write certificate: %w", err)
 	}
 
@@ -80,7 +112,7 @@ func ReadCertFromFile(certPath string) (*x509.Certificate, error) {
 		return nil, fmt.Errorf("failed to read certificate file: %w", err)
 	}
 
-	certs, err := ParseCertsPEM(data)
+	certs, err := parseCerts(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse certificate: %w", err)
 	}
@@ -99,7 +131,7 @@ func ReadCertsFromFile(certPath string) ([]*x509.Certificate, error) {
 		return nil, fmt.Errorf("failed to read certificate file: %w", err)
 	}
 
-	certs, err := ParseCertsPEM(data)
+	certs, err := parseCerts(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse certificates: %w", err)
 	}
@@ -107,6 +139,21 @@ func ReadCertsFromFile(certPath string) ([]*x509.Certificate, error) {
 	return certs, nil
 }
 
+// NewCertPoolFromFiles 从多个证书文件创建证书池，每个文件可以包含多个证书
+func NewCertPoolFromFiles(paths ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, path := range paths {
+		certs, err := ReadCertsFromFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, cert := range certs {
+			pool.AddCert(cert)
+		}
+	}
+	return pool, nil
+}
+
 // ReadPrivateKeyFromFile 从文件读取私钥
 func ReadPrivateKeyFromFile(keyPath string) (crypto.Signer, error) {
 	data, err := os.ReadFile(keyPath)
@@ -114,7 +161,7 @@ func ReadPrivateKeyFromFile(keyPath string) (crypto.Signer, error) {
 		return nil, fmt.Errorf("failed to read private key file: %w", err)
 	}
 
-	key, err := ParsePrivateKeyPEM(data)
+	key, err := parsePrivateKey(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
@@ -149,6 +196,10 @@ func ReadCertAndKeyFromFile(certPath, keyPath string) (*x509.Certificate, crypto
 		return nil, nil, err
 	}
 
+	if !KeyMatchesCert(key, cert) {
+		return nil, nil, fmt.Errorf("%w: %s, %s", ErrKeyMismatch, certPath, keyPath)
+	}
+
 	return cert, key, nil
 }
 
@@ -175,6 +226,61 @@ func (ckp *CertKeyPair) SaveToFile(certPath, keyPath string) error {
 	return WriteCertAndKeyToFile(certPath, keyPath, ckp.Certificate, ckp.PrivateKey)
 }
 
+// SaveChainToFile 保存证书链和私钥到文件
+// 证书文件中先写入叶子证书，再按顺序写入 chain 中的中间证书或 CA 证书
+func (ckp *CertKeyPair) SaveChainToFile(certPath, keyPath string, chain ...*x509.Certificate) error {
+	certs := append([]*x509.Certificate{ckp.Certificate}, chain...)
+	return writeKeyThenCerts(keyPath, ckp.PrivateKey, func() error {
+		if err := WriteCertsToFile(certPath, certs...); err != nil {
+			return fmt.Errorf("failed to write certificate chain: %w", err)
+		}
+		return nil
+	})
+}
+
+// SaveToBundleFile 将证书和私钥写入同一个 PEM 文件，先写入证书再写入私钥，
+// 适用于 HAProxy 等需要单个文件的场景，文件权限为 0600
+func (ckp *CertKeyPair) SaveToBundleFile(path string) error {
+	if ckp.Certificate == nil {
+		return ErrInvalidCertificate
+	}
+	keyPEM, err := EncodePrivateKeyPEM(ckp.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	pemData := append(EncodeCertPEM(ckp.Certificate), keyPEM...)
+	return writeFile(path, pemData, keyFileMode)
+}
+
+// LoadCertKeyPairFromBundleFile 从包含证书和私钥的 PEM 文件中加载证书和私钥对
+// 证书和私钥的顺序不限；文件中包含多个证书时使用与私钥匹配的证书，其余证书被忽略
+func LoadCertKeyPairFromBundleFile(path string) (*CertKeyPair, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle file: %w", err)
+	}
+
+	key, err := ParsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	certs, err := ParseCertsPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, cert := range certs {
+		if KeyMatchesCert(key, cert) {
+			return &CertKeyPair{
+				Certificate: cert,
+				PrivateKey:  key,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKeyMismatch, path)
+}
+
 // CertAndKeyExist 检查证书和私钥文件是否都存在
 func CertAndKeyExist(certPath, keyPath string) (bool, error) {
 	certExists := fileExists(certPath)
@@ -195,20 +301,82 @@ func CertAndKeyExist(certPath, keyPath string) (bool, error) {
 	return true, nil
 }
 
-// writeFile 写入文件（自动创建目录）
-func writeFile(path string, data []byte, perm os.FileMode) error {
+// writeFile 原子地写入文件（自动创建目录）
+// 先写入同目录下的临时文件并 fsync，再重命名覆盖目标文件并 fsync 所在目录，
+// 读取方只会看到完整的旧文件或新文件，已打开旧文件的读取方不受影响
+// 目标为符号链接时替换的是链接本身而不是写入链接指向的文件，
+// 例如 Kubernetes Secret 挂载目录中的文件会被替换为普通文件
+func writeFile(path string, data []byte, perm os.FileMode) (err error) {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, dirFileMode); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, perm); err != nil {
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	// 重命名只有在目录项落盘后才能保证在崩溃后仍然生效
+	if err := syncDir(dir); err != nil {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
 
 	return nil
 }
 
+// syncDir fsync 目录，使目录中的创建和重命名落盘
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// parseCerts 解析证书数据，自动识别 PEM 和 DER 格式
+func parseCerts(data []byte) ([]*x509.Certificate, error) {
+	if isPEM(data) {
+		return ParseCertsPEM(data)
+	}
+
+	cert, err := ParseCertDER(data)
+	if err != nil {
+		return nil, err
+	}
+	return []*x509.Certificate{cert}, nil
+}
+
+// parsePrivateKey 解析私钥数据，自动识别 PEM 和 DER 格式
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	if isPEM(data) {
+		return ParsePrivateKeyPEM(data)
+	}
+	return ParsePrivateKeyDER(data)
+}
+
 // fileExists 检查文件是否存在且可读
 func fileExists(path string) bool {
 	f, err := os.Open(path)
@@ -310,6 +478,10 @@ func LoadCAFromBase64(certBase64, keyBase64 string) (*CA, error) {
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
+	if err := VerifyCertKeyMatch(cert, key); err != nil {
+		return nil, err
+	}
+
 	return &CA{
 		Certificate: cert,
 		PrivateKey:  key,
@@ -343,6 +515,10 @@ func LoadCertKeyPairFromBase64(certBase64, keyBase64 string) (*CertKeyPair, erro
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
+	if err := VerifyCertKeyMatch(cert, key); err != nil {
+		return nil, err
+	}
+
 	return &CertKeyPair{
 		Certificate: cert,
 		PrivateKey:  key,
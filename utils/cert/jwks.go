@@ -0,0 +1,126 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// JSONWebKey RFC 7517 JSON Web Key，只包含公钥字段
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	// RSA 公钥参数
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// ECDSA 公钥参数
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet RFC 7517 JSON Web Key Set
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// BuildJWKS 将公钥编码为 JWKS 文档，支持 RSA 和 ECDSA 公钥
+// 每个 JWK 的 kid 为 RFC 7638 定义的 SHA-256 指纹
+func BuildJWKS(keys ...crypto.PublicKey) ([]byte, error) {
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(keys))}
+	for _, key := range keys {
+		jwk, err := NewJSONWebKey(key)
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return json.Marshal(set)
+}
+
+// NewJSONWebKey 将公钥转换为用于签名校验的 JWK
+func NewJSONWebKey(key crypto.PublicKey) (JSONWebKey, error) {
+	var jwk JSONWebKey
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if k == nil {
+			return jwk, ErrInvalidPublicKey
+		}
+		jwk = JSONWebKey{
+			KeyType:   "RSA",
+			Algorithm: "RS256",
+			N:         base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		if k == nil {
+			return jwk, ErrInvalidPublicKey
+		}
+		crv, alg, err := jwkCurve(k.Curve)
+		if err != nil {
+			return jwk, err
+		}
+		pub, err := k.ECDH()
+		if err != nil {
+			return jwk, fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+		}
+		// 非压缩格式 0x04 || X || Y，X 和 Y 按曲线长度补齐
+		point := pub.Bytes()[1:]
+		size := len(point) / 2
+		jwk = JSONWebKey{
+			KeyType:   "EC",
+			Algorithm: alg,
+			Curve:     crv,
+			X:         base64.RawURLEncoding.EncodeToString(point[:size]),
+			Y:         base64.RawURLEncoding.EncodeToString(point[size:]),
+		}
+	default:
+		return jwk, fmt.Errorf("%w: unsupported public key type %T", ErrInvalidPublicKey, key)
+	}
+	jwk.Use = "sig"
+
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		return jwk, err
+	}
+	jwk.KeyID = thumbprint
+	return jwk, nil
+}
+
+// Thumbprint 计算 RFC 7638 JWK SHA-256 指纹，结果为 base64url 编码
+func (jwk JSONWebKey) Thumbprint() (string, error) {
+	// 必需成员按字典序排列，不含空白字符
+	var members string
+	switch jwk.KeyType {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, jwk.Curve, jwk.X, jwk.Y)
+	default:
+		return "", fmt.Errorf("unsupported JWK key type: %s", jwk.KeyType)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// jwkCurve 返回曲线对应的 JWK crv 和 JWS 签名算法
+func jwkCurve(curve elliptic.Curve) (string, string, error) {
+	switch curve {
+	case elliptic.P256():
+		return "P-256", "ES256", nil
+	case elliptic.P384():
+		return "P-384", "ES384", nil
+	case elliptic.P521():
+		return "P-521", "ES512", nil
+	default:
+		return "", "", fmt.Errorf("%w: unsupported curve", ErrInvalidPublicKey)
+	}
+}
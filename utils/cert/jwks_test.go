@@ -0,0 +1,110 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestJSONWebKeyThumbprint(t *testing.T) {
+	// RFC 7638 3.1 示例
+	jwk := JSONWebKey{
+		KeyType: "RSA",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMs" +
+			"tn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91Cb" +
+			"OpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E: "AQAB",
+	}
+	got, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatalf("Thumbprint() error = %v", err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Errorf("Thumbprint() = %s, want %s", got, want)
+	}
+}
+
+func TestBuildJWKS(t *testing.T) {
+	rsaKey, err := NewPrivateKey(KeyTypeRSA)
+	if err != nil {
+		t.Fatalf("NewPrivateKey() error = %v", err)
+	}
+	p256Key, err := NewECDSAPrivateKey(CurveP256)
+	if err != nil {
+		t.Fatalf("NewECDSAPrivateKey() error = %v", err)
+	}
+	p521Key, err := NewECDSAPrivateKey(CurveP521)
+	if err != nil {
+		t.Fatalf("NewECDSAPrivateKey() error = %v", err)
+	}
+	keys := []crypto.PublicKey{rsaKey.Public(), p256Key.Public(), p521Key.Public()}
+
+	data, err := BuildJWKS(keys...)
+	if err != nil {
+		t.Fatalf("BuildJWKS() error = %v", err)
+	}
+	var set JSONWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(set.Keys) != len(keys) {
+		t.Fatalf("len(keys) = %d, want %d", len(set.Keys), len(keys))
+	}
+
+	tests := []struct {
+		wantKty string
+		wantAlg string
+		wantCrv string
+	}{
+		{wantKty: "RSA", wantAlg: "RS256"},
+		{wantKty: "EC", wantAlg: "ES256", wantCrv: "P-256"},
+		{wantKty: "EC", wantAlg: "ES512", wantCrv: "P-521"},
+	}
+	for i, tt := range tests {
+		jwk := set.Keys[i]
+		if jwk.KeyType != tt.wantKty || jwk.Algorithm != tt.wantAlg || jwk.Curve != tt.wantCrv {
+			t.Errorf("keys[%d] = %s/%s/%s, want %s/%s/%s", i, jwk.KeyType, jwk.Algorithm, jwk.Curve, tt.wantKty, tt.wantAlg, tt.wantCrv)
+		}
+		if kid, _ := jwk.Thumbprint(); jwk.KeyID == "" || jwk.KeyID != kid {
+			t.Errorf("keys[%d].kid = %q, want %q", i, jwk.KeyID, kid)
+		}
+		if !jwkPublicKey(t, jwk).(interface{ Equal(crypto.PublicKey) bool }).Equal(keys[i]) {
+			t.Errorf("keys[%d] does not match the original public key", i)
+		}
+	}
+
+	edPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() error = %v", err)
+	}
+	if _, err := BuildJWKS(edPub); err == nil {
+		t.Error("BuildJWKS() with unsupported key type should fail")
+	}
+}
+
+// jwkPublicKey 从 JWK 还原公钥
+func jwkPublicKey(t *testing.T, jwk JSONWebKey) crypto.PublicKey {
+	t.Helper()
+	decode := func(s string) *big.Int {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("DecodeString(%q) error = %v", s, err)
+		}
+		return new(big.Int).SetBytes(b)
+	}
+	switch jwk.KeyType {
+	case "RSA":
+		return &rsa.PublicKey{N: decode(jwk.N), E: int(decode(jwk.E).Int64())}
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		return &ecdsa.PublicKey{Curve: curves[jwk.Curve], X: decode(jwk.X), Y: decode(jwk.Y)}
+	}
+	t.Fatalf("unexpected kty %s", jwk.KeyType)
+	return nil
+}
@@ -0,0 +1,54 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+)
+
+// KeyIDMethod SubjectKeyId 的计算方法
+type KeyIDMethod string
+
+const (
+	// KeyIDMethodSHA1 公钥的 SHA-1 哈希（RFC 5280 4.2.1.2 方法一）
+	KeyIDMethodSHA1 KeyIDMethod = "SHA1"
+	// KeyIDMethodSHA256 公钥的 SHA-256 哈希截断为 160 位（RFC 7093 方法一）
+	KeyIDMethodSHA256 KeyIDMethod = "SHA256"
+)
+
+// subjectPublicKeyInfo RFC 5280 SubjectPublicKeyInfo
+type subjectPublicKeyInfo struct {
+	Algorithm        pkix.AlgorithmIdentifier
+	SubjectPublicKey asn1.BitString
+}
+
+// SubjectKeyID 计算公钥的 SubjectKeyId，哈希的输入为 SubjectPublicKeyInfo 中的公钥位串
+// method 为空时使用 KeyIDMethodSHA1
+func SubjectKeyID(pub crypto.PublicKey, method KeyIDMethod) ([]byte, error) {
+	if pub == nil {
+		return nil, ErrInvalidPublicKey
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+	var spki subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+
+	switch method {
+	case "", KeyIDMethodSHA1:
+		sum := sha1.Sum(spki.SubjectPublicKey.Bytes)
+		return sum[:], nil
+	case KeyIDMethodSHA256:
+		sum := sha256.Sum256(spki.SubjectPublicKey.Bytes)
+		return sum[:20], nil
+	default:
+		return nil, fmt.Errorf("unsupported key id method: %s", method)
+	}
+}
@@ -0,0 +1,63 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"testing"
+)
+
+func TestSubjectKeyID(t *testing.T) {
+	for _, method := range []KeyIDMethod{"", KeyIDMethodSHA1, KeyIDMethodSHA256} {
+		t.Run(string(method), func(t *testing.T) {
+			root, err := NewCA(Config{CommonName: "Root CA", KeyType: KeyTypeECDSA, KeyIDMethod: method})
+			if err != nil {
+				t.Fatalf("Failed to create CA: %v", err)
+			}
+			intermediate, err := root.NewIntermediateCA(Config{CommonName: "Intermediate CA", KeyType: KeyTypeRSA, KeyIDMethod: method})
+			if err != nil {
+				t.Fatalf("CA.NewIntermediateCA() error = %v", err)
+			}
+			leaf, err := intermediate.NewSignedCert(Config{
+				CommonName:  "leaf",
+				KeyType:     KeyTypeECDSA,
+				Usages:      []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+				KeyIDMethod: method,
+			})
+			if err != nil {
+				t.Fatalf("CA.NewSignedCert() error = %v", err)
+			}
+
+			for _, cert := range []*x509.Certificate{root.Certificate, intermediate.Certificate, leaf.Certificate} {
+				want, err := SubjectKeyID(cert.PublicKey, method)
+				if err != nil {
+					t.Fatalf("SubjectKeyID() error = %v", err)
+				}
+				if len(want) != 20 || !bytes.Equal(cert.SubjectKeyId, want) {
+					t.Errorf("%s SubjectKeyId = %x, want %x", cert.Subject.CommonName, cert.SubjectKeyId, want)
+				}
+			}
+			if !bytes.Equal(intermediate.Certificate.AuthorityKeyId, root.Certificate.SubjectKeyId) {
+				t.Errorf("intermediate AuthorityKeyId = %x, want %x", intermediate.Certificate.AuthorityKeyId, root.Certificate.SubjectKeyId)
+			}
+			if !bytes.Equal(leaf.Certificate.AuthorityKeyId, intermediate.Certificate.SubjectKeyId) {
+				t.Errorf("leaf AuthorityKeyId = %x, want %x", leaf.Certificate.AuthorityKeyId, intermediate.Certificate.SubjectKeyId)
+			}
+		})
+	}
+
+	key, err := NewPrivateKey(KeyTypeECDSA)
+	if err != nil {
+		t.Fatalf("NewPrivateKey() error = %v", err)
+	}
+	sha1ID, _ := SubjectKeyID(key.Public(), KeyIDMethodSHA1)
+	sha256ID, _ := SubjectKeyID(key.Public(), KeyIDMethodSHA256)
+	if bytes.Equal(sha1ID, sha256ID) {
+		t.Error("SHA1 and SHA256 key ids should differ")
+	}
+	if _, err := SubjectKeyID(key.Public(), "MD5"); err == nil {
+		t.Error("SubjectKeyID() should reject unsupported method")
+	}
+	if _, err := NewCA(Config{CommonName: "Test CA", KeyIDMethod: "MD5"}); err == nil {
+		t.Error("NewCA() should reject unsupported key id method")
+	}
+}
@@ -0,0 +1,77 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ErrIncorrectPassword 密码错误
+var ErrIncorrectPassword = errors.New("incorrect password")
+
+// ToPKCS12 将证书和私钥导出为 PKCS#12（.p12/.pfx）格式，caChain 为可选的 CA 证书链
+func (ckp *CertKeyPair) ToPKCS12(password string, caChain ...*x509.Certificate) ([]byte, error) {
+	if ckp.Certificate == nil {
+		return nil, ErrInvalidCertificate
+	}
+	if ckp.PrivateKey == nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	for _, cert := range caChain {
+		if cert == nil {
+			return nil, ErrInvalidCertificate
+		}
+	}
+
+	data, err := pkcs12.Modern.Encode(ckp.PrivateKey, ckp.Certificate, caChain, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PKCS#12: %w", err)
+	}
+	return data, nil
+}
+
+// LoadCertKeyPairFromPKCS12 从 PKCS#12 数据中加载证书、私钥和 CA 证书链
+func LoadCertKeyPairFromPKCS12(data []byte, password string) (*CertKeyPair, []*x509.Certificate, error) {
+	key, cert, caChain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+			return nil, nil, ErrIncorrectPassword
+		}
+		return nil, nil, fmt.Errorf("failed to decode PKCS#12: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: unsupported private key type %T", ErrInvalidPrivateKey, key)
+	}
+	if !KeyMatchesCert(signer, cert) {
+		return nil, nil, ErrKeyMismatch
+	}
+
+	return &CertKeyPair{
+		Certificate: cert,
+		PrivateKey:  signer,
+	}, caChain, nil
+}
+
+// SavePKCS12ToFile 将证书、私钥和可选的 CA 证书链以 PKCS#12 格式写入文件
+func (ckp *CertKeyPair) SavePKCS12ToFile(path, password string, caChain ...*x509.Certificate) error {
+	data, err := ckp.ToPKCS12(password, caChain...)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data, keyFileMode)
+}
+
+// ReadCertKeyPairFromPKCS12File 从 PKCS#12 文件中读取证书、私钥和 CA 证书链
+func ReadCertKeyPairFromPKCS12File(path, password string) (*CertKeyPair, []*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read PKCS#12 file: %w", err)
+	}
+	return LoadCertKeyPairFromPKCS12(data, password)
+}
@@ -0,0 +1,119 @@
+package cert
+
+import (
+	"crypto/x509"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCertKeyPair_PKCS12(t *testing.T) {
+	for _, keyType := range []KeyType{KeyTypeRSA, KeyTypeECDSA} {
+		t.Run(string(keyType), func(t *testing.T) {
+			ca, err := NewCA(Config{CommonName: "Test CA", KeyType: keyType})
+			if err != nil {
+				t.Fatalf("Failed to create CA: %v", err)
+			}
+			pair, err := ca.NewSignedCert(Config{
+				CommonName: "server",
+				KeyType:    keyType,
+				Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			if err != nil {
+				t.Fatalf("CA.NewSignedCert() error = %v", err)
+			}
+
+			data, err := pair.ToPKCS12("changeit", ca.Certificate)
+			if err != nil {
+				t.Fatalf("CertKeyPair.ToPKCS12() error = %v", err)
+			}
+
+			loaded, chain, err := LoadCertKeyPairFromPKCS12(data, "changeit")
+			if err != nil {
+				t.Fatalf("LoadCertKeyPairFromPKCS12() error = %v", err)
+			}
+			if !loaded.Certificate.Equal(pair.Certificate) {
+				t.Error("loaded certificate doesn't match original")
+			}
+			if !KeyMatchesCert(loaded.PrivateKey, pair.Certificate) {
+				t.Error("loaded private key doesn't match original")
+			}
+			if len(chain) != 1 || !chain[0].Equal(ca.Certificate) {
+				t.Errorf("loaded CA chain = %d certificates, want the CA certificate", len(chain))
+			}
+
+			if _, _, err := LoadCertKeyPairFromPKCS12(data, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+				t.Errorf("LoadCertKeyPairFromPKCS12() error = %v, want %v", err, ErrIncorrectPassword)
+			}
+		})
+	}
+}
+
+func TestCertKeyPair_PKCS12WithoutChain(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	pair := &CertKeyPair{Certificate: ca.Certificate, PrivateKey: ca.PrivateKey}
+
+	data, err := pair.ToPKCS12("")
+	if err != nil {
+		t.Fatalf("CertKeyPair.ToPKCS12() error = %v", err)
+	}
+	_, chain, err := LoadCertKeyPairFromPKCS12(data, "")
+	if err != nil {
+		t.Fatalf("LoadCertKeyPairFromPKCS12() error = %v", err)
+	}
+	if len(chain) != 0 {
+		t.Errorf("loaded CA chain = %d certificates, want 0", len(chain))
+	}
+
+	if _, _, err := LoadCertKeyPairFromPKCS12([]byte("not a pfx"), ""); err == nil {
+		t.Error("LoadCertKeyPairFromPKCS12() should fail for invalid data")
+	}
+	if _, err := (&CertKeyPair{}).ToPKCS12("x"); !errors.Is(err, ErrInvalidCertificate) {
+		t.Errorf("CertKeyPair.ToPKCS12() error = %v, want %v", err, ErrInvalidCertificate)
+	}
+}
+
+func TestPKCS12File(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	pair, err := ca.NewSignedCert(Config{
+		CommonName: "client",
+		KeyType:    KeyTypeRSA,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "client.pfx")
+	if err := pair.SavePKCS12ToFile(path, "changeit", ca.Certificate); err != nil {
+		t.Fatalf("CertKeyPair.SavePKCS12ToFile() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("os.Stat() error = %v", err)
+	}
+	if info.Mode().Perm() != keyFileMode {
+		t.Errorf("file mode = %v, want %v", info.Mode().Perm(), os.FileMode(keyFileMode))
+	}
+
+	loaded, chain, err := ReadCertKeyPairFromPKCS12File(path, "changeit")
+	if err != nil {
+		t.Fatalf("ReadCertKeyPairFromPKCS12File() error = %v", err)
+	}
+	if !loaded.Certificate.Equal(pair.Certificate) || len(chain) != 1 {
+		t.Error("loaded PKCS#12 file doesn't match original")
+	}
+	if _, _, err := ReadCertKeyPairFromPKCS12File(path, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("ReadCertKeyPairFromPKCS12File() error = %v, want %v", err, ErrIncorrectPassword)
+	}
+	if _, _, err := ReadCertKeyPairFromPKCS12File(filepath.Join(t.TempDir(), "missing.pfx"), ""); err == nil {
+		t.Error("ReadCertKeyPairFromPKCS12File() should fail for missing file")
+	}
+}
@@ -0,0 +1,64 @@
+package cert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCA_TrustedClientTLSConfig(t *testing.T) {
+	ca, err := NewCA(Config{CommonName: "Test CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	pair, err := ca.NewSignedCert(Config{
+		CommonName: "localhost",
+		KeyType:    KeyTypeECDSA,
+		AltNames: AltNames{
+			DNSNames: []string{"localhost"},
+			IPs:      []net.IP{net.ParseIP("127.0.0.1")},
+		},
+		Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{pair.Certificate.Raw},
+			PrivateKey:  pair.PrivateKey,
+		}},
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: ca.TrustedClientTLSConfig()}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("client.Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "ok" {
+		t.Errorf("response body = %q, want ok", body)
+	}
+
+	// 其他 CA 的客户端配置无法信任该服务端证书
+	otherCA, err := NewCA(Config{CommonName: "Other CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: otherCA.TrustedClientTLSConfig()}}
+	if resp, err := client.Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Error("client.Get() should fail with an untrusted CA")
+	}
+}
@@ -0,0 +1,46 @@
+package cert
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// VerifyOptions 证书链校验选项
+type VerifyOptions struct {
+	// DNSName 需要校验的主机名或 IP，为空时不校验
+	DNSName string
+	// CurrentTime 校验时使用的时间，为空时使用当前时间
+	CurrentTime time.Time
+	// KeyUsages 允许的扩展密钥用途，为空时默认为 x509.ExtKeyUsageServerAuth
+	KeyUsages []x509.ExtKeyUsage
+}
+
+// VerifyCertChain 使用 roots 作为信任根、intermediates 作为中间证书校验 leaf 证书，
+// 同时校验有效期、扩展密钥用途和主机名，返回所有校验通过的证书链
+func VerifyCertChain(leaf *x509.Certificate, intermediates, roots []*x509.Certificate, opts VerifyOptions) ([][]*x509.Certificate, error) {
+	if leaf == nil {
+		return nil, errors.New("leaf certificate is required")
+	}
+	if len(roots) == 0 {
+		return nil, errors.New("at least one root certificate is required")
+	}
+
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         NewCertPool(roots...),
+		Intermediates: NewCertPool(intermediates...),
+		CurrentTime:   opts.CurrentTime,
+		KeyUsages:     opts.KeyUsages,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify certificate chain of %q: %w", leaf.Subject.CommonName, err)
+	}
+
+	if opts.DNSName != "" {
+		if err := leaf.VerifyHostname(opts.DNSName); err != nil {
+			return nil, fmt.Errorf("failed to verify hostname of %q: %w", leaf.Subject.CommonName, err)
+		}
+	}
+	return chains, nil
+}
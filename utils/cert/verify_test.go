@@ -0,0 +1,119 @@
+package cert
+
+import (
+	"crypto/x509"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyCertChain(t *testing.T) {
+	root, err := NewCA(Config{CommonName: "Root CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	intermediate, err := root.NewIntermediateCA(Config{CommonName: "Intermediate CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("CA.NewIntermediateCA() error = %v", err)
+	}
+	leaf, err := intermediate.NewSignedCert(Config{
+		CommonName: "server",
+		KeyType:    KeyTypeECDSA,
+		AltNames:   AltNames{DNSNames: []string{"server.example.com"}},
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+
+	// 从证书包中解析出叶子证书和中间证书
+	bundle := append(EncodeCertPEM(leaf.Certificate), EncodeCertPEM(intermediate.Certificate)...)
+	certs, err := ParseCertsPEM(bundle)
+	if err != nil {
+		t.Fatalf("ParseCertsPEM() error = %v", err)
+	}
+
+	other, err := NewCA(Config{CommonName: "Other CA", KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		intermediates []*x509.Certificate
+		roots         []*x509.Certificate
+		opts          VerifyOptions
+		wantErr       string
+	}{
+		{
+			name:          "valid chain",
+			intermediates: certs[1:],
+			roots:         []*x509.Certificate{root.Certificate},
+			opts:          VerifyOptions{DNSName: "server.example.com"},
+		},
+		{
+			name:    "missing intermediate",
+			roots:   []*x509.Certificate{root.Certificate},
+			opts:    VerifyOptions{},
+			wantErr: "certificate chain",
+		},
+		{
+			name:          "untrusted root",
+			intermediates: certs[1:],
+			roots:         []*x509.Certificate{other.Certificate},
+			wantErr:       "certificate chain",
+		},
+		{
+			name:          "wrong hostname",
+			intermediates: certs[1:],
+			roots:         []*x509.Certificate{root.Certificate},
+			opts:          VerifyOptions{DNSName: "other.example.com"},
+			wantErr:       "hostname",
+		},
+		{
+			name:          "wrong usage",
+			intermediates: certs[1:],
+			roots:         []*x509.Certificate{root.Certificate},
+			opts:          VerifyOptions{KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}},
+			wantErr:       "certificate chain",
+		},
+		{
+			name:          "expired",
+			intermediates: certs[1:],
+			roots:         []*x509.Certificate{root.Certificate},
+			opts:          VerifyOptions{CurrentTime: time.Now().AddDate(20, 0, 0)},
+			wantErr:       "certificate chain",
+		},
+		{
+			name:    "no roots",
+			wantErr: "root certificate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chains, err := VerifyCertChain(certs[0], tt.intermediates, tt.roots, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("VerifyCertChain() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyCertChain() error = %v", err)
+			}
+			if len(chains) != 1 || len(chains[0]) != 3 {
+				t.Fatalf("VerifyCertChain() chains = %v, want one chain of 3 certs", chains)
+			}
+			if !chains[0][2].Equal(root.Certificate) {
+				t.Error("chain should end with the root certificate")
+			}
+		})
+	}
+
+	var hostErr x509.HostnameError
+	_, err = VerifyCertChain(certs[0], certs[1:], []*x509.Certificate{root.Certificate}, VerifyOptions{DNSName: "other.example.com"})
+	if !errors.As(err, &hostErr) {
+		t.Errorf("VerifyCertChain() error = %v, want x509.HostnameError", err)
+	}
+}
@@ -0,0 +1,159 @@
+package cert
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval WatchingCertPool 检查文件变化的默认间隔
+const DefaultWatchInterval = 10 * time.Second
+
+// CertPoolWatcher 从一个或多个文件加载证书池，定期或在调用 ReloadNow 时检查文件内容，变化后重新加载
+// 新文件解析失败时继续使用之前的证书池，可通过 LastError 获取失败原因
+type CertPoolWatcher struct {
+	paths    []string
+	interval time.Duration
+
+	// reloadMu 保证同一时间只有一个重新加载过程
+	reloadMu sync.Mutex
+
+	mu      sync.RWMutex
+	pool    *x509.CertPool
+	sum     [sha256.Size]byte
+	lastErr error
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// WatchingCertPool 从单个文件加载的 CertPoolWatcher
+type WatchingCertPool = CertPoolWatcher
+
+// NewWatchingCertPool 从 path 加载证书池，并按 DefaultWatchInterval 检查文件变化
+// 首次加载失败时返回错误，使用完毕后需要调用 Close 停止检查
+func NewWatchingCertPool(path string) (*WatchingCertPool, error) {
+	return NewCertPoolWatcher(DefaultWatchInterval, path)
+}
+
+// NewCertPoolWatcher 从 paths 中的所有文件加载证书池，并按 interval 检查文件变化
+// interval 小于等于 0 时不在后台检查，只在调用 ReloadNow 时重新加载
+// 首次加载失败时返回错误，使用完毕后需要调用 Close 停止检查
+func NewCertPoolWatcher(interval time.Duration, paths ...string) (*CertPoolWatcher, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("at least one certificate file is required")
+	}
+	w := &CertPoolWatcher{
+		paths:    paths,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if _, err := w.reload(); err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		go w.run()
+	} else {
+		close(w.done)
+	}
+	return w, nil
+}
+
+// Pool 返回当前的证书池，可并发调用，返回的证书池不应被修改
+func (w *CertPoolWatcher) Pool() *x509.CertPool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.pool
+}
+
+// LastError 返回最近一次重新加载的错误，加载成功后清空
+func (w *CertPoolWatcher) LastError() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.lastErr
+}
+
+// ReloadNow 立即检查文件变化并重新加载，返回本次加载的错误
+func (w *CertPoolWatcher) ReloadNow() error {
+	_, err := w.reload()
+	w.setLastError(err)
+	return err
+}
+
+// Close 停止检查文件变化，可重复调用
+func (w *CertPoolWatcher) Close() error {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+	return nil
+}
+
+func (w *CertPoolWatcher) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			_, err := w.reload()
+			w.setLastError(err)
+		}
+	}
+}
+
+func (w *CertPoolWatcher) setLastError(err error) {
+	w.mu.Lock()
+	w.lastErr = err
+	w.mu.Unlock()
+}
+
+// reload 文件内容变化时重新加载证书池，返回是否发生了更新
+func (w *CertPoolWatcher) reload() (bool, error) {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	contents := make([][]byte, 0, len(w.paths))
+	h := sha256.New()
+	for _, path := range w.paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false, fmt.Errorf("failed to read certificate file: %w", err)
+		}
+		sum := sha256.Sum256(data)
+		h.Write(sum[:])
+		contents = append(contents, data)
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+
+	w.mu.RLock()
+	unchanged := w.pool != nil && sum == w.sum
+	w.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	var certs []*x509.Certificate
+	for i, data := range contents {
+		parsed, err := ParseCertsPEM(data)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", w.paths[i], err)
+		}
+		certs = append(certs, parsed...)
+	}
+
+	w.mu.Lock()
+	w.pool = NewCertPool(certs...)
+	w.sum = sum
+	w.mu.Unlock()
+	return true, nil
+}
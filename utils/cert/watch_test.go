@@ -0,0 +1,169 @@
+package cert
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newWatchTestCA(t *testing.T, cn string) (*CA, *x509.Certificate) {
+	t.Helper()
+	ca, err := NewCA(Config{CommonName: cn, KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	pair, err := ca.NewSignedCert(Config{CommonName: cn + " leaf", KeyType: KeyTypeECDSA, Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+	if err != nil {
+		t.Fatalf("CA.NewSignedCert() error = %v", err)
+	}
+	return ca, pair.Certificate
+}
+
+func trustedBy(pool *x509.CertPool, leaf *x509.Certificate) bool {
+	_, err := leaf.Verify(x509.VerifyOptions{Roots: pool})
+	return err == nil
+}
+
+func TestWatchingCertPool(t *testing.T) {
+	waitFor := func(cond func() bool) bool {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if cond() {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+
+	caA, leafA := newWatchTestCA(t, "CA A")
+	caB, leafB := newWatchTestCA(t, "CA B")
+
+	path := filepath.Join(t.TempDir(), "ca.crt")
+	if err := WriteCertToFile(path, caA.Certificate); err != nil {
+		t.Fatalf("WriteCertToFile() error = %v", err)
+	}
+
+	w, err := NewCertPoolWatcher(10*time.Millisecond, path)
+	if err != nil {
+		t.Fatalf("NewCertPoolWatcher() error = %v", err)
+	}
+	defer w.Close()
+
+	if !trustedBy(w.Pool(), leafA) || trustedBy(w.Pool(), leafB) {
+		t.Fatal("initial pool should only trust CA A")
+	}
+
+	// 轮换 CA 证书包
+	if err := WriteCertsToFile(path, caA.Certificate, caB.Certificate); err != nil {
+		t.Fatalf("WriteCertsToFile() error = %v", err)
+	}
+	if !waitFor(func() bool { return trustedBy(w.Pool(), leafB) }) {
+		t.Fatal("pool should trust CA B after reload")
+	}
+
+	// 新文件无效时继续使用之前的证书池
+	if err := os.WriteFile(path, []byte("invalid"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if !waitFor(func() bool { return w.LastError() != nil }) {
+		t.Fatal("LastError() should report the invalid bundle")
+	}
+	if !trustedBy(w.Pool(), leafA) || !trustedBy(w.Pool(), leafB) {
+		t.Error("pool should keep the previous certificates when reload fails")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+
+	if _, err := NewWatchingCertPool(filepath.Join(t.TempDir(), "missing.crt")); err == nil {
+		t.Error("NewWatchingCertPool() should fail for missing file")
+	}
+}
+
+func TestCertPoolWatcherReloadNow(t *testing.T) {
+	caA, leafA := newWatchTestCA(t, "CA A")
+	caB, leafB := newWatchTestCA(t, "CA B")
+	caC, leafC := newWatchTestCA(t, "CA C")
+
+	dir := t.TempDir()
+	pathA := filepath.Join(dir, "a.crt")
+	pathB := filepath.Join(dir, "b.crt")
+	if err := WriteCertToFile(pathA, caA.Certificate); err != nil {
+		t.Fatalf("WriteCertToFile() error = %v", err)
+	}
+	if err := WriteCertToFile(pathB, caB.Certificate); err != nil {
+		t.Fatalf("WriteCertToFile() error = %v", err)
+	}
+
+	// 不在后台检查，只通过 ReloadNow 重新加载
+	w, err := NewCertPoolWatcher(0, pathA, pathB)
+	if err != nil {
+		t.Fatalf("NewCertPoolWatcher() error = %v", err)
+	}
+	defer w.Close()
+	if !trustedBy(w.Pool(), leafA) || !trustedBy(w.Pool(), leafB) || trustedBy(w.Pool(), leafC) {
+		t.Fatal("initial pool should trust CA A and CA B only")
+	}
+
+	// 并发读取证书池的同时重新加载
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					if w.Pool() == nil {
+						t.Error("Pool() returned nil during reload")
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	if err := WriteCertToFile(pathB, caC.Certificate); err != nil {
+		t.Fatalf("WriteCertToFile() error = %v", err)
+	}
+	if trustedBy(w.Pool(), leafC) {
+		t.Error("pool should not change before ReloadNow")
+	}
+	if err := w.ReloadNow(); err != nil {
+		t.Fatalf("ReloadNow() error = %v", err)
+	}
+	if !trustedBy(w.Pool(), leafA) || trustedBy(w.Pool(), leafB) || !trustedBy(w.Pool(), leafC) {
+		t.Error("pool should trust CA A and CA C after reload")
+	}
+
+	if err := os.WriteFile(pathA, []byte("invalid"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := w.ReloadNow(); err == nil {
+		t.Error("ReloadNow() should fail for an invalid bundle")
+	}
+	if w.LastError() == nil {
+		t.Error("LastError() should report the invalid bundle")
+	}
+	if !trustedBy(w.Pool(), leafA) || !trustedBy(w.Pool(), leafC) {
+		t.Error("pool should keep the previous certificates when reload fails")
+	}
+
+	close(stop)
+	wg.Wait()
+
+	if _, err := NewCertPoolWatcher(0); err == nil {
+		t.Error("NewCertPoolWatcher() without paths should fail")
+	}
+}
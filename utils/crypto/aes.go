@@ -4,8 +4,14 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
+	"errors"
 )
 
+// ErrInvalidPadding is returned by AESCBCDecrypt when the decrypted text has no valid PKCS#7 padding,
+// typically because the key is wrong or the cipher text was modified
+var ErrInvalidPadding = errors.New("invalid padding")
+
 // PKCS7Padding fills plaintext as an integral multiple of the block length
 func PKCS7Padding(p []byte, blockSize int) []byte {
 	pad := blockSize - len(p)%blockSize
@@ -45,8 +51,64 @@ func AESCBCDecrypt(ciphertext, key []byte) ([]byte, error) {
 		return nil, err
 	}
 
+	if len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, errors.New("ciphertext is not a multiple of the block size")
+	}
 	plaintext := make([]byte, len(ciphertext))
 	blockMode := cipher.NewCBCDecrypter(block, key[:block.BlockSize()])
 	blockMode.CryptBlocks(plaintext, ciphertext)
-	return PKCS7UnPadding(plaintext), nil
+	return pkcs7Unpad(plaintext, block.BlockSize())
+}
+
+// pkcs7Unpad is PKCS7UnPadding with validation, a wrong key usually yields invalid padding
+func pkcs7Unpad(p []byte, blockSize int) ([]byte, error) {
+	padLen := int(p[len(p)-1])
+	if padLen == 0 || padLen > blockSize || padLen > len(p) {
+		return nil, ErrInvalidPadding
+	}
+	for _, b := range p[len(p)-padLen:] {
+		if int(b) != padLen {
+			return nil, ErrInvalidPadding
+		}
+	}
+	return p[:len(p)-padLen], nil
+}
+
+// AESGCMEncrypt encrypts data with AES algorithm in GCM mode
+// A random nonce is generated and prepended to the returned cipher text
+// Note that key length must be 16, 24 or 32 bytes to select AES-128, AES-192, or AES-256
+func AESGCMEncrypt(text, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, text, nil), nil
+}
+
+// AESGCMDecrypt decrypts cipher text produced by AESGCMEncrypt
+// An error is returned if the cipher text was tampered with or the key is wrong
+func AESGCMDecrypt(ciphertext, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
 }
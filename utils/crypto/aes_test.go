@@ -0,0 +1,87 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestAESCBC(t *testing.T) {
+	key := []byte("12345678abcdefgh12345678abcdefgh")
+	text := []byte("hello world")
+
+	ciphertext, err := AESCBCEncrypt(append([]byte(nil), text...), key)
+	if err != nil {
+		t.Fatalf("AESCBCEncrypt() error = %v", err)
+	}
+	plaintext, err := AESCBCDecrypt(ciphertext, key)
+	if err != nil {
+		t.Fatalf("AESCBCDecrypt() error = %v", err)
+	}
+	if !bytes.Equal(plaintext, text) {
+		t.Errorf("AESCBCDecrypt() = %q, want %q", plaintext, text)
+	}
+}
+
+func TestAESCBCInvalidInput(t *testing.T) {
+	key := []byte("12345678abcdefgh12345678abcdefgh")
+	ciphertext, err := AESCBCEncrypt([]byte("hello world"), key)
+	if err != nil {
+		t.Fatalf("AESCBCEncrypt() error = %v", err)
+	}
+
+	if _, err := AESCBCDecrypt(ciphertext, []byte("abcdefgh12345678abcdefgh12345678")); !errors.Is(err, ErrInvalidPadding) {
+		t.Errorf("AESCBCDecrypt() with wrong key error = %v, want ErrInvalidPadding", err)
+	}
+	for _, invalid := range [][]byte{nil, ciphertext[:len(ciphertext)-1]} {
+		if _, err := AESCBCDecrypt(invalid, key); err == nil {
+			t.Errorf("AESCBCDecrypt(%d bytes) should fail", len(invalid))
+		}
+	}
+}
+
+func TestAESGCM(t *testing.T) {
+	key := []byte("12345678abcdefgh12345678abcdefgh")
+	text := []byte("JBSWY3DPEHPK3PXP")
+
+	ciphertext, err := AESGCMEncrypt(text, key)
+	if err != nil {
+		t.Fatalf("AESGCMEncrypt() error = %v", err)
+	}
+	if bytes.Contains(ciphertext, text) {
+		t.Error("cipher text should not contain the plain text")
+	}
+	other, err := AESGCMEncrypt(text, key)
+	if err != nil {
+		t.Fatalf("AESGCMEncrypt() error = %v", err)
+	}
+	if bytes.Equal(ciphertext, other) {
+		t.Error("AESGCMEncrypt() should use a random nonce")
+	}
+
+	plaintext, err := AESGCMDecrypt(ciphertext, key)
+	if err != nil {
+		t.Fatalf("AESGCMDecrypt() error = %v", err)
+	}
+	if !bytes.Equal(plaintext, text) {
+		t.Errorf("AESGCMDecrypt() = %q, want %q", plaintext, text)
+	}
+
+	tests := []struct {
+		name       string
+		ciphertext []byte
+		key        []byte
+	}{
+		{name: "wrong key", ciphertext: ciphertext, key: []byte("abcdefgh12345678abcdefgh12345678")},
+		{name: "tampered", ciphertext: append(append([]byte(nil), ciphertext[:len(ciphertext)-1]...), ciphertext[len(ciphertext)-1]^0xff), key: key},
+		{name: "too short", ciphertext: []byte("short"), key: key},
+		{name: "invalid key size", ciphertext: ciphertext, key: []byte("short")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := AESGCMDecrypt(tt.ciphertext, tt.key); err == nil {
+				t.Error("AESGCMDecrypt() should fail")
+			}
+		})
+	}
+}
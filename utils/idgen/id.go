@@ -1,40 +1,62 @@
 package idgen
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/sony/sonyflake"
 )
 
 var (
-	_sf   *sonyflake.Sonyflake
-	_once sync.Once
+	_sf        *sonyflake.Sonyflake
+	_startTime time.Time
+	_initErr   error
+	_once      sync.Once
 )
 
+// defaultStartTime sonyflake 默认的起始时间
+var defaultStartTime = time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+
+// ErrNotInitialized sonyflake 初始化失败（例如 StartTime 非法或无法获取 MachineID）
+var ErrNotInitialized = errors.New("failed to initialize sonyflake")
+
 // Initialize 初始化 ID 生成器，可选配置
 // 如果不调用此函数，将使用默认配置
-func Initialize(settings sonyflake.Settings) {
+// 仅第一次调用生效，返回初始化结果
+func Initialize(settings sonyflake.Settings) error {
 	_once.Do(func() {
 		_sf = sonyflake.NewSonyflake(settings)
 		if _sf == nil {
-			panic("failed to initialize sonyflake")
+			_initErr = ErrNotInitialized
+			return
+		}
+		_startTime = defaultStartTime
+		if !settings.StartTime.IsZero() {
+			// sonyflake 内部以 10ms 为单位记录起始时间
+			_startTime = settings.StartTime.UTC().Truncate(10 * time.Millisecond)
 		}
 	})
+	return _initErr
 }
 
 // getSonyflake 获取或初始化 sonyflake 实例
-func getSonyflake() *sonyflake.Sonyflake {
-	if _sf == nil {
-		Initialize(sonyflake.Settings{})
+func getSonyflake() (*sonyflake.Sonyflake, error) {
+	if err := Initialize(sonyflake.Settings{}); err != nil {
+		return nil, err
 	}
-	return _sf
+	return _sf, nil
 }
 
 // NextID 生成下一个唯一 ID
 func NextID() (uint64, error) {
-	return getSonyflake().NextID()
+	sf, err := getSonyflake()
+	if err != nil {
+		return 0, err
+	}
+	return sf.NextID()
 }
 
 // MustNextID 生成下一个唯一 ID，出错时 panic
@@ -84,3 +106,32 @@ func MustNextIDStringWithPrefix(prefix string) string {
 	}
 	return id
 }
+
+// IDTime 返回 ID 中记录的生成时间（UTC，精度 10ms）
+// 需要在 ID 生成器初始化之后调用
+func IDTime(id uint64) time.Time {
+	return _startTime.Add(sonyflake.ElapsedTime(id))
+}
+
+// NextPrefixedDateID 生成 prefix-YYYYMMDD-<id> 格式的 ID
+// 日期取自 ID 中记录的生成时间（UTC），<id> 补齐为 20 位以保证同一天内按字典序有序
+func NextPrefixedDateID(prefix string) (string, error) {
+	id, err := NextID()
+	if err != nil {
+		return "", err
+	}
+	date := IDTime(id).Format("20060102")
+	if prefix == "" {
+		return fmt.Sprintf("%s-%020d", date, id), nil
+	}
+	return fmt.Sprintf("%s-%s-%020d", prefix, date, id), nil
+}
+
+// MustNextPrefixedDateID 生成 prefix-YYYYMMDD-<id> 格式的 ID，出错时 panic
+func MustNextPrefixedDateID(prefix string) string {
+	id, err := NextPrefixedDateID(prefix)
+	if err != nil {
+		panic(fmt.Errorf("failed to generate prefixed date ID: %w", err))
+	}
+	return id
+}
@@ -1,11 +1,13 @@
 package idgen
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/sony/sonyflake"
 )
@@ -216,6 +218,96 @@ func TestInitialize(t *testing.T) {
 	}
 }
 
+// resetForTest 重置全局 sonyflake 实例，使下一次调用重新初始化
+func resetForTest() {
+	_sf = nil
+	_initErr = nil
+	_once = sync.Once{}
+}
+
+// TestInitializeFailure 测试初始化失败时 NextID 返回错误而非 panic
+func TestInitializeFailure(t *testing.T) {
+	resetForTest()
+	t.Cleanup(resetForTest)
+
+	err := Initialize(sonyflake.Settings{
+		MachineID: func() (uint16, error) {
+			return 0, errors.New("no machine id")
+		},
+	})
+	if !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Initialize() error = %v, want %v", err, ErrNotInitialized)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("NextID() should not panic, got: %v", r)
+			}
+		}()
+		if _, err := NextID(); !errors.Is(err, ErrNotInitialized) {
+			t.Errorf("NextID() error = %v, want %v", err, ErrNotInitialized)
+		}
+		if _, err := NextIDStringWithPrefix("user"); !errors.Is(err, ErrNotInitialized) {
+			t.Errorf("NextIDStringWithPrefix() error = %v, want %v", err, ErrNotInitialized)
+		}
+	}()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("MustNextID() should panic when initialization failed")
+		}
+	}()
+	MustNextID()
+}
+
+// TestNextPrefixedDateID 测试带日期的前缀 ID
+func TestNextPrefixedDateID(t *testing.T) {
+	resetForTest()
+	t.Cleanup(resetForTest)
+
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := Initialize(sonyflake.Settings{
+		StartTime: startTime,
+		MachineID: func() (uint16, error) { return 1, nil },
+	}); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	id1, err := NextPrefixedDateID("order")
+	if err != nil {
+		t.Fatalf("NextPrefixedDateID() error = %v", err)
+	}
+	id2 := MustNextPrefixedDateID("order")
+
+	if id1 == id2 {
+		t.Errorf("NextPrefixedDateID() returned duplicate IDs: %s", id1)
+	}
+	if id1 >= id2 {
+		t.Errorf("NextPrefixedDateID() IDs are not sortable: %s >= %s", id1, id2)
+	}
+
+	parts := strings.SplitN(id1, "-", 3)
+	if len(parts) != 3 || parts[0] != "order" {
+		t.Fatalf("NextPrefixedDateID() returned invalid format: %s", id1)
+	}
+	id, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		t.Fatalf("NextPrefixedDateID() returned invalid ID part: %v", err)
+	}
+	if want := IDTime(id).Format("20060102"); parts[1] != want {
+		t.Errorf("date segment = %s, want %s", parts[1], want)
+	}
+	if d := time.Since(IDTime(id)); d < 0 || d > time.Minute {
+		t.Errorf("IDTime() = %v, too far from now", IDTime(id))
+	}
+
+	noPrefix := MustNextPrefixedDateID("")
+	if strings.Count(noPrefix, "-") != 1 {
+		t.Errorf("NextPrefixedDateID(\"\") returned invalid format: %s", noPrefix)
+	}
+}
+
 // TestConcurrentNextID 测试并发生成 ID 的正确性
 func TestConcurrentNextID(t *testing.T) {
 	const (
@@ -0,0 +1,49 @@
+package labels
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Matches reports whether labels satisfy the equality-based selector.
+// Every key in selector must exist in labels with the same value; an empty
+// selector matches everything.
+func Matches(selector map[string]string, labels map[string]string) bool {
+	for k, v := range selector {
+		if lv, ok := labels[k]; !ok || lv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSelector parses a selector in "k1=v1,k2=v2" form. Whitespace around
+// keys and values is trimmed, "==" is accepted as an alias of "=", and an
+// empty string yields an empty selector.
+func ParseSelector(s string) (map[string]string, error) {
+	selector := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return selector, nil
+	}
+
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return nil, fmt.Errorf("invalid selector %q: empty requirement", s)
+		}
+		kv := strings.SplitN(term, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid selector requirement %q: missing '='", term)
+		}
+		key := strings.TrimSpace(kv[0])
+		value := strings.TrimSpace(strings.TrimPrefix(kv[1], "="))
+		if key == "" {
+			return nil, fmt.Errorf("invalid selector requirement %q: empty key", term)
+		}
+		if _, ok := selector[key]; ok {
+			return nil, fmt.Errorf("invalid selector %q: duplicate key %s", s, key)
+		}
+		selector[key] = value
+	}
+	return selector, nil
+}
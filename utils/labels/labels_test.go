@@ -0,0 +1,61 @@
+package labels
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	labels := map[string]string{"app": "web", "env": "prod"}
+
+	tests := []struct {
+		name     string
+		selector map[string]string
+		want     bool
+	}{
+		{name: "empty selector", selector: map[string]string{}, want: true},
+		{name: "nil selector", selector: nil, want: true},
+		{name: "match", selector: map[string]string{"app": "web"}, want: true},
+		{name: "match all", selector: map[string]string{"app": "web", "env": "prod"}, want: true},
+		{name: "missing key", selector: map[string]string{"tier": "frontend"}, want: false},
+		{name: "wrong value", selector: map[string]string{"env": "dev"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Matches(tt.selector, labels); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: map[string]string{}},
+		{name: "single", input: "app=web", want: map[string]string{"app": "web"}},
+		{name: "multiple with spaces", input: "app = web, env==prod", want: map[string]string{"app": "web", "env": "prod"}},
+		{name: "empty value", input: "app=", want: map[string]string{"app": ""}},
+		{name: "missing equal", input: "app", wantErr: true},
+		{name: "empty key", input: "=web", wantErr: true},
+		{name: "trailing comma", input: "app=web,", wantErr: true},
+		{name: "duplicate key", input: "app=web,app=api", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSelector(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSelector() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package maps
+
+import "net/http"
+
+// ToHTTPHeader converts m to an http.Header, canonicalizing every key.
+func ToHTTPHeader(m map[string]string) http.Header {
+	h := make(http.Header, len(m))
+	for k, v := range m {
+		h.Set(k, v)
+	}
+	return h
+}
+
+// FromHTTPHeader converts h to a map keyed by canonical header name, taking
+// the first value of each key. Keys without values are skipped.
+func FromHTTPHeader(h http.Header) map[string]string {
+	m := make(map[string]string, len(h))
+	for k, vs := range h {
+		if len(vs) == 0 {
+			continue
+		}
+		m[http.CanonicalHeaderKey(k)] = vs[0]
+	}
+	return m
+}
@@ -2,9 +2,12 @@ package maps
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
+// ToSlice converts m to a list of "k=v" strings in map iteration order,
+// which is random. Use ToSortedSlice when a stable order is required.
 func ToSlice(m map[string]string) []string {
 	slice := make([]string, 0, len(m))
 	for k, v := range m {
@@ -13,11 +16,28 @@ func ToSlice(m map[string]string) []string {
 	return slice
 }
 
+// ToSortedSlice converts m to a list of "k=v" strings sorted by key.
+func ToSortedSlice(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	slice := make([]string, 0, len(m))
+	for _, k := range keys {
+		slice = append(slice, fmt.Sprintf("%s=%s", k, m[k]))
+	}
+	return slice
+}
+
 func FromString(data string, sep string) map[string]string {
 	list := strings.Split(data, sep)
 	return FromSlice(list)
 }
 
+// FromSlice parses "k=v" entries into a map. Entries without "=" are ignored
+// and duplicated keys are last-write-wins; use FromSliceStrict to reject them.
 func FromSlice(data []string) map[string]string {
 	m := make(map[string]string)
 	for _, l := range data {
@@ -31,6 +51,24 @@ func FromSlice(data []string) map[string]string {
 	return m
 }
 
+// FromSliceStrict is like FromSlice but returns an error naming the first
+// duplicated key instead of silently overwriting it.
+func FromSliceStrict(data []string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, l := range data {
+		if l != "" {
+			kv := strings.SplitN(l, "=", 2)
+			if len(kv) == 2 {
+				if _, ok := m[kv[0]]; ok {
+					return nil, fmt.Errorf("duplicate key: %s", kv[0])
+				}
+				m[kv[0]] = kv[1]
+			}
+		}
+	}
+	return m, nil
+}
+
 func Merge(ms ...map[string]string) map[string]string {
 	res := map[string]string{}
 	for _, m := range ms {
@@ -71,9 +109,46 @@ func GetFromKeys(m map[string]string, keys ...string) string {
 	return ""
 }
 
+// SetKeys sets every key in keys to value in m. It mutates m and returns the same map,
+// so m must not be nil; use WithKeys to leave the input untouched.
 func SetKeys(m map[string]string, keys []string, value string) map[string]string {
 	for _, v := range keys {
 		m[v] = value
 	}
 	return m
 }
+
+// WithKeys returns a new map holding the entries of m plus every key in keys set to value.
+// m is not modified and may be nil.
+func WithKeys(m map[string]string, keys []string, value string) map[string]string {
+	res := make(map[string]string, len(m)+len(keys))
+	for k, v := range m {
+		res[k] = v
+	}
+	return SetKeys(res, keys, value)
+}
+
+// Equal reports whether two maps contain the same key/value pairs.
+func Equal[K, V comparable](a, b map[K]V) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, va := range a {
+		if vb, ok := b[k]; !ok || va != vb {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a shallow copy of m. A nil map is cloned to nil.
+func Clone[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	res := make(map[K]V, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,142 @@
+package maps
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[string]string
+		want bool
+	}{
+		{name: "both nil", a: nil, b: nil, want: true},
+		{name: "nil and empty", a: nil, b: map[string]string{}, want: true},
+		{name: "equal", a: map[string]string{"a": "1", "b": "2"}, b: map[string]string{"b": "2", "a": "1"}, want: true},
+		{name: "different value", a: map[string]string{"a": "1"}, b: map[string]string{"a": "2"}, want: false},
+		{name: "different key", a: map[string]string{"a": "1"}, b: map[string]string{"b": "1"}, want: false},
+		{name: "different length", a: map[string]string{"a": "1"}, b: map[string]string{"a": "1", "b": "2"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClone(t *testing.T) {
+	if Clone[string, string](nil) != nil {
+		t.Error("Clone(nil) should return nil")
+	}
+
+	src := map[string]string{"a": "1", "b": "2"}
+	dst := Clone(src)
+	if !Equal(src, dst) {
+		t.Fatalf("Clone() = %v, want %v", dst, src)
+	}
+
+	dst["a"] = "changed"
+	dst["c"] = "3"
+	if src["a"] != "1" || len(src) != 2 {
+		t.Errorf("modifying clone changed the original: %v", src)
+	}
+}
+
+func TestWithKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		m     map[string]string
+		keys  []string
+		value string
+		want  map[string]string
+	}{
+		{name: "nil map", m: nil, keys: []string{"a"}, value: "1", want: map[string]string{"a": "1"}},
+		{name: "merge", m: map[string]string{"a": "1", "b": "2"}, keys: []string{"c", "d"}, value: "x", want: map[string]string{"a": "1", "b": "2", "c": "x", "d": "x"}},
+		{name: "overwrite", m: map[string]string{"a": "1", "b": "2"}, keys: []string{"a"}, value: "x", want: map[string]string{"a": "x", "b": "2"}},
+		{name: "no keys", m: map[string]string{"a": "1"}, keys: nil, value: "x", want: map[string]string{"a": "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := Clone(tt.m)
+			got := WithKeys(tt.m, tt.keys, tt.value)
+			if !Equal(got, tt.want) {
+				t.Errorf("WithKeys() = %v, want %v", got, tt.want)
+			}
+			if !Equal(tt.m, original) {
+				t.Errorf("WithKeys() modified the input: %v, want %v", tt.m, original)
+			}
+			got["new"] = "value"
+			if _, ok := tt.m["new"]; ok {
+				t.Error("WithKeys() result aliases the input")
+			}
+		})
+	}
+}
+
+func TestToSortedSlice(t *testing.T) {
+	m := map[string]string{"c": "3", "a": "1", "b": "2", "d": ""}
+	want := []string{"a=1", "b=2", "c=3", "d="}
+
+	for i := 0; i < 20; i++ {
+		if got := ToSortedSlice(m); !reflect.DeepEqual(got, want) {
+			t.Fatalf("ToSortedSlice() = %v, want %v", got, want)
+		}
+	}
+
+	if got := ToSortedSlice(nil); len(got) != 0 {
+		t.Errorf("ToSortedSlice(nil) = %v, want empty", got)
+	}
+}
+
+func TestFromSliceStrict(t *testing.T) {
+	got, err := FromSliceStrict([]string{"a=1", "b=2", "", "invalid", "c=x=y"})
+	if err != nil {
+		t.Fatalf("FromSliceStrict() error = %v", err)
+	}
+	want := map[string]string{"a": "1", "b": "2", "c": "x=y"}
+	if !Equal(got, want) {
+		t.Errorf("FromSliceStrict() = %v, want %v", got, want)
+	}
+
+	_, err = FromSliceStrict([]string{"a=1", "b=2", "a=3"})
+	if err == nil {
+		t.Fatal("FromSliceStrict() should fail with duplicate key")
+	}
+	if !strings.Contains(err.Error(), "duplicate key: a") {
+		t.Errorf("FromSliceStrict() error = %v, want it to name the duplicated key", err)
+	}
+
+	// FromSlice 保持后写覆盖的行为
+	if got := FromSlice([]string{"a=1", "a=3"}); got["a"] != "3" {
+		t.Errorf("FromSlice() a = %v, want 3", got["a"])
+	}
+}
+
+func TestHTTPHeader(t *testing.T) {
+	h := ToHTTPHeader(map[string]string{
+		"content-type": "application/json",
+		"X-REQUEST-ID": "abc",
+	})
+	if got := h.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %v, want application/json", got)
+	}
+	if _, ok := h["X-Request-Id"]; !ok {
+		t.Errorf("header keys should be canonicalized: %v", h)
+	}
+
+	h.Add("X-Request-Id", "def")
+	got := FromHTTPHeader(h)
+	want := map[string]string{
+		"Content-Type": "application/json",
+		"X-Request-Id": "abc",
+	}
+	if !Equal(got, want) {
+		t.Errorf("FromHTTPHeader() = %v, want %v", got, want)
+	}
+}
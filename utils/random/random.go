@@ -128,6 +128,8 @@ func RandStringBytesMaskImprSrcUnsafe(n int) string {
 
 const digitBytes = "0123456789"
 
+// RandDigitString returns a string of n random digits. The first digit may be '0', so the result
+// must be treated as a string; use RandDigitStringNoLeadingZero if it may be parsed as an integer.
 func RandDigitString(n int) string {
 	sb := strings.Builder{}
 	sb.Grow(n)
@@ -146,3 +148,12 @@ func RandDigitString(n int) string {
 
 	return sb.String()
 }
+
+// RandDigitStringNoLeadingZero returns a string of n random digits whose first digit is never '0',
+// so it keeps its length when parsed as an integer.
+func RandDigitStringNoLeadingZero(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return string(digitBytes[1+rand.Intn(9)]) + RandDigitString(n-1)
+}
@@ -0,0 +1,34 @@
+package random
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRandDigitStringNoLeadingZero(t *testing.T) {
+	for _, n := range []int{1, 4, 6, 8, 20} {
+		for i := 0; i < 1000; i++ {
+			code := RandDigitStringNoLeadingZero(n)
+			if len(code) != n {
+				t.Fatalf("RandDigitStringNoLeadingZero(%d) = %q, want length %d", n, code, n)
+			}
+			if code[0] == '0' {
+				t.Fatalf("RandDigitStringNoLeadingZero(%d) = %q starts with 0", n, code)
+			}
+			for _, c := range code {
+				if c < '0' || c > '9' {
+					t.Fatalf("RandDigitStringNoLeadingZero(%d) = %q contains non-digit", n, code)
+				}
+			}
+			if n <= 18 {
+				v, err := strconv.ParseInt(code, 10, 64)
+				if err != nil || len(strconv.FormatInt(v, 10)) != n {
+					t.Fatalf("RandDigitStringNoLeadingZero(%d) = %q loses digits when parsed", n, code)
+				}
+			}
+		}
+	}
+	if code := RandDigitStringNoLeadingZero(0); code != "" {
+		t.Errorf("RandDigitStringNoLeadingZero(0) = %q, want empty", code)
+	}
+}